@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -12,7 +13,16 @@ import (
 	"github.com/nikoremi97/debate/internal/api"
 	"github.com/nikoremi97/debate/internal/auth"
 	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/events"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/experiments"
+	"github.com/nikoremi97/debate/internal/formats"
+	"github.com/nikoremi97/debate/internal/judge"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/personas"
+	"github.com/nikoremi97/debate/internal/prompts"
 	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/nikoremi97/debate/internal/summary"
 )
 
 func main() {
@@ -29,7 +39,8 @@ func main() {
 	// Initialize services
 	authService := initializeAuthService()
 	store := initializeStorage(redisAddr)
-	llm := bot.NewOpenAIEngine(openAIKey, openAIModel)
+	promptRegistry := initializePrompts()
+	llm := initializeEngine(openAIKey, openAIModel, promptRegistry)
 
 	r := gin.Default()
 
@@ -39,7 +50,26 @@ func main() {
 
 	// Register routes
 	registerHealthRoutes(r, store)
-	api.RegisterRoutes(r, store, llm)
+	topicPolicy := initializeTopicPolicy()
+	routeOpts := []api.RouteOption{
+		api.WithTopicPolicy(topicPolicy),
+		api.WithModerator(initializeModerator(topicPolicy, openAIKey)),
+		api.WithExperiments(initializeExperiments(promptRegistry)),
+		api.WithPersonas(initializePersonas()),
+		api.WithFormats(initializeFormats()),
+		api.WithJudge(initializeJudge(llm)),
+		api.WithEvents(initializeEvents(redisAddr)),
+		api.WithEvidence(initializeEvidence()),
+		api.WithPrompts(promptRegistry),
+		api.WithSummarizer(initializeSummarizer(llm)),
+	}
+
+	if getenv("TOOL_CALLING", "false") == "true" {
+		log.Println("tool calling enabled for debate replies")
+		routeOpts = append(routeOpts, api.WithToolCalling())
+	}
+
+	api.RegisterRoutes(r, store, llm, routeOpts...)
 
 	log.Printf("listening on :%s", port)
 
@@ -58,7 +88,7 @@ func CORSMiddleware() gin.HandlerFunc {
 		}
 
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, accept, origin, Cache-Control, X-Requested-With")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, X-User-ID, accept, origin, Cache-Control, X-Requested-With")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE, PATCH")
 		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
 
@@ -90,6 +120,182 @@ func initializeAuthService() auth.ServiceInterface {
 	return authService
 }
 
+// initializeEngine wraps the OpenAI engine with the prompt-injection guard. INJECTION_GUARD=llm
+// adds LLM-backed detection and stance checks on top of the heuristics (two extra calls per turn).
+func initializeEngine(openAIKey, openAIModel string, promptRegistry *prompts.Registry) bot.Engine {
+	openAI := bot.NewOpenAIEngine(openAIKey, openAIModel).WithPrompts(promptRegistry)
+	guarded := bot.NewGuardedEngine(openAI)
+
+	if getenv("INJECTION_GUARD", "heuristic") == "llm" {
+		guarded.WithDetector(bot.NewLLMDetector(openAI)).WithChecker(bot.NewLLMConsistency(openAI))
+		log.Println("LLM prompt-injection guard enabled")
+	}
+
+	return guarded
+}
+
+// initializePrompts serves the embedded prompt templates, overridden by PROMPTS_DIR when set.
+// The directory is polled so template edits go live without a redeploy.
+func initializePrompts() *prompts.Registry {
+	dir := os.Getenv("PROMPTS_DIR")
+	if dir == "" {
+		return prompts.Default()
+	}
+
+	registry, err := prompts.NewRegistry(dir)
+	if err != nil {
+		log.Printf("WARNING: %v — using the embedded prompt templates", err)
+		return prompts.Default()
+	}
+
+	go registry.Watch(context.Background(), 5*time.Second)
+
+	log.Printf("prompt templates loaded from %s: %v", dir, registry.Versions())
+
+	return registry
+}
+
+func initializeExperiments(promptRegistry *prompts.Registry) *experiments.Config {
+	path := os.Getenv("EXPERIMENTS_PATH")
+	if path == "" {
+		return nil
+	}
+
+	cfg, err := experiments.Load(path)
+	if err == nil {
+		err = cfg.ValidateTemplates(promptRegistry)
+	}
+
+	if err != nil {
+		log.Printf("WARNING: %v — prompt experiments disabled", err)
+		return nil
+	}
+
+	log.Printf("prompt experiments loaded from %s", path)
+
+	return cfg
+}
+
+func initializePersonas() *personas.Catalog {
+	path := os.Getenv("PERSONAS_PATH")
+	if path == "" {
+		return personas.Default()
+	}
+
+	catalog, err := personas.Load(path)
+	if err != nil {
+		log.Printf("WARNING: %v — using the embedded persona catalog", err)
+		return personas.Default()
+	}
+
+	log.Printf("persona catalog loaded from %s", path)
+
+	return catalog
+}
+
+func initializeFormats() *formats.Catalog {
+	path := os.Getenv("FORMATS_PATH")
+	if path == "" {
+		return formats.Default()
+	}
+
+	catalog, err := formats.Load(path)
+	if err != nil {
+		log.Printf("WARNING: %v — using the embedded debate formats", err)
+		return formats.Default()
+	}
+
+	log.Printf("debate formats loaded from %s", path)
+
+	return catalog
+}
+
+func initializeJudge(engine bot.Engine) *judge.Judge {
+	rubric := judge.DefaultRubric()
+
+	if path := os.Getenv("JUDGE_RUBRIC_PATH"); path != "" {
+		loaded, err := judge.LoadRubric(path)
+		if err != nil {
+			log.Printf("WARNING: %v — using the embedded judge rubric", err)
+		} else {
+			log.Printf("judge rubric loaded from %s", path)
+			rubric = loaded
+		}
+	}
+
+	var opts []bot.Option
+	if model := os.Getenv("JUDGE_MODEL"); model != "" {
+		opts = append(opts, bot.WithModel(model))
+	}
+
+	return judge.New(engine, rubric, opts...)
+}
+
+// initializeSummarizer refreshes conversation titles and summaries every SUMMARY_EVERY bot
+// turns, with SUMMARY_MODEL when set.
+func initializeSummarizer(engine bot.Engine) *summary.Summarizer {
+	every, err := strconv.Atoi(getenv("SUMMARY_EVERY", strconv.Itoa(summary.DefaultEvery)))
+	if err != nil || every <= 0 {
+		log.Printf("WARNING: invalid SUMMARY_EVERY — summarizing every %d turns", summary.DefaultEvery)
+		every = summary.DefaultEvery
+	}
+
+	var opts []bot.Option
+	if model := os.Getenv("SUMMARY_MODEL"); model != "" {
+		opts = append(opts, bot.WithModel(model))
+	}
+
+	return summary.New(engine, every, opts...)
+}
+
+// initializeEvidence indexes the documents under EVIDENCE_DIR. Without it the bot gets no
+// evidence to cite.
+func initializeEvidence() evidence.Retriever {
+	dir := os.Getenv("EVIDENCE_DIR")
+	if dir == "" {
+		return nil
+	}
+
+	idx, err := evidence.Load(dir)
+	if err != nil {
+		log.Printf("WARNING: %v — replies won't cite evidence", err)
+		return nil
+	}
+
+	log.Printf("evidence loaded from %s: %d passages", dir, idx.Len())
+
+	return idx
+}
+
+func initializeTopicPolicy() *moderation.Policy {
+	path := os.Getenv("MODERATION_POLICY_PATH")
+	if path == "" {
+		return moderation.Default()
+	}
+
+	policy, err := moderation.LoadPolicy(path)
+	if err != nil {
+		log.Printf("WARNING: %v — using the embedded moderation policy", err)
+		return moderation.Default()
+	}
+
+	log.Printf("moderation policy loaded from %s", path)
+
+	return policy
+}
+
+func initializeModerator(policy *moderation.Policy, openAIKey string) moderation.Moderator {
+	rules := moderation.NewPolicyModerator(policy, moderation.Severity(getenv("MESSAGE_BLOCK_SEVERITY", string(moderation.SeverityCritical))))
+
+	if openAIKey == "" || getenv("OPENAI_MODERATION", "true") != "true" {
+		return rules
+	}
+
+	log.Println("OpenAI moderation enabled for chat messages")
+
+	return moderation.Chain(rules, moderation.NewOpenAIModerator(openAIKey))
+}
+
 func initializeStorage(redisAddr string) storage.Store {
 	if redisAddr != "" {
 		client, err := storage.NewRedisClient(redisAddr, getenv("REDIS_PASSWORD", ""))
@@ -104,6 +310,22 @@ func initializeStorage(redisAddr string) storage.Store {
 	return storage.NewMemoryStore()
 }
 
+// initializeEvents fans spectator updates out through Redis pub/sub when Redis is configured,
+// so every replica sees every debate's updates.
+func initializeEvents(redisAddr string) events.Broker {
+	if redisAddr == "" {
+		return events.NewMemoryBroker()
+	}
+
+	client, err := storage.NewRedisClient(redisAddr, getenv("REDIS_PASSWORD", ""))
+	if err != nil {
+		log.Printf("redis unavailable: %v — spectators only see updates made on this replica", err)
+		return events.NewMemoryBroker()
+	}
+
+	return events.NewRedisBroker(client)
+}
+
 func setupAuthMiddleware(r *gin.Engine, authService auth.ServiceInterface) {
 	if authService != nil {
 		r.Use(auth.Middleware(authService))
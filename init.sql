@@ -11,9 +11,10 @@ CREATE TABLE IF NOT EXISTS users (
 -- Topics table (predefined or user-created)
 CREATE TABLE IF NOT EXISTS topics (
     id VARCHAR(26) PRIMARY KEY, -- ULID format
-    name VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL UNIQUE,
     description TEXT,
     category VARCHAR(100), -- e.g., 'technology', 'politics', 'sports'
+    active BOOLEAN DEFAULT TRUE, -- inactive topics are kept but never offered
     created_at TIMESTAMP DEFAULT NOW()
 );
 
@@ -21,10 +22,31 @@ CREATE TABLE IF NOT EXISTS topics (
 CREATE TABLE IF NOT EXISTS conversations (
     id VARCHAR(26) PRIMARY KEY, -- ULID format
     user_id VARCHAR(26) REFERENCES users(id),
-    topic_id VARCHAR(26) REFERENCES topics(id),
+    topic_id VARCHAR(26) REFERENCES topics(id) ON DELETE SET NULL, -- catalog topic, NULL for free-text topics
     topic_name VARCHAR(255), -- denormalized for performance
     bot_stance VARCHAR(10) NOT NULL, -- 'PRO' or 'CON'
+    user_stance VARCHAR(10), -- always the opposite of bot_stance
+    switch_every INTEGER DEFAULT 0, -- rounds between side swaps (0 = never)
+    persona VARCHAR(50), -- persona ID from the catalog, NULL for the default
+    difficulty VARCHAR(50), -- difficulty ID from the catalog, NULL for the default
+    coach BOOLEAN DEFAULT FALSE, -- annotate the fallacies in the user's messages
+    debate_state JSONB, -- format, phase and turn counter of a structured debate
+    judgement JSONB, -- the judge's verdict; a judged debate is over
+    argument_map JSONB, -- claims and support/attack relations extracted from the debate
+    autoplay JSONB, -- side setups of a bot-vs-bot debate
+    mode VARCHAR(20), -- 'human' for human-vs-human debates, NULL for debates against the bot
+    participants JSONB, -- users and their sides in a human debate
+    spectators JSONB, -- user IDs allowed to watch a human debate
+    rounds INTEGER DEFAULT 0, -- planned rounds of a human debate (0 = open-ended)
+    auto_judge BOOLEAN DEFAULT FALSE, -- judge a human debate when its rounds are done
+    experiment_name VARCHAR(100), -- sticky prompt experiment assignment
+    experiment_variant VARCHAR(100),
+    rating SMALLINT CHECK (rating BETWEEN 1 AND 5), -- user rating, NULL when unrated
+    error_count INTEGER DEFAULT 0, -- failed bot generations
+    parent_id VARCHAR(26) REFERENCES conversations(id) ON DELETE SET NULL, -- the conversation a branch was forked from
+    forked_from VARCHAR(26), -- ID of the last message copied from the parent
     title VARCHAR(255), -- auto-generated or user-defined
+    summary JSONB, -- rolling summary of the debate, refreshed every few turns
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP DEFAULT NOW(),
     message_count INTEGER DEFAULT 0
@@ -32,20 +54,43 @@ CREATE TABLE IF NOT EXISTS conversations (
 
 -- Messages table
 CREATE TABLE IF NOT EXISTS messages (
-    id VARCHAR(26) PRIMARY KEY, -- ULID format
-    conversation_id VARCHAR(26) REFERENCES conversations(id) ON DELETE CASCADE,
-    role VARCHAR(10) NOT NULL, -- 'user' or 'bot'
+    id VARCHAR(26) NOT NULL, -- ULID format; branches keep the IDs of the messages they copy
+    conversation_id VARCHAR(26) NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+    role VARCHAR(10) NOT NULL, -- 'user', 'bot' or 'system'; 'pro', 'con' or 'moderator' in human debates
+    author VARCHAR(64), -- user ID of a human debater
     content TEXT NOT NULL,
-    created_at TIMESTAMP DEFAULT NOW()
+    event VARCHAR(50), -- system message kind, e.g. 'side_switch'
+    moderation JSONB, -- verdict for turns withheld by moderation
+    prompt_version VARCHAR(100), -- template name@hash behind a bot reply
+    report JSONB, -- the moderator's round review in human debates
+    citations JSONB, -- evidence passages a bot reply cites
+    tool_calls JSONB, -- tools the bot called for a reply, kept for auditing
+    fallacies JSONB, -- coaching notes on the fallacies in a user message
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, id)
+);
+
+-- Prep briefs, cached per topic and version of the prompt that wrote them
+CREATE TABLE IF NOT EXISTS topic_briefs (
+    topic_id VARCHAR(26) NOT NULL REFERENCES topics(id) ON DELETE CASCADE,
+    prompt_version VARCHAR(100) NOT NULL, -- template name@hash
+    brief JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (topic_id, prompt_version)
 );
 
 -- Indexes for performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_topics_name_lower ON topics(lower(name));
+CREATE INDEX IF NOT EXISTS idx_topics_category ON topics(lower(category));
 CREATE INDEX IF NOT EXISTS idx_conversations_user_id ON conversations(user_id);
 CREATE INDEX IF NOT EXISTS idx_conversations_topic_id ON conversations(topic_id);
 CREATE INDEX IF NOT EXISTS idx_conversations_created_at ON conversations(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_conversations_updated_at ON conversations(updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_conversations_experiment ON conversations(experiment_name, experiment_variant);
+CREATE INDEX IF NOT EXISTS idx_conversations_parent_id ON conversations(parent_id);
 CREATE INDEX IF NOT EXISTS idx_messages_conversation_id ON messages(conversation_id);
 CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages(created_at);
+CREATE INDEX IF NOT EXISTS idx_messages_prompt_version ON messages(prompt_version);
 
 -- Insert some popular debate topics with ULID
 INSERT INTO topics (id, name, description, category) VALUES
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/argmap"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// dotContentType is the media type of Graphviz exports.
+const dotContentType = "text/vnd.graphviz"
+
+// RegisterArgumentMapRoutes registers the argument map routes
+func RegisterArgumentMapRoutes(r *gin.Engine, store storage.Store, cfg routeConfig) {
+	r.GET("/conversations/:id/argument-map", getArgumentMap(store, cfg))
+}
+
+// getArgumentMap handles GET /conversations/:id/argument-map. The stored map is rebuilt when
+// the debate has moved on since it was made, or with ?refresh=true. ?format=dot (or an Accept
+// header asking for text/vnd.graphviz) returns it as a Graphviz digraph instead of JSON.
+func getArgumentMap(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+		defer cancel()
+
+		conversation, err := store.GetConversation(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conversation.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not read this debate"})
+			return
+		}
+
+		if c.Query("refresh") == "true" || argmap.Stale(conversation) {
+			m, err := cfg.argmap.Analyze(ctx, conversation)
+			if err != nil {
+				if errors.Is(err, argmap.ErrNothingToMap) {
+					c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+					return
+				}
+
+				c.JSON(http.StatusBadGateway, gin.H{"error": "argument map error: " + err.Error()})
+
+				return
+			}
+
+			conversation.ArgumentMap = m
+
+			// only the map is written: turns saved while it was built must survive
+			if err := store.SaveArgumentMap(ctx, conversation.ID, m); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save argument map: " + err.Error()})
+				return
+			}
+		}
+
+		if c.Query("format") == "dot" || strings.Contains(c.GetHeader("Accept"), dotContentType) {
+			c.Data(http.StatusOK, dotContentType+"; charset=utf-8", []byte(argmap.DOT(conversation.ArgumentMap)))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conversation.ID, "argument_map": conversation.ArgumentMap})
+	}
+}
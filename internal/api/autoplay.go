@@ -0,0 +1,163 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/autoplay"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/oklog/ulid/v2"
+)
+
+// AutoplayRequest starts a bot-vs-bot debate
+type AutoplayRequest struct {
+	Topic  string              `json:"topic" binding:"required"`
+	Rounds int                 `json:"rounds"` // defaults to 3
+	Pro    models.AutoplaySide `json:"pro"`
+	Con    models.AutoplaySide `json:"con"`
+	Async  bool                `json:"async"` // run as a background job instead of waiting
+}
+
+// AutoplayResponse reports an autoplay job, with the transcript once a synchronous run ends
+type AutoplayResponse struct {
+	Job          autoplay.Job         `json:"job"`
+	Conversation *models.Conversation `json:"conversation,omitempty"`
+}
+
+// RegisterAutoplayRoutes registers the bot-vs-bot debate routes
+func RegisterAutoplayRoutes(r *gin.Engine, store storage.Store, manager *autoplay.Manager, cfg routeConfig) {
+	group := r.Group("/debates/autoplay")
+	{
+		group.POST("", startAutoplay(store, manager, cfg))
+		group.GET("/:id", getAutoplayJob(manager))
+		group.DELETE("/:id", cancelAutoplayJob(manager))
+	}
+}
+
+// startAutoplay handles POST /debates/autoplay. A synchronous run is cancelled when the client
+// disconnects; either kind can be cancelled with DELETE /debates/autoplay/:id. A job started
+// with X-User-ID belongs to that user (see auth.UserID).
+func startAutoplay(store storage.Store, manager *autoplay.Manager, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req AutoplayRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		if err := validateAutoplay(cfg, &req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		if verdict := cfg.topicPolicy.CheckTopic(req.Topic); !verdict.Allowed {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "topic rejected by moderation policy",
+				"moderation": verdict,
+			})
+
+			return
+		}
+
+		conv := autoplay.NewConversation(ulid.Make().String(), req.Topic, &models.Autoplay{Rounds: req.Rounds, Pro: req.Pro, Con: req.Con})
+
+		job, done, err := manager.Start(conv, auth.UserID(c))
+		if err != nil {
+			if errors.Is(err, autoplay.ErrTooManyJobs) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start autoplay: " + err.Error()})
+
+			return
+		}
+
+		if req.Async {
+			c.JSON(http.StatusAccepted, AutoplayResponse{Job: job})
+			return
+		}
+
+		select {
+		case <-done:
+			job, _ = manager.Get(job.ID)
+		case <-c.Request.Context().Done():
+			job, _ = manager.Cancel(job.ID)
+			return
+		}
+
+		transcript, err := store.GetConversation(c.Request.Context(), job.ConversationID)
+		if err != nil {
+			transcript = nil
+		}
+
+		c.JSON(http.StatusOK, AutoplayResponse{Job: job, Conversation: transcript})
+	}
+}
+
+// getAutoplayJob handles GET /debates/autoplay/:id
+func getAutoplayJob(manager *autoplay.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := manager.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "autoplay job not found"})
+			return
+		}
+
+		if !job.CanAccess(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not view this autoplay job"})
+			return
+		}
+
+		c.JSON(http.StatusOK, AutoplayResponse{Job: job})
+	}
+}
+
+// cancelAutoplayJob handles DELETE /debates/autoplay/:id
+func cancelAutoplayJob(manager *autoplay.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		job, ok := manager.Get(c.Param("id"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "autoplay job not found"})
+			return
+		}
+
+		if !job.CanAccess(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not cancel this autoplay job"})
+			return
+		}
+
+		if job, ok = manager.Cancel(job.ID); !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "autoplay job not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, AutoplayResponse{Job: job})
+	}
+}
+
+func validateAutoplay(cfg routeConfig, req *AutoplayRequest) error {
+	if req.Rounds == 0 {
+		req.Rounds = 3
+	}
+
+	if req.Rounds < 0 || req.Rounds > autoplay.MaxRounds {
+		return fmt.Errorf("rounds must be between 1 and %d", autoplay.MaxRounds)
+	}
+
+	for _, side := range []models.AutoplaySide{req.Pro, req.Con} {
+		if err := validatePersona(cfg.personas, &side.Persona, &side.Difficulty); err != nil {
+			return err
+		}
+
+		if side.Temperature != nil && (*side.Temperature < 0 || *side.Temperature > 2) {
+			return errors.New("temperature must be between 0 and 2")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/oklog/ulid/v2"
+)
+
+// ForkRequest starts a branch of a conversation
+type ForkRequest struct {
+	MessageID string `json:"message_id" binding:"required"` // the last message the branch keeps
+}
+
+// TreeNode is a conversation in a branch graph with the branches forked from it
+type TreeNode struct {
+	storage.Branch
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// RegisterBranchRoutes registers the routes that fork conversations and list their branches
+func RegisterBranchRoutes(r *gin.Engine, store storage.Store, cfg routeConfig) {
+	r.POST("/conversations/:id/fork", forkConversation(store, cfg))
+	r.GET("/conversations/:id/tree", getConversationTree(store))
+}
+
+// forkConversation handles POST /conversations/:id/fork. The branch copies the history up to
+// and including the given message and continues as a conversation of its own.
+func forkConversation(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ForkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		conv, err := store.GetConversation(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conv.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not read this debate"})
+			return
+		}
+
+		if conv.Autoplay != nil || conv.Mode == models.ModeHuman {
+			c.JSON(http.StatusConflict, gin.H{"error": "only debates against the bot can be forked", "conversation_id": conv.ID})
+			return
+		}
+
+		i := conv.MessageIndex(req.MessageID)
+		if i < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		branch := conv.Fork(ulid.Make().String(), i)
+		replayFormat(cfg, branch)
+
+		if err := store.ForkConversation(ctx, branch); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fork conversation: " + err.Error()})
+			return
+		}
+
+		branch.Messages = models.RedactFlagged(branch.Messages)
+
+		c.JSON(http.StatusCreated, branch)
+	}
+}
+
+// getConversationTree handles GET /conversations/:id/tree, returning the whole fork tree the
+// conversation belongs to from its root.
+func getConversationTree(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		conv, err := store.GetConversation(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conv.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not read this debate"})
+			return
+		}
+
+		branches, err := store.ConversationTree(ctx, conv.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get conversation tree: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, buildTree(branches))
+	}
+}
+
+// buildTree nests branches, which come with the root first and each branch after its parent.
+func buildTree(branches []storage.Branch) *TreeNode {
+	nodes := make(map[string]*TreeNode, len(branches))
+
+	var root *TreeNode
+
+	for _, b := range branches {
+		node := &TreeNode{Branch: b}
+		nodes[b.ID] = node
+
+		if parent, ok := nodes[b.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		} else if root == nil {
+			root = node
+		}
+	}
+
+	return root
+}
@@ -3,8 +3,11 @@ package api
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/models"
 	"github.com/nikoremi97/debate/internal/storage"
 )
 
@@ -18,7 +21,17 @@ type ListConversationsResponse struct {
 
 // PopularTopicsResponse represents the response for popular topics
 type PopularTopicsResponse struct {
-	Topics []string `json:"topics"`
+	Topics   []storage.TopicCount `json:"topics"`
+	Window   string               `json:"window"`
+	Trending bool                 `json:"trending,omitempty"`
+}
+
+// popularityWindows are the windows popular topics can be counted over
+var popularityWindows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+	"all": 0,
 }
 
 // RegisterConversationRoutes registers conversation-related routes
@@ -28,15 +41,53 @@ func RegisterConversationRoutes(r *gin.Engine, store storage.Store) {
 		conversations.GET("", listConversations(store))
 		conversations.GET("/topics", getPopularTopics(store))
 		conversations.GET("/:id", getConversation(store))
+		conversations.POST("/:id/rating", rateConversation(store))
+	}
+}
+
+// RatingRequest is the payload for rating a conversation
+type RatingRequest struct {
+	Rating int `json:"rating" binding:"required,min=1,max=5"`
+}
+
+// rateConversation handles POST /conversations/:id/rating. Only those who may read a human
+// debate may rate it; the check trusts the X-User-ID header like the others (see auth.UserID).
+func rateConversation(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RatingRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		conversation, err := store.GetConversation(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conversation.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not rate this debate"})
+			return
+		}
+
+		// only the rating is written: a turn saved since the conversation was read must survive
+		if err := store.SaveRating(c.Request.Context(), conversation.ID, req.Rating); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save rating: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"id": conversation.ID, "rating": req.Rating})
 	}
 }
 
-// listConversations handles GET /conversations
+// listConversations handles GET /conversations. Human debates are only listed to the users
+// who may read them.
 func listConversations(store storage.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		limit, offset := parsePaginationParams(c)
 
-		conversations, err := store.ListConversations(c.Request.Context(), limit, offset)
+		conversations, err := store.ListConversations(c.Request.Context(), auth.UserID(c), limit, offset)
 		if err != nil {
 			handleListConversationsError(c, err)
 
@@ -88,7 +139,9 @@ func calculateTotal(conversations []storage.ConversationSummary, limit, offset i
 	return offset + len(conversations)
 }
 
-// getPopularTopics handles GET /conversations/topics
+// getPopularTopics handles GET /conversations/topics. ?window=24h|7d|30d|all counts the
+// debates started within it (all by default); ?trending=true ranks the topics by how much they
+// rose over the window before (24h by default).
 func getPopularTopics(store storage.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Parse query parameters
@@ -99,8 +152,31 @@ func getPopularTopics(store storage.Store) gin.HandlerFunc {
 			limit = 10
 		}
 
+		trending := c.Query("trending") == "true"
+
+		window := c.Query("window")
+		if window == "" {
+			window = "all"
+			if trending {
+				window = "24h"
+			}
+		}
+
+		duration, ok := popularityWindows[window]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: window must be 24h, 7d, 30d or all"})
+			return
+		}
+
+		if trending && duration == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: trending needs a window of 24h, 7d or 30d"})
+			return
+		}
+
 		// Get popular topics from store
-		topics, err := store.GetPopularTopics(c.Request.Context(), limit)
+		q := storage.PopularTopicsQuery{Window: duration, Trending: trending, Limit: limit}
+
+		topics, err := store.GetPopularTopics(c.Request.Context(), q)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to get popular topics: " + err.Error(),
@@ -110,7 +186,9 @@ func getPopularTopics(store storage.Store) gin.HandlerFunc {
 		}
 
 		response := PopularTopicsResponse{
-			Topics: topics,
+			Topics:   topics,
+			Window:   window,
+			Trending: trending,
 		}
 
 		c.JSON(http.StatusOK, response)
@@ -132,6 +210,13 @@ func getConversation(store storage.Store) gin.HandlerFunc {
 			return
 		}
 
+		if !conversation.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not read this debate"})
+			return
+		}
+
+		conversation.Messages = models.RedactFlagged(conversation.Messages)
+
 		c.JSON(http.StatusOK, conversation)
 	}
 }
@@ -0,0 +1,226 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/events"
+	"github.com/nikoremi97/debate/internal/formats"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// EditMessageRequest replaces the text of a user message
+type EditMessageRequest struct {
+	Message string `json:"message" binding:"required"`
+}
+
+// RewindRequest rewinds a conversation to one of its messages
+type RewindRequest struct {
+	MessageID string `json:"message_id" binding:"required"` // the last message to keep
+}
+
+// RegisterEditRoutes registers the routes that rework past turns
+func RegisterEditRoutes(r *gin.Engine, store storage.Store, engine bot.Engine, cfg routeConfig) {
+	r.POST("/conversations/:id/regenerate", regenerateReply(store, engine, cfg))
+	r.PUT("/conversations/:id/messages/:msgId", editMessage(store, engine, cfg))
+	r.POST("/conversations/:id/rewind", rewindConversation(store, cfg))
+}
+
+// regenerateReply handles POST /conversations/:id/regenerate. It drops everything after the
+// last user message and answers it again, which also retries a reply that failed.
+func regenerateReply(store storage.Store, engine bot.Engine, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 25*time.Second) // keep under 30s
+		defer cancel()
+
+		conv, ok := loadEditable(ctx, c, store)
+		if !ok {
+			return
+		}
+
+		mark := markUpdates(cfg, conv)
+		defer publishUpdates(ctx, cfg, conv, mark)
+
+		last := lastUserMessage(conv)
+		if last < 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "there is no user message to answer", "conversation_id": conv.ID})
+			return
+		}
+
+		if conv.Messages[last].Flagged() {
+			c.JSON(http.StatusConflict, gin.H{"error": "the last message was withheld by moderation", "conversation_id": conv.ID})
+			return
+		}
+
+		format := rewind(cfg, conv, last+1)
+		notifyRewind(ctx, cfg, conv)
+
+		c.JSON(botTurn(ctx, store, engine, cfg, conv, format, conv.Messages[last].Message))
+	}
+}
+
+// editMessage handles PUT /conversations/:id/messages/:msgId. The message keeps its ID; the
+// turns after it are dropped and the bot answers the new text.
+func editMessage(store storage.Store, engine bot.Engine, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req EditMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 25*time.Second) // keep under 30s
+		defer cancel()
+
+		conv, ok := loadEditable(ctx, c, store)
+		if !ok {
+			return
+		}
+
+		mark := markUpdates(cfg, conv)
+		defer publishUpdates(ctx, cfg, conv, mark)
+
+		i := conv.MessageIndex(c.Param("msgId"))
+		if i < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		if conv.Messages[i].Role != "user" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "only user messages can be edited"})
+			return
+		}
+
+		edited := models.Message{ID: conv.Messages[i].ID, Role: "user", Message: req.Message}
+
+		// the edit has to fit the phase the debate was in at that point
+		format := rewind(cfg, conv, i)
+		if format != nil {
+			if err := format.CheckTurn(conv.Debate, "", req.Message); err != nil {
+				c.JSON(turnError(cfg, conv, err))
+				return
+			}
+		}
+
+		notifyRewind(ctx, cfg, conv)
+
+		c.JSON(userTurn(ctx, store, engine, cfg, conv, format, edited))
+	}
+}
+
+// rewindConversation handles POST /conversations/:id/rewind, dropping every message after
+// the given one.
+func rewindConversation(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req RewindRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		conv, ok := loadEditable(ctx, c, store)
+		if !ok {
+			return
+		}
+
+		mark := markUpdates(cfg, conv)
+
+		i := conv.MessageIndex(req.MessageID)
+		if i < 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+			return
+		}
+
+		rewind(cfg, conv, i+1)
+
+		if err := store.SaveConversation(ctx, conv); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rewind conversation: " + err.Error()})
+			return
+		}
+
+		notifyRewind(ctx, cfg, conv)
+		publishUpdates(ctx, cfg, conv, mark)
+
+		c.JSON(http.StatusOK, buildChatResponse(cfg, conv))
+	}
+}
+
+// loadEditable loads a conversation whose history may be reworked, or responds with why not.
+func loadEditable(ctx context.Context, c *gin.Context, store storage.Store) (*models.Conversation, bool) {
+	conv, err := store.GetConversation(ctx, c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+		return nil, false
+	}
+
+	var reason string
+
+	switch {
+	case conv.Autoplay != nil:
+		reason = "bot-vs-bot debates can't be edited"
+	case conv.Mode == models.ModeHuman:
+		reason = "human debates can't be edited"
+	case conv.Judgement != nil:
+		reason = "the debate has been judged and is over"
+	}
+
+	if reason != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": reason, "conversation_id": conv.ID})
+		return nil, false
+	}
+
+	return conv, true
+}
+
+// rewind drops the messages from position i on and winds a structured debate back to match
+// the bot replies that are left. It returns the debate's format, if any.
+func rewind(cfg routeConfig, conv *models.Conversation, i int) *formats.Format {
+	conv.Truncate(i)
+
+	return replayFormat(cfg, conv)
+}
+
+// replayFormat rebuilds the phase state of a structured debate from its bot replies.
+func replayFormat(cfg routeConfig, conv *models.Conversation) *formats.Format {
+	if conv.Debate == nil {
+		return nil
+	}
+
+	format, ok := cfg.formats.Format(conv.Debate.Format)
+	if !ok {
+		return nil
+	}
+
+	conv.Debate = format.Start()
+	for range conv.Turns() {
+		format.CompleteTurn(conv.Debate)
+	}
+
+	return &format
+}
+
+// notifyRewind tells spectators to drop the messages after the conversation's last one.
+func notifyRewind(ctx context.Context, cfg routeConfig, conv *models.Conversation) {
+	var kept string
+	if n := len(conv.Messages); n > 0 {
+		kept = conv.Messages[n-1].ID
+	}
+
+	events.Notify(context.WithoutCancel(ctx), cfg.events, events.NewRewind(conv.ID, kept))
+}
+
+func lastUserMessage(conv *models.Conversation) int {
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		if conv.Messages[i].Role == "user" {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/events"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// keepAliveInterval spaces the comments that keep idle event streams open through proxies.
+const keepAliveInterval = 15 * time.Second
+
+// RegisterEventRoutes registers the live spectator stream
+func RegisterEventRoutes(r *gin.Engine, store storage.Store, broker events.Broker) {
+	r.GET("/conversations/:id/events", streamEvents(store, broker))
+}
+
+// streamEvents handles GET /conversations/:id/events as a Server-Sent Events stream of new
+// messages, phase changes and verdicts. The stream opens with a "ready" event.
+func streamEvents(store storage.Store, broker events.Broker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		conv, err := store.GetConversation(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conv.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not watch this debate"})
+			return
+		}
+
+		stream, err := broker.Subscribe(ctx, conv.ID)
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "live updates unavailable: " + err.Error()})
+			return
+		}
+
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no") // don't let nginx buffer the stream
+
+		c.SSEvent(events.TypeReady, events.Event{Type: events.TypeReady, ConversationID: conv.ID, TS: time.Now().UnixMilli()})
+		c.Writer.Flush()
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		c.Stream(func(w io.Writer) bool {
+			select {
+			case e, ok := <-stream:
+				if !ok {
+					return false
+				}
+
+				c.SSEvent(e.Type, e)
+			case <-keepAlive.C:
+				_, _ = io.WriteString(w, ": keep-alive\n\n")
+			case <-ctx.Done():
+				return false
+			}
+
+			return true
+		})
+	}
+}
+
+// updateMark is where a conversation stood before a request changed it.
+type updateMark struct {
+	messages int
+	phase    *models.PhaseStatus
+	judged   bool
+}
+
+func markUpdates(cfg routeConfig, conv *models.Conversation) updateMark {
+	return updateMark{messages: conv.Mark(), phase: phaseStatus(cfg.formats, conv), judged: conv.Judgement != nil}
+}
+
+// publishUpdates pushes what changed since mark to the conversation's spectators.
+func publishUpdates(ctx context.Context, cfg routeConfig, conv *models.Conversation, mark updateMark) {
+	var updates []events.Event
+
+	for _, m := range conv.Since(mark.messages) {
+		updates = append(updates, events.NewMessage(conv.ID, m))
+	}
+
+	if phase := phaseStatus(cfg.formats, conv); phase != nil && (mark.phase == nil || phase.Phase != mark.phase.Phase || phase.Finished != mark.phase.Finished) {
+		updates = append(updates, events.NewPhase(conv.ID, phase))
+	}
+
+	if conv.Judgement != nil && !mark.judged {
+		updates = append(updates, events.NewVerdict(conv.ID, conv.Judgement))
+	}
+
+	events.Notify(context.WithoutCancel(ctx), cfg.events, updates...)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/experiments"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// VariantReport is the outcome summary of one experiment variant.
+type VariantReport struct {
+	storage.VariantStats
+	AvgTurns  float64 `json:"avg_turns"`
+	AvgRating float64 `json:"avg_rating"`
+	ErrorRate float64 `json:"error_rate"` // errors per generation attempt
+}
+
+// ExperimentReportResponse represents the response for an experiment report
+type ExperimentReportResponse struct {
+	Experiment string          `json:"experiment"`
+	Active     bool            `json:"active"`
+	Variants   []VariantReport `json:"variants"`
+}
+
+// RegisterExperimentRoutes registers experiment-related routes
+func RegisterExperimentRoutes(r *gin.Engine, store storage.Store, cfg *experiments.Config) {
+	group := r.Group("/experiments")
+	{
+		group.GET("", listExperiments(cfg))
+		group.GET("/:name/report", getExperimentReport(store, cfg))
+	}
+}
+
+// listExperiments handles GET /experiments
+func listExperiments(cfg *experiments.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list := []experiments.Experiment{}
+		if cfg != nil {
+			list = cfg.Experiments
+		}
+
+		c.JSON(http.StatusOK, gin.H{"experiments": list})
+	}
+}
+
+// getExperimentReport handles GET /experiments/:name/report
+func getExperimentReport(store storage.Store, cfg *experiments.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		experiment, ok := findExperiment(cfg, c.Param("name"))
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "experiment not found"})
+			return
+		}
+
+		stats, err := store.ExperimentStats(c.Request.Context(), experiment.Name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "Failed to get experiment stats: " + err.Error(),
+			})
+
+			return
+		}
+
+		c.JSON(http.StatusOK, buildExperimentReport(experiment, stats))
+	}
+}
+
+func findExperiment(cfg *experiments.Config, name string) (experiments.Experiment, bool) {
+	if cfg == nil {
+		return experiments.Experiment{}, false
+	}
+
+	for _, e := range cfg.Experiments {
+		if e.Name == name {
+			return e, true
+		}
+	}
+
+	return experiments.Experiment{}, false
+}
+
+// buildExperimentReport lists every configured variant, including ones without traffic yet.
+func buildExperimentReport(experiment experiments.Experiment, stats []storage.VariantStats) ExperimentReportResponse {
+	byVariant := make(map[string]storage.VariantStats, len(stats))
+	for _, s := range stats {
+		byVariant[s.Variant] = s
+	}
+
+	resp := ExperimentReportResponse{Experiment: experiment.Name, Active: experiment.Active, Variants: []VariantReport{}}
+
+	for _, v := range experiment.Variants {
+		s, ok := byVariant[v.Name]
+		if !ok {
+			s = storage.VariantStats{Variant: v.Name}
+		}
+
+		report := VariantReport{VariantStats: s}
+
+		if s.Conversations > 0 {
+			report.AvgTurns = float64(s.Turns) / float64(s.Conversations)
+		}
+
+		if s.RatingCount > 0 {
+			report.AvgRating = float64(s.RatingSum) / float64(s.RatingCount)
+		}
+
+		if attempts := s.Turns + s.Errors; attempts > 0 {
+			report.ErrorRate = float64(s.Errors) / float64(attempts)
+		}
+
+		resp.Variants = append(resp.Variants, report)
+	}
+
+	return resp
+}
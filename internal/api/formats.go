@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/formats"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+var (
+	errNoFormat     = errors.New("this debate has no format, so it has no phases")
+	errFormatLocked = errors.New("the format can't be changed once the debate has started")
+)
+
+// RegisterFormatRoutes registers the debate format routes
+func RegisterFormatRoutes(r *gin.Engine, catalog *formats.Catalog) {
+	r.GET("/formats", listFormats(catalog))
+}
+
+// listFormats handles GET /formats
+func listFormats(catalog *formats.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, catalog)
+	}
+}
+
+// applyFormat starts the requested format on a new debate and returns the format of a
+// structured debate, or nil for a free-form one.
+func applyFormat(catalog *formats.Catalog, conv *models.Conversation, requested *string) (*formats.Format, error) {
+	if requested != nil && *requested != "" {
+		switch {
+		case conv.Debate == nil && len(conv.Messages) == 0:
+			format, _ := catalog.Format(*requested)
+			conv.Debate = format.Start()
+		case conv.Debate == nil || conv.Debate.Format != *requested:
+			return nil, errFormatLocked
+		}
+	}
+
+	if conv.Debate == nil {
+		return nil, nil
+	}
+
+	format, ok := catalog.Format(conv.Debate.Format)
+	if !ok {
+		// the format was removed from the catalog; carry on free-form
+		return nil, nil
+	}
+
+	return &format, nil
+}
+
+// phaseStatus reports the progress of a structured debate, or nil for a free-form one.
+func phaseStatus(catalog *formats.Catalog, conv *models.Conversation) *models.PhaseStatus {
+	if conv.Debate == nil {
+		return nil
+	}
+
+	format, ok := catalog.Format(conv.Debate.Format)
+	if !ok {
+		return nil
+	}
+
+	return format.Status(conv.Debate)
+}
+
+// turnError explains why a turn doesn't fit the debate, with the current phase so the client
+// can recover.
+func turnError(cfg routeConfig, conv *models.Conversation, err error) (int, any) {
+	status := http.StatusConflict
+
+	var tooLong *formats.WordLimitError
+	if errors.As(err, &tooLong) {
+		status = http.StatusUnprocessableEntity
+	}
+
+	return status, gin.H{
+		"error":           err.Error(),
+		"conversation_id": conv.ID,
+		"phase":           phaseStatus(cfg.formats, conv),
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -2,23 +2,69 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/autoplay"
 	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/coach"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/experiments"
+	"github.com/nikoremi97/debate/internal/formats"
 	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/personas"
 	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/nikoremi97/debate/internal/summary"
+	"github.com/nikoremi97/debate/internal/tools"
 	"github.com/oklog/ulid/v2"
 )
 
-func RegisterRoutes(r *gin.Engine, store storage.Store, engine bot.Engine) {
-	r.POST("/chat", handleChat(store, engine))
+func RegisterRoutes(r *gin.Engine, store storage.Store, engine bot.Engine, opts ...RouteOption) {
+	cfg := newRouteConfig(engine, opts)
+
+	r.POST("/chat", handleChat(store, engine, cfg))
+	r.GET("/ws", handleWebSocket(store, engine, cfg))
 	RegisterConversationRoutes(r, store)
+	RegisterExperimentRoutes(r, store, cfg.experiments)
+	RegisterPersonaRoutes(r, cfg.personas)
+	RegisterFormatRoutes(r, cfg.formats)
+	RegisterJudgeRoutes(r, store, cfg)
+	RegisterEventRoutes(r, store, cfg.events)
+	RegisterEditRoutes(r, store, engine, cfg)
+	RegisterBranchRoutes(r, store, cfg)
+	RegisterArgumentMapRoutes(r, store, cfg)
+	RegisterTopicRoutes(r, store, cfg)
+
+	runner := autoplay.NewRunner(engine, store, cfg.moderator, cfg.personas).WithEvents(cfg.events)
+	RegisterAutoplayRoutes(r, store, autoplay.NewManager(runner, maxAutoplayJobs), cfg)
+	RegisterHumanDebateRoutes(r, store, cfg)
 }
 
-func handleChat(store storage.Store, engine bot.Engine) gin.HandlerFunc {
+// maxAutoplayJobs bounds the bot-vs-bot debates running at once on this replica.
+const maxAutoplayJobs = 4
+
+// evidencePassages is how many retrieved passages a bot reply is given to cite.
+const evidencePassages = 3
+
+// topicRejectedError carries the moderation verdict for a rejected user topic.
+type topicRejectedError struct {
+	verdict moderation.Verdict
+}
+
+func (e *topicRejectedError) Error() string { return "topic rejected: " + e.verdict.Reason }
+
+// errUnknownTopic is returned when a new debate asks for a catalog topic or category that
+// isn't there.
+var errUnknownTopic = errors.New("unknown topic")
+
+func handleChat(store storage.Store, engine bot.Engine, cfg routeConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req models.ChatRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -29,76 +75,552 @@ func handleChat(store storage.Store, engine bot.Engine) gin.HandlerFunc {
 		ctx, cancel := context.WithTimeout(c.Request.Context(), 25*time.Second) // keep under 30s
 		defer cancel()
 
-		conversation, err := getOrCreateConversation(ctx, store, req.ConversationID, req.Topic, req.Message)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
-			return
+		c.JSON(chatTurn(ctx, store, engine, cfg, req, auth.UserID(c)))
+	}
+}
+
+// chatTurn plays one chat turn for any transport and returns the HTTP status and JSON body to
+// answer with. userID identifies the caller in human debates; extra options are added to the
+// ones the reply is generated with, e.g. bot.WithStream.
+func chatTurn(ctx context.Context, store storage.Store, engine bot.Engine, cfg routeConfig, req models.ChatRequest, userID string, extra ...bot.Option) (int, any) {
+	userStance, err := parseUserStance(req.UserStance)
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()}
+	}
+
+	if req.SwitchSidesEvery != nil && *req.SwitchSidesEvery < 0 {
+		return http.StatusBadRequest, gin.H{"error": "invalid request: switch_sides_every must not be negative"}
+	}
+
+	if err := validatePersona(cfg.personas, req.Persona, req.Difficulty); err != nil {
+		return http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()}
+	}
+
+	if req.Format != nil && *req.Format != "" {
+		if _, ok := cfg.formats.Format(*req.Format); !ok {
+			return http.StatusBadRequest, gin.H{"error": "invalid request: unknown format " + *req.Format}
+		}
+	}
+
+	conversation, confirmation, err := getOrCreateConversation(ctx, store, cfg, req, userStance)
+	if err != nil {
+		var rejected *topicRejectedError
+		if errors.As(err, &rejected) {
+			return http.StatusUnprocessableEntity, gin.H{
+				"error":      "topic rejected by moderation policy",
+				"moderation": rejected.verdict,
+			}
+		}
+
+		if errors.Is(err, errUnknownTopic) {
+			return http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()}
 		}
 
-		// append user message
-		conversation.Append(models.Message{Role: "user", Message: req.Message})
+		return http.StatusNotFound, gin.H{"error": "conversation not found"}
+	}
 
-		// generate bot reply
-		reply, err := generateBotReply(ctx, engine, conversation, req.Message)
-		if err != nil {
-			c.JSON(http.StatusBadGateway, gin.H{"error": "llm error: " + err.Error()})
-			return
+	suggestion := suggestTopic(ctx, store, cfg, conversation, req.Topic)
+
+	// spectators get whatever this turn adds, however it ends
+	mark := markUpdates(cfg, conversation)
+	defer publishUpdates(ctx, cfg, conversation, mark)
+
+	if confirmation != nil {
+		// nothing is stored until the user confirms a side
+		return http.StatusOK, models.ChatResponse{
+			Messages:           []models.Message{},
+			Topic:              conversation.Topic,
+			StanceConfirmation: confirmation,
+			SuggestedTopic:     suggestion,
 		}
+	}
+
+	if conversation.Autoplay != nil {
+		return http.StatusConflict, gin.H{"error": "bot-vs-bot debates can't be continued in chat", "conversation_id": conversation.ID}
+	}
+
+	if conversation.Judgement != nil {
+		return http.StatusConflict, gin.H{"error": "the debate has been judged and is over", "conversation_id": conversation.ID}
+	}
+
+	if conversation.Mode == models.ModeHuman {
+		return humanTurn(ctx, store, cfg, conversation, userID, req.Message)
+	}
+
+	applySideOptions(conversation, userStance, req.SwitchSidesEvery)
+	applyPersonaOptions(cfg.personas, conversation, req.Persona, req.Difficulty)
+
+	if req.Coach != nil {
+		conversation.Coach = *req.Coach
+	}
+
+	// structured debates only accept turns that fit the current phase
+	format, err := applyFormat(cfg.formats, conversation, req.Format)
+	if err == nil && format != nil {
+		err = format.CheckTurn(conversation.Debate, stringValue(req.Phase), req.Message)
+	} else if err == nil && stringValue(req.Phase) != "" {
+		err = errNoFormat
+	}
+
+	if err != nil {
+		return turnError(cfg, conversation, err)
+	}
+
+	userMsg := models.Message{Role: "user", Message: req.Message}
+
+	status, body := userTurn(ctx, store, engine, cfg, conversation, format, userMsg, extra...)
+	if resp, ok := body.(models.ChatResponse); ok && suggestion != nil {
+		resp.SuggestedTopic = suggestion
+		body = resp
+	}
+
+	return status, body
+}
+
+// suggestTopic links a new debate on a free-text topic to the closest catalog topic, if it is
+// close enough, and returns it as the suggestion. The debate keeps the user's wording.
+func suggestTopic(ctx context.Context, store storage.Store, cfg routeConfig, conv *models.Conversation, userTopic *string) *models.TopicSuggestion {
+	if len(conv.Messages) > 0 || conv.TopicID != "" || stringValue(userTopic) == "" {
+		return nil
+	}
+
+	catalog, err := store.ListTopics(ctx, storage.TopicFilter{})
+	if err != nil {
+		log.Printf("topic catalog unavailable: %v", err)
+		return nil
+	}
+
+	match, ok := cfg.matcher.Match(catalog, *userTopic)
+	if !ok {
+		return nil
+	}
+
+	conv.TopicID = match.TopicID
+
+	return &match
+}
+
+// userTurn moderates the user's message, adds it to the debate and answers it.
+func userTurn(ctx context.Context, store storage.Store, engine bot.Engine, cfg routeConfig, conv *models.Conversation, format *formats.Format, userMsg models.Message, extra ...bot.Option) (int, any) {
+	// moderate the user message before it reaches the model
+	if verdict := moderate(ctx, cfg.moderator, userMsg.Message); !verdict.Allowed {
+		userMsg.Moderation = &verdict
+		conv.Append(userMsg)
+
+		return respondWithPolicy(ctx, store, cfg, conv, verdict)
+	}
+
+	if conv.Coach {
+		userMsg.Fallacies = coachMessage(ctx, cfg.coach, conv, userMsg.Message)
+	}
+
+	conv.Append(userMsg)
+
+	return botTurn(ctx, store, engine, cfg, conv, format, userMsg.Message, extra...)
+}
+
+// botTurn generates the bot's answer to message, moderates it and moves the debate on.
+func botTurn(ctx context.Context, store storage.Store, engine bot.Engine, cfg routeConfig, conv *models.Conversation, format *formats.Format, message string, extra ...bot.Option) (int, any) {
+	// generate bot reply
+	var info bot.ReplyInfo
+
+	// experiment variants go last so they can override the persona's temperature
+	opts := cfg.personas.Options(conv.Persona, conv.Difficulty)
+	opts = append(opts, variantOptions(cfg.experiments, conv)...)
+
+	if format != nil {
+		opts = append(opts, format.Options(conv.Debate)...)
+	}
+
+	// evidence and the topic tools are keyed by the catalog's name, not the user's wording
+	topic := catalogTopicName(ctx, store, conv)
+
+	passages := retrieveEvidence(ctx, cfg.evidence, topic, conv, message)
+	if len(passages) > 0 {
+		opts = append(opts, bot.WithEvidence(evidence.Prompt(passages)))
+	}
+
+	if cfg.toolCalling {
+		opts = append(opts, bot.WithTools(tools.Debate(topic, cfg.evidence, store)))
+	}
+
+	opts = append(opts, extra...)
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	reply, err := generateBotReply(ctx, engine, conv, message, opts...)
+	if err != nil {
+		// keep the failure so experiment reports can count it (best effort)
+		conv.ErrorCount++
+		_ = store.SaveConversation(ctx, conv)
+
+		return http.StatusBadGateway, gin.H{"error": "llm error: " + err.Error(), "conversation_id": conv.ID}
+	}
+
+	// and the reply before it reaches the user
+	botMsg := models.Message{Role: "bot", Message: reply, PromptVersion: info.PromptVersion}
+	for _, call := range info.ToolCalls {
+		botMsg.ToolCalls = append(botMsg.ToolCalls, models.ToolCall(call))
+	}
+
+	if verdict := moderate(ctx, cfg.moderator, reply); !verdict.Allowed {
+		botMsg.Moderation = &verdict
+		conv.Append(botMsg)
+
+		return respondWithPolicy(ctx, store, cfg, conv, verdict)
+	}
+
+	botMsg.Citations = evidence.Cited(reply, passages)
+	conv.Append(botMsg)
+
+	if format != nil && format.CompleteTurn(conv.Debate) {
+		conv.Append(models.Message{Role: "system", Event: models.EventPhaseChange, Message: format.Announcement(conv.Debate)})
+	}
+
+	if conv.ShouldSwitchSides() {
+		conv.SwapSides()
+	}
+
+	// persist (best effort)
+	_ = store.SaveConversation(ctx, conv)
+
+	if cfg.summarizer.Due(conv) {
+		cfg.summarizer.Start(conv, store.SaveSummary)
+	}
+
+	resp := buildChatResponse(cfg, conv)
+	resp.Citations = botMsg.Citations
+
+	if i := lastUserMessage(conv); i >= 0 {
+		resp.Fallacies = conv.Messages[i].Fallacies
+	}
+
+	return http.StatusOK, resp
+}
+
+// buildChatResponse returns the last 5 messages on both sides (max 10 total), with flagged content redacted.
+func buildChatResponse(cfg routeConfig, conv *models.Conversation) models.ChatResponse {
+	return models.ChatResponse{
+		ConversationID: conv.ID,
+		Messages:       models.RedactFlagged(conv.LastN(10)),
+		Topic:          conv.Topic,
+		Stance:         conv.Stance,
+		UserStance:     conv.UserStance,
+		Persona:        conv.Persona,
+		Difficulty:     conv.Difficulty,
+		Phase:          phaseStatus(cfg.formats, conv),
+		Participants:   conv.Participants,
+		NextSpeaker:    nextSpeaker(conv),
+	}
+}
+
+// nextSpeaker reports whose turn it is in an ongoing human debate.
+func nextSpeaker(conv *models.Conversation) string {
+	if conv.Mode != models.ModeHuman || conv.HumanDebateOver() || conv.Judgement != nil {
+		return ""
+	}
 
-		conversation.Append(models.Message{Role: "bot", Message: reply})
+	return conv.NextSpeaker()
+}
+
+// moderate runs the moderator and fails open: an unavailable moderation service
+// shouldn't take the debate down with it.
+func moderate(ctx context.Context, moderator moderation.Moderator, text string) moderation.Verdict {
+	verdict, err := moderator.Moderate(ctx, text)
+	if err != nil {
+		log.Printf("moderation error (allowing message): %v", err)
+		return moderation.Verdict{Allowed: true, Reason: "moderation unavailable"}
+	}
 
-		// persist (best effort)
-		_ = store.SaveConversation(ctx, conversation)
+	return verdict
+}
 
-		// build response with last 5 messages on both sides (max 10 total)
-		resp := models.ChatResponse{
-			ConversationID: conversation.ID,
-			Messages:       conversation.LastN(10),
-			Topic:          conversation.Topic,
-			Stance:         conversation.Stance,
+// catalogTopicName is the name of the catalog topic the debate is linked to, or the debate's
+// own wording when it isn't linked or the catalog is unavailable.
+func catalogTopicName(ctx context.Context, store storage.Store, conv *models.Conversation) string {
+	if conv.TopicID == "" {
+		return conv.Topic
+	}
+
+	topic, err := store.GetTopic(ctx, conv.TopicID)
+	if err != nil {
+		log.Printf("topic catalog unavailable (using the debate's wording): %v", err)
+		return conv.Topic
+	}
+
+	return topic.Name
+}
+
+// retrieveEvidence looks up passages on topic for the reply to message. Like moderation it
+// fails open: without evidence the bot still answers, just without citations.
+func retrieveEvidence(ctx context.Context, retriever evidence.Retriever, topic string, conv *models.Conversation, message string) []evidence.Passage {
+	if retriever == nil {
+		return nil
+	}
+
+	passages, err := retriever.Retrieve(ctx, topic, conv.Topic+" "+message, evidencePassages)
+	if err != nil {
+		log.Printf("evidence retrieval error (answering without evidence): %v", err)
+		return nil
+	}
+
+	return passages
+}
+
+// coachMessage annotates the fallacies in a user message that answers the bot's last reply.
+// Like moderation it fails open: the debate goes on without coaching.
+func coachMessage(ctx context.Context, classifier coach.Classifier, conv *models.Conversation, message string) []models.FallacyAnnotation {
+	var previous string
+
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		if m := conv.Messages[i]; m.Role == "bot" && !m.Flagged() {
+			previous = m.Message
+			break
 		}
+	}
 
-		c.JSON(http.StatusOK, resp)
+	notes, err := classifier.Classify(ctx, conv.Topic, previous, message)
+	if err != nil {
+		log.Printf("coach error (answering without coaching): %v", err)
+		return nil
 	}
+
+	return notes
 }
 
-func getOrCreateConversation(ctx context.Context, store storage.Store, conversationID *string, userTopic *string, userMessage string) (*models.Conversation, error) {
+// respondWithPolicy stores the flagged turn with a policy notice and returns the notice instead of the content.
+func respondWithPolicy(ctx context.Context, store storage.Store, cfg routeConfig, conv *models.Conversation, verdict moderation.Verdict) (int, any) {
+	conv.Append(models.Message{
+		Role:    "system",
+		Event:   models.EventModeration,
+		Message: "This turn was withheld by the moderation policy (" + verdict.Category + "). Please rephrase and keep the debate respectful.",
+	})
+
+	// persist (best effort)
+	_ = store.SaveConversation(ctx, conv)
+
+	resp := buildChatResponse(cfg, conv)
+	resp.Moderation = &verdict
+
+	return http.StatusOK, resp
+}
+
+func getOrCreateConversation(ctx context.Context, store storage.Store, cfg routeConfig, req models.ChatRequest, userStance string) (*models.Conversation, *models.StanceConfirmation, error) {
 	// Determine conversation ID
-	var convID string
-	if conversationID == nil || *conversationID == "" {
+	conversationID := stringValue(req.ConversationID)
+
+	convID := conversationID
+	if convID == "" {
 		convID = ulid.Make().String()
-	} else {
-		convID = *conversationID
 	}
 
 	// Try to get existing conversation if we have a specific ID
-	if conversationID != nil && *conversationID != "" {
-		conv, err := store.GetConversation(ctx, *conversationID)
+	if conversationID != "" {
+		conv, err := store.GetConversation(ctx, conversationID)
 		if err == nil {
-			return conv, nil
+			return conv, nil, nil
 		}
 	}
 
-	// Create new conversation
 	conv := models.NewConversation(convID)
-	setConversationTopicAndStance(conv, userTopic, userMessage)
+	userTopic := req.Topic
+
+	// Create new conversation, but never silently replace a topic the user asked for
+	if id := stringValue(req.TopicID); id != "" {
+		topic, err := store.GetTopic(ctx, id)
+		if err != nil || !topic.Active {
+			return nil, nil, fmt.Errorf("%w %s", errUnknownTopic, id)
+		}
+
+		conv.TopicID, userTopic = topic.ID, &topic.Name
+	} else if userTopic != nil && *userTopic != "" {
+		if verdict := cfg.topicPolicy.CheckTopic(*userTopic); !verdict.Allowed {
+			return nil, nil, &topicRejectedError{verdict: verdict}
+		}
+	}
+
+	var catalog []models.Topic
+
+	category := stringValue(req.Category)
+	if userTopic == nil || *userTopic == "" {
+		var err error
+		if catalog, err = pickableTopics(ctx, store, category); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	confirmation := setConversationTopicAndStance(ctx, cfg.classifier, conv, userTopic, catalog, category, req.Message, userStance)
+
+	if experiment, variant, ok := cfg.experiments.Assign(conv.ID); ok {
+		conv.Experiment = &models.ExperimentAssignment{Experiment: experiment.Name, Variant: variant.Name}
+	}
 
-	return conv, nil
+	return conv, confirmation, nil
 }
 
-func setConversationTopicAndStance(conv *models.Conversation, userTopic *string, userMessage string) {
-	if userTopic != nil && *userTopic != "" {
-		conv.Topic, conv.Stance = bot.ProcessUserTopic(*userTopic, userMessage)
+// pickableTopics lists the active catalog topics a debate without a topic can be given. An
+// unavailable catalog leaves the built-in topics, unless a category was asked for.
+func pickableTopics(ctx context.Context, store storage.Store, category string) ([]models.Topic, error) {
+	catalog, err := store.ListTopics(ctx, storage.TopicFilter{Category: category})
+	if category != "" && (err != nil || len(catalog) == 0) {
+		return nil, fmt.Errorf("%w category %s", errUnknownTopic, category)
+	}
+
+	if err != nil {
+		log.Printf("topic catalog unavailable: %v", err)
+	}
+
+	return catalog, nil
+}
+
+// setConversationTopicAndStance picks the topic and sides for a new conversation. When the
+// user brings their own topic without naming a side, the classifier decides; a low-confidence
+// verdict returns a confirmation request instead of a guess. Without a topic, one of the
+// category is picked from the catalog.
+func setConversationTopicAndStance(ctx context.Context, classifier bot.StanceClassifier, conv *models.Conversation, userTopic *string, catalog []models.Topic, category, userMessage, userStance string) *models.StanceConfirmation {
+	if userTopic == nil || *userTopic == "" {
+		// no topic requested: pick a safe one with the default sides
+		var topic models.Topic
+
+		topic, conv.Stance = bot.PickTopicAndStance(catalog, category)
+		conv.Topic, conv.TopicID = topic.Name, topic.ID
 	} else {
-		conv.Topic, conv.Stance = bot.PickTopicAndStance()
+		conv.Topic = *userTopic
+
+		if userStance == "" {
+			verdict, err := classifier.Classify(ctx, conv.Topic, userMessage)
+			if err != nil || verdict.Stance == bot.StanceUnclear || verdict.Confidence < bot.MinStanceConfidence {
+				return newStanceConfirmation(verdict)
+			}
+
+			userStance = verdict.Stance
+		}
 	}
+
+	// an explicit or classified side from the user wins over the default one
+	if userStance != "" {
+		conv.SetSides(models.OppositeStance(bot.ResolveUserStance(userStance)))
+	} else {
+		conv.SetSides(conv.Stance)
+	}
+
+	return nil
 }
 
-func generateBotReply(ctx context.Context, engine bot.Engine, conv *models.Conversation, userMessage string) (string, error) {
-	history := make([]bot.HistoryItem, len(conv.Messages))
-	for i, msg := range conv.Messages {
-		history[i] = bot.HistoryItem{Role: msg.Role, Message: msg.Message}
+func newStanceConfirmation(verdict bot.StanceVerdict) *models.StanceConfirmation {
+	confirmation := &models.StanceConfirmation{
+		Confidence: verdict.Confidence,
+		Prompt:     "We couldn't tell which side you're arguing. Please resend your message with user_stance set to PRO or CON.",
+	}
+
+	if verdict.Stance == "PRO" || verdict.Stance == "CON" {
+		confirmation.SuggestedStance = verdict.Stance
+		confirmation.Prompt = "It sounds like you're arguing " + verdict.Stance + ". Please confirm by resending your message with user_stance set to PRO or CON."
+	}
+
+	return confirmation
+}
+
+// parseUserStance validates the optional user_stance field; it returns "" when not provided.
+func parseUserStance(stance *string) (string, error) {
+	if stance == nil || *stance == "" {
+		return "", nil
+	}
+
+	return bot.ParseUserStance(*stance)
+}
+
+// applySideOptions updates the switch interval and, on an ongoing debate, honours an
+// explicit change of side by recording a swap in the history.
+func applySideOptions(conv *models.Conversation, userStance string, switchEvery *int) {
+	if switchEvery != nil {
+		conv.SwitchEvery = *switchEvery
+	}
+
+	if conv.UserStance == "" {
+		// conversations created before user stances were tracked
+		conv.UserStance = models.OppositeStance(conv.Stance)
+	}
+
+	if userStance == "" || userStance == bot.StanceRandom || len(conv.Messages) == 0 {
+		return
+	}
+
+	if userStance != conv.UserStance {
+		conv.SwapSides()
+	}
+}
+
+// validatePersona rejects persona and difficulty IDs that aren't in the catalog.
+func validatePersona(catalog *personas.Catalog, persona, difficulty *string) error {
+	if persona != nil && *persona != "" {
+		if _, ok := catalog.Persona(*persona); !ok {
+			return fmt.Errorf("unknown persona %q", *persona)
+		}
+	}
+
+	if difficulty != nil && *difficulty != "" {
+		if _, ok := catalog.Difficulty(*difficulty); !ok {
+			return fmt.Errorf("unknown difficulty %q", *difficulty)
+		}
+	}
+
+	return nil
+}
+
+// applyPersonaOptions sets the requested persona and difficulty, which may change mid-debate,
+// and fills in the catalog defaults for conversations that never picked one.
+func applyPersonaOptions(catalog *personas.Catalog, conv *models.Conversation, persona, difficulty *string) {
+	if persona != nil && *persona != "" {
+		conv.Persona = *persona
+	}
+
+	if difficulty != nil && *difficulty != "" {
+		conv.Difficulty = *difficulty
+	}
+
+	if conv.Persona == "" {
+		conv.Persona = catalog.DefaultPersona
+	}
+
+	if conv.Difficulty == "" {
+		conv.Difficulty = catalog.DefaultDifficulty
+	}
+}
+
+// variantOptions returns the engine options of the conversation's experiment variant. A variant
+// that was removed from the config falls back to the engine defaults.
+func variantOptions(cfg *experiments.Config, conv *models.Conversation) []bot.Option {
+	if conv.Experiment == nil {
+		return nil
+	}
+
+	variant, ok := cfg.Lookup(conv.Experiment.Experiment, conv.Experiment.Variant)
+	if !ok {
+		return nil
+	}
+
+	return variant.Options()
+}
+
+// longDebate is the history length past which the turns covered by the rolling summary are
+// sent to the model as the summary instead.
+const longDebate = 24
+
+func generateBotReply(ctx context.Context, engine bot.Engine, conv *models.Conversation, userMessage string, opts ...bot.Option) (string, error) {
+	messages := conv.Messages
+	if len(messages) > longDebate {
+		messages = summary.Context(conv)
+	}
+
+	history := make([]bot.HistoryItem, 0, len(messages))
+	for _, msg := range messages {
+		// flagged turns and their notices never reach the model
+		if msg.Flagged() || msg.Event == models.EventModeration {
+			continue
+		}
+
+		history = append(history, bot.HistoryItem{Role: msg.Role, Message: msg.Message})
 	}
 
-	return engine.Generate(ctx, conv.Topic, conv.Stance, history, userMessage)
+	return engine.Generate(ctx, conv.Topic, conv.Stance, history, userMessage, opts...)
 }
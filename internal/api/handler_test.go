@@ -1,24 +1,45 @@
 package api
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"github.com/nikoremi97/debate/internal/auth"
 	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/experiments"
+	"github.com/nikoremi97/debate/internal/formats"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
 	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/nikoremi97/debate/internal/summary"
 )
 
 // mock engine avoids real OpenAI calls for tests
 type mockEngine struct{}
 
-func (m mockEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string) (string, error) {
+func (m mockEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
 	return "Test reply on topic: " + topic + " (" + stance + ")", nil
 }
 
+// Complete is unsupported so classifiers fall back to their heuristics.
+func (m mockEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	return "", errors.New("not supported by mock")
+}
+
 func TestChatStart(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -76,3 +97,1760 @@ func TestChatContinue(t *testing.T) {
 		t.Fatalf("expected 200, got %d: %s", continueW.Code, continueW.Body.String())
 	}
 }
+
+func postChat(t *testing.T, r *gin.Engine, body string) (int, models.ChatResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/chat", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp models.ChatResponse
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+
+	return w.Code, resp
+}
+
+func TestChatExplicitUserStance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	code, resp := postChat(t, r, `{"message":"I think it's good","topic":"Remote work is better","user_stance":"con"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if resp.UserStance != "CON" || resp.Stance != "PRO" {
+		t.Fatalf("expected user CON and bot PRO, got user=%s bot=%s", resp.UserStance, resp.Stance)
+	}
+
+	code, _ = postChat(t, r, `{"message":"Hello","user_stance":"sideways"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid stance, got %d", code)
+	}
+}
+
+func TestChatSwitchSides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	_, first := postChat(t, r, `{"message":"Hello","user_stance":"PRO","switch_sides_every":2}`)
+	if first.Stance != "CON" {
+		t.Fatalf("expected bot CON, got %s", first.Stance)
+	}
+
+	_, second := postChat(t, r, `{"conversation_id":"`+first.ConversationID+`","message":"Round two"}`)
+	if second.Stance != "PRO" || second.UserStance != "CON" {
+		t.Fatalf("expected sides to switch after two rounds, got bot=%s user=%s", second.Stance, second.UserStance)
+	}
+
+	last := second.Messages[len(second.Messages)-1]
+	if last.Role != "system" || last.Event != models.EventSideSwitch {
+		t.Fatalf("expected side switch to be recorded, got %+v", last)
+	}
+}
+
+func TestChatAsksToConfirmUnclearStance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	RegisterRoutes(r, store, mockEngine{})
+
+	code, resp := postChat(t, r, `{"message":"Maybe it depends","topic":"Remote work is better"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if resp.StanceConfirmation == nil {
+		t.Fatal("expected a stance confirmation request")
+	}
+
+	if resp.ConversationID != "" || len(resp.Messages) != 0 {
+		t.Fatalf("no conversation should be started before confirmation: %+v", resp)
+	}
+
+	_, resp = postChat(t, r, `{"message":"I agree with this","topic":"Remote work is better"}`)
+	if resp.StanceConfirmation != nil || resp.UserStance != "PRO" {
+		t.Fatalf("expected a confident PRO classification, got %+v", resp)
+	}
+}
+
+// verdictEngine answers the stance classifier with a canned verdict.
+type verdictEngine struct {
+	mockEngine
+	reply string
+}
+
+func (e verdictEngine) Complete(context.Context, []map[string]string, ...bot.Option) (string, error) {
+	return e.reply, nil
+}
+
+func TestChatConfirmsLowConfidenceStance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		reply   string
+		confirm bool
+		stance  string
+	}{
+		{"Below threshold", `{"stance": "PRO", "confidence": 0.4}`, true, ""},
+		{"Confident", `{"stance": "CON", "confidence": 0.9}`, false, "CON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			RegisterRoutes(r, storage.NewMemoryStore(), verdictEngine{reply: tt.reply})
+
+			_, resp := postChat(t, r, `{"message":"I agree with this","topic":"Remote work is better"}`)
+			if (resp.StanceConfirmation != nil) != tt.confirm {
+				t.Fatalf("expected confirmation %v, got %+v", tt.confirm, resp)
+			}
+
+			if !tt.confirm && resp.UserStance != tt.stance {
+				t.Fatalf("expected the model's stance %s, got %q", tt.stance, resp.UserStance)
+			}
+		})
+	}
+}
+
+func TestChatRejectsTopicWithVerdict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	req := httptest.NewRequest("POST", "/chat", strings.NewReader(`{"message":"I agree","topic":"Violence is good","user_stance":"PRO"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body struct {
+		Moderation moderation.Verdict `json:"moderation"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if body.Moderation.Allowed || body.Moderation.Category != "violence" || body.Moderation.Reason == "" {
+		t.Fatalf("expected a structured verdict, got %+v", body.Moderation)
+	}
+}
+
+// keywordModerator blocks any text containing "BLOCKED".
+type keywordModerator struct{}
+
+func (keywordModerator) Moderate(_ context.Context, text string) (moderation.Verdict, error) {
+	if strings.Contains(text, "BLOCKED") {
+		return moderation.Verdict{Reason: "test rule", Category: "test", Severity: moderation.SeverityHigh}, nil
+	}
+
+	return moderation.Verdict{Allowed: true, Reason: "ok"}, nil
+}
+
+// shoutEngine replies with the user's message in upper case.
+type shoutEngine struct{ mockEngine }
+
+func (shoutEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	return "You said: " + strings.ToUpper(userMessage), nil
+}
+
+func TestChatModeratesUserMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	RegisterRoutes(r, store, mockEngine{}, WithModerator(keywordModerator{}))
+
+	code, resp := postChat(t, r, `{"message":"This is BLOCKED"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if resp.Moderation == nil || resp.Moderation.Category != "test" {
+		t.Fatalf("expected a moderation verdict, got %+v", resp.Moderation)
+	}
+
+	for _, m := range resp.Messages {
+		if strings.Contains(m.Message, "BLOCKED") {
+			t.Fatalf("flagged content must not be returned: %+v", m)
+		}
+
+		if m.Role == "bot" {
+			t.Fatalf("no reply should be generated for a flagged message: %+v", m)
+		}
+	}
+
+	stored, err := store.GetConversation(context.Background(), resp.ConversationID)
+	if err != nil {
+		t.Fatalf("flagged turn should be stored: %v", err)
+	}
+
+	if !stored.Messages[0].Flagged() || stored.Messages[0].Message != "This is BLOCKED" {
+		t.Fatalf("expected the original content to be stored with its verdict, got %+v", stored.Messages[0])
+	}
+}
+
+func TestChatModeratesBotReply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	RegisterRoutes(r, store, shoutEngine{}, WithModerator(keywordModerator{}))
+
+	// the user message passes, but the shouted reply is flagged
+	_, resp := postChat(t, r, `{"message":"blocked"}`)
+	if resp.Moderation == nil {
+		t.Fatalf("expected the reply to be withheld, got %+v", resp)
+	}
+
+	stored, _ := store.GetConversation(context.Background(), resp.ConversationID)
+	if stored.Messages[0].Flagged() || !stored.Messages[1].Flagged() {
+		t.Fatalf("expected only the bot reply to be flagged, got %+v", stored.Messages)
+	}
+
+	for _, m := range resp.Messages {
+		if m.Role == "bot" && strings.Contains(m.Message, "BLOCKED") {
+			t.Fatalf("flagged reply must be redacted: %+v", m)
+		}
+	}
+}
+
+func TestChatAllowListDoesNotExcuseCriticalContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	RegisterRoutes(r, store, mockEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	// "sex education" is allow-listed, but only covers itself
+	_, resp := postChat(t, r, `{"message":"Sex education should show pornography", "topic":"Remote work", "user_stance":"PRO"}`)
+	if resp.Moderation == nil || resp.Moderation.Category != "sexual_explicit" {
+		t.Fatalf("expected the critical term to be flagged, got %+v", resp.Moderation)
+	}
+
+	for _, m := range resp.Messages {
+		if strings.Contains(m.Message, "pornography") || m.Role == "bot" {
+			t.Fatalf("expected the message withheld without a reply, got %+v", m)
+		}
+	}
+}
+
+// optionsEngine records the options passed to Generate.
+type optionsEngine struct {
+	mockEngine
+	seen *bot.Options
+}
+
+func (e optionsEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	*e.seen = bot.ApplyOptions(opts...)
+	return "Reply", nil
+}
+
+func TestChatAssignsExperimentVariant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	seen := &bot.Options{}
+
+	cfg, err := experiments.Parse([]byte(`{"experiments":[{"name":"models","active":true,"variants":[{"name":"only","weight":1,"model":"gpt-test"}]}]}`))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	RegisterRoutes(r, store, optionsEngine{seen: seen}, WithExperiments(cfg))
+
+	code, resp := postChat(t, r, `{"message":"Remote work is better"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if seen.Model != "gpt-test" {
+		t.Fatalf("expected variant model to reach the engine, got %q", seen.Model)
+	}
+
+	conv, err := store.GetConversation(context.Background(), resp.ConversationID)
+	if err != nil {
+		t.Fatalf("conversation not stored: %v", err)
+	}
+
+	if conv.Experiment == nil || conv.Experiment.Variant != "only" {
+		t.Fatalf("expected assignment to be persisted, got %+v", conv.Experiment)
+	}
+
+	req := httptest.NewRequest("POST", "/conversations/"+resp.ConversationID+"/rating", strings.NewReader(`{"rating":4}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected rating to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/experiments/models/report", nil))
+
+	var report ExperimentReportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+
+	if len(report.Variants) != 1 || report.Variants[0].Conversations != 1 || report.Variants[0].AvgRating != 4 || report.Variants[0].AvgTurns != 1 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestRatingValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	req := httptest.NewRequest("POST", "/conversations/missing/rating", strings.NewReader(`{"rating":9}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for out-of-range rating, got %d", w.Code)
+	}
+}
+
+func TestChatPersonaAndDifficulty(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	seen := &bot.Options{}
+	RegisterRoutes(r, store, optionsEngine{seen: seen})
+
+	code, resp := postChat(t, r, `{"message":"Hello","persona":"socratic","difficulty":"beginner"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if resp.Persona != "socratic" || resp.Difficulty != "beginner" {
+		t.Fatalf("expected persona and difficulty in the response, got %+v", resp)
+	}
+
+	if seen.Style.Length != "2-3 sentences" || seen.MaxTokens != 200 || !strings.Contains(seen.Style.Persona, "questions") {
+		t.Fatalf("persona options did not reach the engine: %+v", seen)
+	}
+
+	// the choice sticks to the conversation
+	code, resp = postChat(t, r, `{"conversation_id":"`+resp.ConversationID+`","message":"Go on"}`)
+	if code != http.StatusOK || resp.Persona != "socratic" {
+		t.Fatalf("expected the persona to persist, got %d %+v", code, resp)
+	}
+
+	code, _ = postChat(t, r, `{"message":"Hello","persona":"pirate"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown persona, got %d", code)
+	}
+}
+
+func TestListPersonas(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/personas", nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "devils_advocate") || !strings.Contains(w.Body.String(), "expert") {
+		t.Fatalf("unexpected personas response %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestChatStructuredDebate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	seen := &bot.Options{}
+
+	catalog, err := formats.Parse([]byte(`{"formats":[{"id":"mini","name":"Mini","phases":[
+		{"id":"opening","name":"Opening","instructions":"Open.","turns":1,"word_limit":10},
+		{"id":"closing","name":"Closing","instructions":"Close.","turns":1}
+	]}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	RegisterRoutes(r, storage.NewMemoryStore(), optionsEngine{seen: seen}, WithFormats(catalog))
+
+	code, resp := postChat(t, r, `{"message":"Hello","format":"mini","phase":"opening"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if seen.Phase.Name != "Opening" {
+		t.Fatalf("expected the opening phase to reach the engine, got %+v", seen.Phase)
+	}
+
+	if resp.Phase == nil || resp.Phase.Phase != "closing" || resp.Phase.TurnsRemaining != 1 {
+		t.Fatalf("expected the debate to move to the closing, got %+v", resp.Phase)
+	}
+
+	id := resp.ConversationID
+
+	code, _ = postChat(t, r, `{"conversation_id":"`+id+`","message":"Again","phase":"opening"}`)
+	if code != http.StatusConflict {
+		t.Fatalf("expected 409 for an out-of-phase turn, got %d", code)
+	}
+
+	code, _ = postChat(t, r, `{"conversation_id":"`+id+`","message":"Bye","format":"other"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown format, got %d", code)
+	}
+
+	code, resp = postChat(t, r, `{"conversation_id":"`+id+`","message":"Closing words"}`)
+	if code != http.StatusOK || !resp.Phase.Finished {
+		t.Fatalf("expected the debate to finish, got %d %+v", code, resp.Phase)
+	}
+
+	code, _ = postChat(t, r, `{"conversation_id":"`+id+`","message":"One more"}`)
+	if code != http.StatusConflict {
+		t.Fatalf("expected 409 after the debate ended, got %d", code)
+	}
+}
+
+func TestChatPhaseWordLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	long := strings.Repeat("word ", 301)
+
+	code, _ := postChat(t, r, `{"message":"`+long+`","format":"oxford"}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a message over the word limit, got %d", code)
+	}
+}
+
+// judgeEngine answers every Complete call with a fixed judgement, after running during if set.
+type judgeEngine struct {
+	mockEngine
+	during func()
+}
+
+func (e judgeEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	if e.during != nil {
+		e.during()
+	}
+
+	return `{"winner": "user", "summary": "Clear case.", "scores": [{"criterion": "argument_strength", "user": 8, "bot": 6}], "fallacies": [], "turn_feedback": []}`, nil
+}
+
+func TestJudgeConversation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), judgeEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	code, resp := postChat(t, r, `{"message":"Hello"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/conversations/"+resp.ConversationID+"/judge", nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"winner":"user"`) {
+		t.Fatalf("unexpected judge response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+resp.ConversationID, nil))
+
+	if !strings.Contains(w.Body.String(), `"judgement"`) {
+		t.Fatalf("expected the judgement to be persisted: %s", w.Body.String())
+	}
+
+	code, _ = postChat(t, r, `{"conversation_id":"`+resp.ConversationID+`","message":"One more point"}`)
+	if code != http.StatusConflict {
+		t.Fatalf("expected 409 for a judged debate, got %d", code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/conversations/missing/judge", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown conversation, got %d", w.Code)
+	}
+}
+
+func TestJudgeKeepsTurnsSavedMeanwhile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+
+	var id string
+
+	// a turn is saved while the judge is thinking
+	during := func() {
+		conv, _ := store.GetConversation(context.Background(), id)
+		conv.Append(models.Message{Role: "user", Message: "A last word"})
+		_ = store.SaveConversation(context.Background(), conv)
+	}
+
+	RegisterRoutes(r, store, judgeEngine{during: during}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	_, resp := postChat(t, r, `{"message":"Hello"}`)
+	id = resp.ConversationID
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/conversations/"+id+"/judge", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	conv, _ := store.GetConversation(context.Background(), id)
+	if conv.Judgement == nil || conv.Messages[len(conv.Messages)-1].Message != "A last word" {
+		t.Fatalf("expected both the verdict and the turn saved meanwhile, got %+v", conv)
+	}
+}
+
+// raceStore runs during right after a conversation is read, to save something in between.
+type raceStore struct {
+	storage.Store
+	during func()
+}
+
+func (s *raceStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
+	conv, err := s.Store.GetConversation(ctx, id)
+	if s.during != nil {
+		s.during()
+	}
+
+	return conv, err
+}
+
+func TestRatingKeepsTurnsSavedMeanwhile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	race := &raceStore{Store: store}
+	RegisterRoutes(r, race, mockEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	_, resp := postChat(t, r, `{"message":"Hello"}`)
+	id := resp.ConversationID
+
+	// a turn is saved between the rating handler's read and its write
+	race.during = func() {
+		conv, _ := store.GetConversation(context.Background(), id)
+		conv.Append(models.Message{Role: "user", Message: "A last word"})
+		_ = store.SaveConversation(context.Background(), conv)
+	}
+
+	if code, _ := postAs(t, r, "/conversations/"+id+"/rating", "", `{"rating":4}`); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	conv, _ := store.GetConversation(context.Background(), id)
+	if conv.Rating != 4 || conv.Messages[len(conv.Messages)-1].Message != "A last word" {
+		t.Fatalf("expected both the rating and the turn saved meanwhile, got %+v", conv)
+	}
+}
+
+// mapEngine answers every Complete call with a fixed argument map and counts the calls.
+type mapEngine struct {
+	mockEngine
+	n *atomic.Int32
+}
+
+func (e mapEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	e.n.Add(1)
+	return `{"claims": [{"id": "a", "turn": 1, "text": "Hello is a claim."}, {"id": "b", "turn": 2, "text": "A rebuttal."}],
+	 "relations": [{"from": "b", "to": "a", "type": "attack"}]}`, nil
+}
+
+func TestArgumentMap(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := &atomic.Int32{}
+	RegisterRoutes(r, storage.NewMemoryStore(), mapEngine{n: calls}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	code, resp := postChat(t, r, `{"message":"Hello"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	get := func(path string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if len(header) == 2 {
+			req.Header.Set(header[0], header[1])
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	path := "/conversations/" + resp.ConversationID + "/argument-map"
+
+	w := get(path)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"from":"c2","to":"c1","type":"attack"`) {
+		t.Fatalf("unexpected argument map response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = get(path+"?format=dot", "Accept", "application/json")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/vnd.graphviz") ||
+		!strings.Contains(w.Body.String(), "c2 -> c1") {
+		t.Fatalf("unexpected DOT response %d (%s): %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	if w = get(path, "Accept", "text/vnd.graphviz"); !strings.HasPrefix(w.Body.String(), "digraph") {
+		t.Fatalf("expected the Accept header to select DOT, got %s", w.Body.String())
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected the stored map to be reused, got %d analyses", calls.Load())
+	}
+
+	postChat(t, r, `{"conversation_id":"`+resp.ConversationID+`","message":"Another point"}`)
+	get(path)
+	get(path + "?refresh=true")
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected a new turn and ?refresh=true to rebuild the map, got %d analyses", calls.Load())
+	}
+
+	if w = get("/conversations/missing/argument-map"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown conversation, got %d", w.Code)
+	}
+}
+
+func TestAutoplaySync(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	req := httptest.NewRequest("POST", "/debates/autoplay", strings.NewReader(`{"topic":"Tabs are better than spaces","rounds":2,"pro":{"persona":"lawyer"},"con":{"persona":"socratic"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp AutoplayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if resp.Job.Status != "completed" || resp.Conversation == nil || len(resp.Conversation.Messages) != 4 {
+		t.Fatalf("expected a completed 4-turn debate, got %+v", resp)
+	}
+
+	code, _ := postChat(t, r, `{"conversation_id":"`+resp.Job.ConversationID+`","message":"Can I join?"}`)
+	if code != http.StatusConflict {
+		t.Fatalf("expected 409 when chatting in an autoplay debate, got %d", code)
+	}
+}
+
+func TestAutoplayValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	for body, want := range map[string]int{
+		`{"topic":"Tabs","rounds":50}`:                http.StatusBadRequest,
+		`{"topic":"Tabs","pro":{"persona":"pirate"}}`: http.StatusBadRequest,
+		`{"rounds":1}`: http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest("POST", "/debates/autoplay", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Fatalf("%s: expected %d, got %d", body, want, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("DELETE", "/debates/autoplay/missing", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown job, got %d", w.Code)
+	}
+}
+
+func TestAutoplayJobBelongsToItsOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{})
+
+	send := func(method, path, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := send("POST", "/debates/autoplay", "alice", `{"topic":"Tabs are better than spaces","rounds":1,"async":true}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var resp AutoplayResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Job.Owner != "alice" {
+		t.Fatalf("expected a job owned by alice, got %s", w.Body.String())
+	}
+
+	path := "/debates/autoplay/" + resp.Job.ID
+
+	for _, method := range []string{"GET", "DELETE"} {
+		for userID, want := range map[string]int{"mallory": http.StatusForbidden, "": http.StatusForbidden, "alice": http.StatusOK} {
+			if w := send(method, path, userID, ""); w.Code != want {
+				t.Fatalf("%s as %q: expected %d, got %d", method, userID, want, w.Code)
+			}
+		}
+	}
+}
+
+// refereeEngine reviews rounds and judges human debates with fixed answers.
+type refereeEngine struct{ mockEngine }
+
+func (refereeEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	if strings.Contains(messages[0]["content"], "You moderate a debate") {
+		return `{"summary": "Both sides stayed on topic.", "off_topic": [], "fallacies": []}`, nil
+	}
+
+	return `{"winner": "con", "summary": "CON was sharper.", "scores": [{"criterion": "argument_strength", "pro": 6, "con": 8}]}`, nil
+}
+
+// postAs sends a JSON body as the given user.
+func postAs(t *testing.T, r *gin.Engine, path, userID, body string) (int, models.ChatResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	if userID != "" {
+		req.Header.Set("X-User-ID", userID)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp models.ChatResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return w.Code, resp
+}
+
+func TestHumanDebate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), refereeEngine{})
+
+	if code, _ := postAs(t, r, "/debates", "", `{"topic":"Tabs are better than spaces","stance":"PRO"}`); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a user, got %d", code)
+	}
+
+	code, created := postAs(t, r, "/debates", "alice", `{"topic":"Tabs are better than spaces","stance":"PRO","rounds":1,"judge":true}`)
+	if code != http.StatusCreated || created.ConversationID == "" {
+		t.Fatalf("expected 201, got %d", code)
+	}
+
+	id := created.ConversationID
+	turn := func(userID, msg string) int {
+		code, _ := postAs(t, r, "/chat", userID, `{"conversation_id":"`+id+`","message":"`+msg+`"}`)
+		return code
+	}
+
+	if code := turn("alice", "Opening"); code != http.StatusConflict {
+		t.Fatalf("expected 409 before an opponent joins, got %d", code)
+	}
+
+	code, joined := postAs(t, r, "/debates/"+id+"/join", "bob", "")
+	if code != http.StatusOK || len(joined.Participants) != 2 || joined.Participants[1].Stance != models.StanceCon {
+		t.Fatalf("expected bob to join as CON, got %d %+v", code, joined.Participants)
+	}
+
+	if code, _ := postAs(t, r, "/debates/"+id+"/join", "carol", ""); code != http.StatusConflict {
+		t.Fatalf("expected 409 for a full debate, got %d", code)
+	}
+
+	if code := turn("carol", "Hi"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a non-participant, got %d", code)
+	}
+
+	if code := turn("bob", "I go first"); code != http.StatusConflict {
+		t.Fatalf("expected 409 when CON speaks out of turn, got %d", code)
+	}
+
+	if code := turn("alice", "Tabs are accessible."); code != http.StatusOK {
+		t.Fatalf("expected 200 for PRO's turn, got %d", code)
+	}
+
+	code, resp := postAs(t, r, "/chat", "bob", `{"conversation_id":"`+id+`","message":"Spaces never break alignment."}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200 for CON's turn, got %d", code)
+	}
+
+	var review, over bool
+
+	for _, m := range resp.Messages {
+		review = review || (m.Role == models.RoleModerator && m.Report != nil)
+		over = over || m.Event == models.EventDebateOver
+	}
+
+	if !review || !over {
+		t.Fatalf("expected a round review and the end of the debate, got %+v", resp.Messages)
+	}
+
+	req := httptest.NewRequest("GET", "/conversations/"+id, nil)
+	req.Header.Set("X-User-ID", "alice")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `"winner":"con"`) {
+		t.Fatalf("expected the finished debate to be judged: %s", w.Body.String())
+	}
+
+	// outsiders can neither judge nor rate a private debate
+	for _, path := range []string{"/conversations/" + id + "/judge", "/conversations/" + id + "/rating"} {
+		if code, _ := postAs(t, r, path, "mallory", `{"rating":1}`); code != http.StatusForbidden {
+			t.Fatalf("expected 403 for an outsider on %s, got %d", path, code)
+		}
+	}
+
+	if code, _ := postAs(t, r, "/conversations/"+id+"/rating", "bob", `{"rating":4}`); code != http.StatusOK {
+		t.Fatalf("expected a participant to rate the debate, got %d", code)
+	}
+
+	if code := turn("alice", "One more thing"); code != http.StatusConflict {
+		t.Fatalf("expected 409 after the debate is over, got %d", code)
+	}
+}
+
+func TestListConversationsHidesHumanDebates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), refereeEngine{})
+
+	_, created := postAs(t, r, "/debates", "alice", `{"topic":"Tabs are better than spaces","stance":"PRO","spectators":["teacher"]}`)
+	id := created.ConversationID
+
+	for userID, listed := range map[string]bool{"alice": true, "teacher": true, "mallory": false, "": false} {
+		req := httptest.NewRequest("GET", "/conversations", nil)
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var list ListConversationsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+			t.Fatalf("invalid list response: %v", err)
+		}
+
+		found := slices.ContainsFunc(list.Conversations, func(c storage.ConversationSummary) bool { return c.ID == id })
+		if found != listed {
+			t.Fatalf("%q listing conversations: expected listed=%v, got %+v", userID, listed, list.Conversations)
+		}
+	}
+}
+
+func TestSpectatorEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), refereeEngine{})
+
+	_, created := postAs(t, r, "/debates", "alice", `{"topic":"Tabs are better than spaces","stance":"PRO","spectators":["teacher"]}`)
+	id := created.ConversationID
+	postAs(t, r, "/debates/"+id+"/join", "bob", "")
+
+	for userID, want := range map[string]int{"teacher": http.StatusOK, "mallory": http.StatusForbidden, "": http.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/conversations/"+id, nil)
+		req.Header.Set("X-User-ID", userID)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != want {
+			t.Fatalf("%q reading the debate: expected %d, got %d", userID, want, w.Code)
+		}
+	}
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch := func(userID string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/conversations/"+id+"/events", nil)
+		req.Header.Set("X-User-ID", userID)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("subscribe: %v", err)
+		}
+
+		return resp
+	}
+
+	if resp := watch("mallory"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for an outsider, got %d", resp.StatusCode)
+	}
+
+	resp := watch("teacher")
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	next := func() string {
+		for lines.Scan() {
+			if event, ok := strings.CutPrefix(lines.Text(), "event:"); ok {
+				lines.Scan()
+				return event + " " + lines.Text()
+			}
+		}
+
+		t.Fatalf("stream ended: %v", lines.Err())
+
+		return ""
+	}
+
+	if got := next(); !strings.HasPrefix(got, "ready") {
+		t.Fatalf("expected the stream to open with ready, got %q", got)
+	}
+
+	postAs(t, r, "/chat", "alice", `{"conversation_id":"`+id+`","message":"Tabs are accessible."}`)
+
+	if got := next(); !strings.HasPrefix(got, "message") || !strings.Contains(got, "Tabs are accessible.") {
+		t.Fatalf("expected alice's turn, got %q", got)
+	}
+}
+
+// streamEngine streams its reply word by word.
+type streamEngine struct{ mockEngine }
+
+func (streamEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	reply := "Streaming beats polling."
+	if o := bot.ApplyOptions(opts...); o.Stream != nil {
+		for _, word := range strings.SplitAfter(reply, " ") {
+			o.Stream(word)
+		}
+	}
+
+	return reply, nil
+}
+
+// keyService accepts a single API key.
+type keyService struct{}
+
+func (keyService) GetAPIKey(context.Context) (string, error) { return "secret", nil }
+func (keyService) RefreshCache(context.Context) error        { return nil }
+
+func (keyService) ValidateAPIKey(_ context.Context, key string) error {
+	if key != "secret" {
+		return errors.New("invalid key")
+	}
+
+	return nil
+}
+
+func TestWebSocketChat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(auth.Middleware(keyService{}))
+	RegisterRoutes(r, storage.NewMemoryStore(), streamEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	dial := func(key string) (*websocket.Conn, error) {
+		config, err := websocket.NewConfig("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config.Header.Set(auth.APIKeyHeader, key)
+
+		return websocket.DialConfig(config)
+	}
+
+	if _, err := dial("wrong"); err == nil {
+		t.Fatal("the handshake should be rejected without a valid API key")
+	}
+
+	conn, err := dial("secret")
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	receive := func() WSResponse {
+		var frame WSResponse
+		if err := websocket.JSON.Receive(conn, &frame); err != nil {
+			t.Fatalf("receive: %v", err)
+		}
+
+		return frame
+	}
+
+	_ = websocket.JSON.Send(conn, WSRequest{Type: "ping", ID: "p1"})
+
+	if frame := receive(); frame.Type != "pong" || frame.ID != "p1" {
+		t.Fatalf("expected a pong, got %+v", frame)
+	}
+
+	_ = websocket.JSON.Send(conn, WSRequest{Type: "message", ID: "m1", ChatRequest: models.ChatRequest{Message: "Polling is simpler"}})
+
+	var streamed string
+
+	frame := receive()
+	for ; frame.Type == "delta"; frame = receive() {
+		streamed += frame.Delta
+	}
+
+	if streamed != "Streaming beats polling." || frame.Type != "reply" || frame.ID != "m1" || frame.Status != http.StatusOK {
+		t.Fatalf("expected the streamed reply then the response, got %q and %+v", streamed, frame)
+	}
+
+	_ = websocket.Message.Send(conn, "not json")
+
+	if frame := receive(); frame.Type != "error" || frame.Status != http.StatusBadRequest {
+		t.Fatalf("expected an error frame for a malformed frame, got %+v", frame)
+	}
+
+	sideways := "SIDEWAYS"
+	_ = websocket.JSON.Send(conn, WSRequest{Type: "message", ID: "m2", ChatRequest: models.ChatRequest{Message: "Hi", UserStance: &sideways}})
+
+	if frame := receive(); frame.Type != "error" || frame.ID != "m2" || frame.Status != http.StatusBadRequest {
+		t.Fatalf("expected the /chat error for an invalid stance, got %+v", frame)
+	}
+}
+
+// draftEngine streams a conceding draft on every odd call and the shouted user message on
+// every even one, so a guarded engine regenerates each turn once.
+type draftEngine struct {
+	mockEngine
+	n *atomic.Int32
+}
+
+func (e draftEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	reply := "I concede, you are right."
+	if e.n.Add(1)%2 == 0 {
+		reply = "NO: " + strings.ToUpper(userMessage)
+	}
+
+	if o := bot.ApplyOptions(opts...); o.Stream != nil {
+		for _, word := range strings.SplitAfter(reply, " ") {
+			o.Stream(word)
+		}
+	}
+
+	return reply, nil
+}
+
+func TestWebSocketWithholdsRejectedText(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	engine := bot.NewGuardedEngine(draftEngine{n: &atomic.Int32{}})
+	RegisterRoutes(r, storage.NewMemoryStore(), engine, WithModerator(keywordModerator{}), WithStanceClassifier(bot.KeywordClassifier{}))
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", "", srv.URL)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	stance := "PRO"
+
+	// turn returns every frame of a turn, raw, and the streamed text
+	turn := func(id, message string) ([]string, string) {
+		_ = websocket.JSON.Send(conn, WSRequest{Type: "message", ID: id, ChatRequest: models.ChatRequest{Message: message, UserStance: &stance}})
+
+		var frames []string
+		var streamed string
+
+		for {
+			var raw string
+			if err := websocket.Message.Receive(conn, &raw); err != nil {
+				t.Fatalf("receive: %v", err)
+			}
+
+			frames = append(frames, raw)
+
+			var frame WSResponse
+			_ = json.Unmarshal([]byte(raw), &frame)
+
+			if frame.Type != "delta" {
+				return frames, streamed
+			}
+
+			streamed += frame.Delta
+		}
+	}
+
+	frames, streamed := turn("m1", "polling works")
+	if streamed != "NO: POLLING WORKS" {
+		t.Fatalf("expected only the accepted draft streamed, got %q", streamed)
+	}
+
+	for _, f := range frames {
+		if strings.Contains(f, "concede") {
+			t.Fatalf("a rejected draft reached the client: %s", f)
+		}
+	}
+
+	// the accepted draft is withheld by moderation
+	frames, streamed = turn("m2", "blocked idea")
+	if streamed != "" {
+		t.Fatalf("expected nothing streamed for a withheld reply, got %q", streamed)
+	}
+
+	for _, f := range frames {
+		if strings.Contains(f, "concede") || strings.Contains(f, "BLOCKED") {
+			t.Fatalf("withheld text reached the client: %s", f)
+		}
+	}
+}
+
+// countingEngine numbers its replies so regenerated turns can be told apart.
+type countingEngine struct {
+	mockEngine
+	n *atomic.Int32
+}
+
+func (e countingEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	return fmt.Sprintf("Reply %d to %s", e.n.Add(1), userMessage), nil
+}
+
+func sendJSON(t *testing.T, r *gin.Engine, method, path, body string) (int, models.ChatResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp models.ChatResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+	return w.Code, resp
+}
+
+func TestEditTurns(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), countingEngine{n: &atomic.Int32{}})
+
+	_, resp := postChat(t, r, `{"message":"First","user_stance":"PRO"}`)
+	id := resp.ConversationID
+	_, resp = postChat(t, r, `{"conversation_id":"`+id+`","message":"Second"}`)
+
+	if len(resp.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %+v", resp.Messages)
+	}
+
+	second := resp.Messages[2]
+
+	code, resp := sendJSON(t, r, "POST", "/conversations/"+id+"/regenerate", ``)
+	if code != http.StatusOK || len(resp.Messages) != 4 {
+		t.Fatalf("expected the last reply to be replaced, got %d %+v", code, resp.Messages)
+	}
+
+	if resp.Messages[2].ID != second.ID || resp.Messages[3].Message != "Reply 3 to Second" {
+		t.Fatalf("expected a new reply to the same message, got %+v", resp.Messages[2:])
+	}
+
+	code, resp = sendJSON(t, r, "PUT", "/conversations/"+id+"/messages/"+second.ID, `{"message":"Edited"}`)
+	if code != http.StatusOK || len(resp.Messages) != 4 {
+		t.Fatalf("expected the edit to replace the last turn, got %d %+v", code, resp.Messages)
+	}
+
+	if resp.Messages[2].ID != second.ID || resp.Messages[2].Message != "Edited" || resp.Messages[3].Message != "Reply 4 to Edited" {
+		t.Fatalf("expected the edited message to keep its ID and get a new reply, got %+v", resp.Messages[2:])
+	}
+
+	code, _ = sendJSON(t, r, "PUT", "/conversations/"+id+"/messages/"+resp.Messages[3].ID, `{"message":"Nope"}`)
+	if code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for editing a bot reply, got %d", code)
+	}
+
+	code, _ = sendJSON(t, r, "PUT", "/conversations/"+id+"/messages/missing", `{"message":"Nope"}`)
+	if code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown message, got %d", code)
+	}
+
+	code, resp = sendJSON(t, r, "POST", "/conversations/"+id+"/rewind", `{"message_id":"`+resp.Messages[1].ID+`"}`)
+	if code != http.StatusOK || len(resp.Messages) != 2 {
+		t.Fatalf("expected the conversation to be rewound to the first reply, got %d %+v", code, resp.Messages)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+id, nil))
+
+	var conv models.Conversation
+	if err := json.Unmarshal(w.Body.Bytes(), &conv); err != nil || len(conv.Messages) != 2 {
+		t.Fatalf("expected the rewind to be saved, got %s", w.Body.String())
+	}
+
+	code, _ = sendJSON(t, r, "POST", "/conversations/unknown/regenerate", ``)
+	if code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown conversation, got %d", code)
+	}
+}
+
+func TestEditTurnsStructuredDebate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	catalog, err := formats.Parse([]byte(`{"formats":[{"id":"mini","name":"Mini","phases":[
+		{"id":"opening","name":"Opening","instructions":"Open.","turns":1,"word_limit":3},
+		{"id":"closing","name":"Closing","instructions":"Close.","turns":1}
+	]}]}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	RegisterRoutes(r, storage.NewMemoryStore(), countingEngine{n: &atomic.Int32{}}, WithFormats(catalog))
+
+	_, resp := postChat(t, r, `{"message":"Hello","format":"mini"}`)
+	id, first := resp.ConversationID, resp.Messages[0].ID
+
+	_, resp = postChat(t, r, `{"conversation_id":"`+id+`","message":"Closing words"}`)
+	if !resp.Phase.Finished {
+		t.Fatalf("expected the debate to finish, got %+v", resp.Phase)
+	}
+
+	code, _ := sendJSON(t, r, "PUT", "/conversations/"+id+"/messages/"+first, `{"message":"far too many words here"}`)
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected the opening word limit to apply to the edit, got %d", code)
+	}
+
+	code, resp = sendJSON(t, r, "PUT", "/conversations/"+id+"/messages/"+first, `{"message":"Hi again"}`)
+	if code != http.StatusOK || len(resp.Messages) != 3 || resp.Phase.Phase != "closing" {
+		t.Fatalf("expected the debate to be wound back to the closing, got %d %+v", code, resp.Phase)
+	}
+}
+
+func TestForkConversation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), countingEngine{n: &atomic.Int32{}})
+
+	_, resp := postChat(t, r, `{"message":"First","user_stance":"PRO"}`)
+	id := resp.ConversationID
+	_, resp = postChat(t, r, `{"conversation_id":"`+id+`","message":"Second"}`)
+	firstReply := resp.Messages[1].ID
+
+	fork := func(id, body string) (int, models.Conversation) {
+		req := httptest.NewRequest("POST", "/conversations/"+id+"/fork", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		var conv models.Conversation
+		_ = json.Unmarshal(w.Body.Bytes(), &conv)
+
+		return w.Code, conv
+	}
+
+	code, branch := fork(id, `{"message_id":"`+firstReply+`"}`)
+	if code != http.StatusCreated || branch.ParentID != id || branch.ForkedFrom != firstReply || len(branch.Messages) != 2 {
+		t.Fatalf("expected a branch with the first exchange, got %d %+v", code, branch)
+	}
+
+	code, resp = postChat(t, r, `{"conversation_id":"`+branch.ID+`","message":"Other"}`)
+	if code != http.StatusOK || len(resp.Messages) != 4 || resp.Messages[2].Message != "Other" {
+		t.Fatalf("expected the branch to continue on its own, got %d %+v", code, resp.Messages)
+	}
+
+	_, nested := fork(branch.ID, `{"message_id":"`+firstReply+`"}`)
+
+	if code, _ := fork(id, `{"message_id":"missing"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown message, got %d", code)
+	}
+
+	if code, _ := fork("unknown", `{"message_id":"`+firstReply+`"}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown conversation, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+nested.ID+"/tree", nil))
+
+	var tree TreeNode
+	if err := json.Unmarshal(w.Body.Bytes(), &tree); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected the tree, got %d %s", w.Code, w.Body.String())
+	}
+
+	if tree.ID != id || tree.MessageCount != 4 || len(tree.Children) != 1 {
+		t.Fatalf("expected the original conversation at the root, got %+v", tree)
+	}
+
+	child := tree.Children[0]
+	if child.ID != branch.ID || len(child.Children) != 1 || child.Children[0].ID != nested.ID {
+		t.Fatalf("expected the branch and its own branch below the root, got %+v", child)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+id, nil))
+
+	var original models.Conversation
+	if err := json.Unmarshal(w.Body.Bytes(), &original); err != nil || len(original.Messages) != 4 || original.Messages[2].Message != "Second" {
+		t.Fatalf("expected the original thread to be kept, got %s", w.Body.String())
+	}
+}
+
+// citingEngine cites the second evidence passage it is given.
+type citingEngine struct {
+	mockEngine
+	seen *bot.Options
+}
+
+func (e citingEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	*e.seen = bot.ApplyOptions(opts...)
+	return "Commutes eat almost an hour a day [2].", nil
+}
+
+func TestChatCitesEvidence(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	seen := &bot.Options{}
+
+	// "Remote work" is linked to the catalog's "Remote Work vs Office Work", whose folder it uses
+	idx := evidence.NewIndex()
+	idx.Add("remote-work-vs-office-work", "remote-work/commute.md", "The average commute takes 27 minutes each way.")
+	idx.Add("remote-work-vs-office-work", "remote-work/focus.md", "Remote teams report more focus time and a shorter commute.")
+	idx.Add("pineapple", "pineapple/history.md", "Hawaiian pizza was invented in Canada.")
+
+	RegisterRoutes(r, storage.NewMemoryStore(), citingEngine{seen: seen}, WithEvidence(idx))
+
+	code, resp := postChat(t, r, `{"message":"Office commutes are a waste","topic":"Remote work","user_stance":"PRO"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if len(seen.Evidence) != 2 || seen.Evidence[0].Marker != 1 {
+		t.Fatalf("expected the topic's two commute passages in the prompt, got %+v", seen.Evidence)
+	}
+
+	if len(resp.Citations) != 1 || resp.Citations[0].Marker != 2 || resp.Citations[0].Source != seen.Evidence[1].Source {
+		t.Fatalf("expected the cited passage in the response, got %+v", resp.Citations)
+	}
+
+	if last := resp.Messages[len(resp.Messages)-1]; len(last.Citations) != 1 {
+		t.Fatalf("expected the citation to be kept on the reply, got %+v", last)
+	}
+
+	_, resp = postChat(t, r, `{"message":"Hello","topic":"Tabs are better than spaces","user_stance":"PRO"}`)
+	if len(seen.Evidence) != 0 || resp.Citations != nil {
+		t.Fatalf("expected no evidence for a topic without documents, got %+v %+v", seen.Evidence, resp.Citations)
+	}
+}
+
+// descriptionEngine asks for the topic description before it replies.
+type descriptionEngine struct{ mockEngine }
+
+func (descriptionEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	call := bot.ApplyOptions(opts...).Tools.Call(ctx, "get_topic_description", "")
+	return "Described: " + call.Result + call.Error, nil
+}
+
+func TestLinkedTopicKeysEvidenceAndTools(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	seen := &bot.Options{}
+
+	// the corpus is filed under the catalog topic, not the user's wording
+	idx := evidence.NewIndex()
+	idx.Add("artificial-intelligence-regulation", "ai/oversight.md", "Regulators audit high-risk AI systems.")
+
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), citingEngine{seen: seen}, WithEvidence(idx), WithStanceClassifier(bot.KeywordClassifier{}))
+
+	_, resp := postChat(t, r, `{"message":"Who audits AI systems?", "topic":"Should AI be regulated?", "user_stance":"PRO"}`)
+	if resp.SuggestedTopic == nil || len(seen.Evidence) != 1 {
+		t.Fatalf("expected evidence from the linked catalog topic, got %+v", seen.Evidence)
+	}
+
+	r = gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), descriptionEngine{}, WithToolCalling(), WithStanceClassifier(bot.KeywordClassifier{}))
+
+	_, resp = postChat(t, r, `{"message":"Governments must act", "topic":"Should AI be regulated?", "user_stance":"PRO"}`)
+	if reply := resp.Messages[len(resp.Messages)-1].Message; strings.Contains(reply, "not found") || reply == "Described: " {
+		t.Fatalf("expected the catalog topic's description, got %q", reply)
+	}
+}
+
+// toolEngine checks a number with the calculator before it replies.
+type toolEngine struct{ mockEngine }
+
+func (toolEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	o := bot.ApplyOptions(opts...)
+	if o.Tools.Len() == 0 {
+		return "No tools.", nil
+	}
+
+	call := o.Tools.Call(ctx, "calculate", `{"expression": "27 * 2 * 5"}`)
+	o.Info.ToolCalls = append(o.Info.ToolCalls, call)
+
+	return "That is " + call.Result + " minutes a week.", nil
+}
+
+func TestChatRecordsToolCalls(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), toolEngine{}, WithToolCalling())
+
+	code, resp := postChat(t, r, `{"message":"Commutes are short","topic":"Remote work","user_stance":"CON"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+resp.ConversationID, nil))
+
+	var conv models.Conversation
+	if err := json.Unmarshal(w.Body.Bytes(), &conv); err != nil {
+		t.Fatalf("failed to decode conversation: %v", err)
+	}
+
+	reply := conv.Messages[len(conv.Messages)-1]
+	if reply.Message != "That is 270 minutes a week." || len(reply.ToolCalls) != 1 || reply.ToolCalls[0].Name != "calculate" || reply.ToolCalls[0].Result != "270" {
+		t.Fatalf("expected the tool call to be stored with the reply, got %+v", reply)
+	}
+
+	r = gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), toolEngine{})
+
+	if _, resp := postChat(t, r, `{"message":"Hi","topic":"Remote work","user_stance":"CON"}`); resp.Messages[1].Message != "No tools." {
+		t.Fatalf("expected no tools without tool calling, got %+v", resp.Messages)
+	}
+}
+
+// stubCoach flags ad hominems on "lazy" and records the arguments it was asked about.
+type stubCoach struct{ previous *[]string }
+
+func (c stubCoach) Classify(_ context.Context, _, previous, message string) ([]models.FallacyAnnotation, error) {
+	*c.previous = append(*c.previous, previous)
+
+	if !strings.Contains(message, "lazy") {
+		return nil, nil
+	}
+
+	return []models.FallacyAnnotation{{Fallacy: "ad_hominem", Name: "Ad hominem", Explanation: "Attacks people."}}, nil
+}
+
+func TestChatCoachMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	var previous []string
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{}, WithCoach(stubCoach{previous: &previous}))
+
+	_, resp := postChat(t, r, `{"message":"Offices are fine","topic":"Remote work","user_stance":"CON"}`)
+	if len(previous) != 0 {
+		t.Fatalf("expected no coaching before coach mode is on, got %d calls", len(previous))
+	}
+
+	id := resp.ConversationID
+
+	code, resp := postChat(t, r, `{"conversation_id":"`+id+`","message":"Remote workers are lazy","coach":true}`)
+	if code != http.StatusOK || len(resp.Fallacies) != 1 || resp.Fallacies[0].Fallacy != "ad_hominem" {
+		t.Fatalf("expected the fallacy with the reply, got %d %+v", code, resp.Fallacies)
+	}
+
+	if len(previous) != 1 || previous[0] == "" {
+		t.Fatalf("expected the bot's last reply to be passed as the opposing argument, got %q", previous)
+	}
+
+	if _, resp = postChat(t, r, `{"conversation_id":"`+id+`","message":"Offices build culture"}`); resp.Fallacies != nil {
+		t.Fatalf("expected no notes for a sound argument, got %+v", resp.Fallacies)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+id, nil))
+
+	var conv models.Conversation
+	if err := json.Unmarshal(w.Body.Bytes(), &conv); err != nil {
+		t.Fatalf("failed to decode conversation: %v", err)
+	}
+
+	if !conv.Coach || len(conv.Messages) != 6 || len(conv.Messages[2].Fallacies) != 1 {
+		t.Fatalf("expected coach mode and the notes to be stored with the user message, got %+v", conv)
+	}
+}
+
+// briefEngine answers every Complete call with a fixed brief and counts the calls.
+type briefEngine struct {
+	mockEngine
+	n *atomic.Int32
+}
+
+func (e briefEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	e.n.Add(1)
+	return `{"pro": [{"claim": "Regulation prevents harm", "reasoning": "Rules set a floor.", "evidence": [], "rebuttals": []}],
+	 "con": [{"claim": "Regulation slows research", "reasoning": "Compliance costs time.", "evidence": [], "rebuttals": []}],
+	 "definitions": []}`, nil
+}
+
+func TestTopicBrief(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	calls := &atomic.Int32{}
+	RegisterRoutes(r, storage.NewMemoryStore(), briefEngine{n: calls})
+
+	post := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("POST", path, nil))
+
+		return w
+	}
+
+	path := "/topics/01HZ0000000000000000000001/brief"
+
+	w := post(path)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"topic":"Artificial Intelligence Regulation"`) {
+		t.Fatalf("unexpected brief response %d: %s", w.Code, w.Body.String())
+	}
+
+	w = post(path)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Regulation slows research") {
+		t.Fatalf("unexpected cached brief response %d: %s", w.Code, w.Body.String())
+	}
+
+	if calls.Load() != 1 {
+		t.Fatalf("expected the cached brief to be reused, got %d generations", calls.Load())
+	}
+
+	post(path + "?refresh=true")
+
+	if calls.Load() != 2 {
+		t.Fatalf("expected ?refresh=true to write a new brief, got %d generations", calls.Load())
+	}
+
+	if w = post("/topics/missing/brief"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown topic, got %d", w.Code)
+	}
+}
+
+// summaryEngine answers every Complete call with a fixed title and summary.
+type summaryEngine struct{ mockEngine }
+
+func (summaryEngine) Complete(ctx context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	return `{"title": "Does pineapple rescue bland pizza?", "summary": "The user defends pineapple; the bot calls it a gimmick."}`, nil
+}
+
+func TestChatSummaries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	store := storage.NewMemoryStore()
+	summarizer := summary.New(summaryEngine{}, 2)
+	RegisterRoutes(r, store, summaryEngine{}, WithStanceClassifier(bot.KeywordClassifier{}), WithSummarizer(summarizer))
+
+	_, resp := postChat(t, r, `{"message":"Pineapple belongs on pizza"}`)
+	summarizer.Wait()
+
+	conv, _ := store.GetConversation(context.Background(), resp.ConversationID)
+	if conv.Summary != nil {
+		t.Fatalf("expected no summary after one turn, got %+v", conv.Summary)
+	}
+
+	postChat(t, r, `{"conversation_id":"`+resp.ConversationID+`","message":"It balances the salt"}`)
+	summarizer.Wait()
+
+	conv, _ = store.GetConversation(context.Background(), resp.ConversationID)
+	if conv.Title != "Does pineapple rescue bland pizza?" || conv.Summary == nil || conv.Summary.UpTo != conv.Messages[len(conv.Messages)-1].ID {
+		t.Fatalf("expected the second turn to be summarized, got %q %+v", conv.Title, conv.Summary)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations", nil))
+
+	if !strings.Contains(w.Body.String(), `"title":"Does pineapple rescue bland pizza?","summary":"The user defends pineapple`) {
+		t.Fatalf("expected the generated title and summary in the list, got %s", w.Body.String())
+	}
+}
+
+func TestTopicCatalog(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := send("POST", "/topics", `{"name": "Four-Day Work Week", "description": "Should companies adopt it?", "category": "Business"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var topic models.Topic
+	_ = json.Unmarshal(w.Body.Bytes(), &topic)
+
+	if topic.ID == "" || topic.Category != "business" || !topic.Active {
+		t.Fatalf("unexpected topic %+v", topic)
+	}
+
+	if w = send("POST", "/topics", `{"name": "four-day work week"}`); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a duplicate name, got %d", w.Code)
+	}
+
+	if w = send("POST", "/topics", `{"name": "Violence is good"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a topic the policy rejects, got %d", w.Code)
+	}
+
+	if w = send("GET", "/topics?category=business", ""); !strings.Contains(w.Body.String(), "Four-Day Work Week") ||
+		!strings.Contains(w.Body.String(), "Remote Work vs Office Work") {
+		t.Fatalf("expected both business topics, got %s", w.Body.String())
+	}
+
+	if w = send("GET", "/topics/categories", ""); !strings.Contains(w.Body.String(), `{"name":"business","topics":2}`) {
+		t.Fatalf("unexpected categories %s", w.Body.String())
+	}
+
+	// a debate can start on a catalog topic, or on a random one of a category
+	code, resp := postChat(t, r, `{"message":"I like it", "topic_id":"`+topic.ID+`", "user_stance":"PRO"}`)
+	if code != http.StatusOK || resp.Topic != "Four-Day Work Week" {
+		t.Fatalf("expected a debate on the catalog topic, got %d %+v", code, resp)
+	}
+
+	code, resp = postChat(t, r, `{"message":"Hello", "category":"finance"}`)
+	if code != http.StatusOK || resp.Topic != "Cryptocurrency Future" {
+		t.Fatalf("expected the finance topic, got %d %+v", code, resp)
+	}
+
+	if code, _ = postChat(t, r, `{"message":"Hello", "category":"cooking"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a category without topics, got %d", code)
+	}
+
+	w = send("GET", "/conversations/"+resp.ConversationID, "")
+	if !strings.Contains(w.Body.String(), `"topic_id":"01HZ0000000000000000000006"`) {
+		t.Fatalf("expected the conversation to link the topic, got %s", w.Body.String())
+	}
+
+	if w = send("PUT", "/topics/"+topic.ID, `{"name": "Four-Day Work Week", "active": false}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if code, _ = postChat(t, r, `{"message":"Hello", "topic_id":"`+topic.ID+`"}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an inactive topic, got %d", code)
+	}
+
+	if w = send("DELETE", "/topics/"+topic.ID, ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", w.Code)
+	}
+
+	if w = send("GET", "/topics/"+topic.ID, ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after deletion, got %d", w.Code)
+	}
+}
+
+func TestTopicMatching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	code, resp := postChat(t, r, `{"message":"Governments must act", "topic":"Should AI be regulated?", "user_stance":"PRO"}`)
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	if s := resp.SuggestedTopic; s == nil || s.TopicID != "01HZ0000000000000000000001" || s.Name != "Artificial Intelligence Regulation" {
+		t.Fatalf("expected the catalog topic suggested, got %+v", s)
+	}
+
+	if resp.Topic != "Should AI be regulated?" {
+		t.Fatalf("expected the debate to keep the user's wording, got %q", resp.Topic)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/conversations/"+resp.ConversationID, nil))
+
+	if !strings.Contains(w.Body.String(), `"topic_id":"01HZ0000000000000000000001"`) {
+		t.Fatalf("expected the conversation to link the catalog topic, got %s", w.Body.String())
+	}
+
+	if _, resp = postChat(t, r, `{"message":"Hello", "topic":"Pineapple on pizza", "user_stance":"PRO"}`); resp.SuggestedTopic != nil {
+		t.Fatalf("expected no suggestion, got %+v", resp.SuggestedTopic)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/topics/match?q=ai+regulation", nil))
+
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"normalized":"ai regulat"`) ||
+		!strings.Contains(w.Body.String(), `"topic_id":"01HZ0000000000000000000001"`) {
+		t.Fatalf("unexpected match %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/topics/match?q=pizza", nil))
+
+	if !strings.Contains(w.Body.String(), `"match":null`) {
+		t.Fatalf("expected no match, got %s", w.Body.String())
+	}
+}
+
+func TestPopularTopics(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r, storage.NewMemoryStore(), mockEngine{}, WithStanceClassifier(bot.KeywordClassifier{}))
+
+	for _, topic := range []string{"Should AI be regulated?", "AI regulation", "Pineapple on pizza"} {
+		if code, _ := postChat(t, r, `{"message":"Hello", "topic":"`+topic+`", "user_stance":"PRO"}`); code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+
+	get := func(path string) (int, PopularTopicsResponse) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+
+		var resp PopularTopicsResponse
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+
+		return w.Code, resp
+	}
+
+	code, resp := get("/conversations/topics?window=7d")
+	if code != http.StatusOK || resp.Window != "7d" || len(resp.Topics) != 2 {
+		t.Fatalf("expected two topics, got %d %+v", code, resp)
+	}
+
+	if top := resp.Topics[0]; top.Name != "Artificial Intelligence Regulation" || top.Count != 2 || top.Previous != nil {
+		t.Fatalf("expected the AI debates counted together, got %+v", top)
+	}
+
+	code, resp = get("/conversations/topics?trending=true")
+	if code != http.StatusOK || resp.Window != "24h" || !resp.Trending || resp.Topics[0].Previous == nil {
+		t.Fatalf("expected a trending list over 24h, got %d %+v", code, resp)
+	}
+
+	if code, _ = get("/conversations/topics?window=1y"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown window, got %d", code)
+	}
+
+	if code, _ = get("/conversations/topics?window=all&trending=true"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a trending list of all time, got %d", code)
+	}
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/referee"
+	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/oklog/ulid/v2"
+)
+
+// maxHumanRounds caps the planned rounds of a human debate.
+const maxHumanRounds = 20
+
+// CreateDebateRequest opens a human-vs-human debate
+type CreateDebateRequest struct {
+	Topic      string   `json:"topic" binding:"required"`
+	Stance     string   `json:"stance" binding:"required"` // the creator's side: PRO | CON | RANDOM
+	Rounds     int      `json:"rounds"`                    // defaults to 3
+	Judge      bool     `json:"judge"`                     // judge the debate when the rounds are done
+	Spectators []string `json:"spectators"`                // user IDs allowed to watch, e.g. a teacher
+}
+
+// RegisterHumanDebateRoutes registers the human-vs-human debate routes
+func RegisterHumanDebateRoutes(r *gin.Engine, store storage.Store, cfg routeConfig) {
+	r.POST("/debates", createHumanDebate(store, cfg))
+	r.POST("/debates/:id/join", joinHumanDebate(store, cfg))
+}
+
+// createHumanDebate handles POST /debates
+func createHumanDebate(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := requireUser(c)
+		if userID == "" {
+			return
+		}
+
+		var req CreateDebateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		stance, err := bot.ParseUserStance(req.Stance)
+		stance = bot.ResolveUserStance(stance)
+
+		if req.Rounds == 0 {
+			req.Rounds = 3
+		}
+
+		if err == nil && (req.Rounds < 1 || req.Rounds > maxHumanRounds) {
+			err = fmt.Errorf("rounds must be between 1 and %d", maxHumanRounds)
+		}
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		if verdict := cfg.topicPolicy.CheckTopic(req.Topic); !verdict.Allowed {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":      "topic rejected by moderation policy",
+				"moderation": verdict,
+			})
+
+			return
+		}
+
+		conv := models.NewConversation(ulid.Make().String())
+		conv.Topic = req.Topic
+		conv.Mode = models.ModeHuman
+		conv.Rounds = req.Rounds
+		conv.AutoJudge = req.Judge
+		conv.Spectators = req.Spectators
+		conv.Join(userID, stance)
+
+		if err := store.SaveConversation(c.Request.Context(), conv); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create debate: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, buildChatResponse(cfg, conv))
+	}
+}
+
+// joinHumanDebate handles POST /debates/:id/join. The second user takes the open side.
+func joinHumanDebate(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := requireUser(c)
+		if userID == "" {
+			return
+		}
+
+		conv, err := store.GetConversation(c.Request.Context(), c.Param("id"))
+		if err != nil || conv.Mode != models.ModeHuman {
+			c.JSON(http.StatusNotFound, gin.H{"error": "debate not found"})
+			return
+		}
+
+		if _, ok := conv.Participant(userID); ok {
+			c.JSON(http.StatusOK, buildChatResponse(cfg, conv))
+			return
+		}
+
+		if len(conv.Participants) >= 2 {
+			c.JSON(http.StatusConflict, gin.H{"error": "the debate already has two participants"})
+			return
+		}
+
+		conv.Join(userID, models.OppositeStance(conv.Participants[0].Stance))
+
+		if err := store.SaveConversation(c.Request.Context(), conv); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join debate: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, buildChatResponse(cfg, conv))
+	}
+}
+
+// humanTurn posts a debater's message to a human debate. Only the debater whose turn it is may
+// speak; after each round the bot reviews it as moderator.
+func humanTurn(ctx context.Context, store storage.Store, cfg routeConfig, conv *models.Conversation, userID, message string) (int, any) {
+	if userID == "" {
+		return http.StatusUnauthorized, missingUser()
+	}
+
+	participant, ok := conv.Participant(userID)
+	if !ok {
+		return http.StatusForbidden, gin.H{"error": "you are not a participant in this debate"}
+	}
+
+	var turnErr string
+
+	switch next := conv.NextSpeaker(); {
+	case len(conv.Participants) < 2:
+		turnErr = "waiting for an opponent to join"
+	case conv.HumanDebateOver():
+		turnErr = "the debate is over"
+	case participant.Stance != next:
+		turnErr = "not your turn: waiting for " + next
+	}
+
+	if turnErr != "" {
+		return http.StatusConflict, gin.H{"error": turnErr, "conversation_id": conv.ID, "next_speaker": conv.NextSpeaker()}
+	}
+
+	msg := models.Message{Role: models.RoleForStance(participant.Stance), Author: userID, Message: message}
+	if verdict := moderate(ctx, cfg.moderator, message); !verdict.Allowed {
+		msg.Moderation = &verdict
+		conv.Append(msg)
+
+		return respondWithPolicy(ctx, store, cfg, conv, verdict)
+	}
+
+	conv.Append(msg)
+
+	if participant.Stance == models.StanceCon {
+		finishRound(ctx, cfg, conv)
+	}
+
+	// persist (best effort)
+	_ = store.SaveConversation(ctx, conv)
+
+	return http.StatusOK, buildChatResponse(cfg, conv)
+}
+
+// finishRound adds the moderator's review and, after the last round, closes (and optionally
+// judges) the debate. Review and judging failures don't block the debaters.
+func finishRound(ctx context.Context, cfg routeConfig, conv *models.Conversation) {
+	round := conv.CompletedRounds()
+
+	report, err := cfg.referee.Review(ctx, conv, round)
+	if err != nil {
+		log.Printf("round review failed for %s: %v", conv.ID, err)
+	}
+
+	conv.Append(referee.Message(round, report))
+
+	if !conv.HumanDebateOver() {
+		return
+	}
+
+	conv.Append(models.Message{Role: "system", Event: models.EventDebateOver, Message: "All rounds are complete. The debate is over."})
+
+	if conv.AutoJudge {
+		judgeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 60*time.Second)
+		defer cancel()
+
+		judgement, err := cfg.judge.Judge(judgeCtx, conv)
+		if err != nil {
+			log.Printf("judging failed for %s: %v", conv.ID, err)
+			return
+		}
+
+		conv.Judgement = judgement
+	}
+}
+
+// requireUser returns the calling user's ID, or responds 401 and returns "".
+func requireUser(c *gin.Context) string {
+	userID := auth.UserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, missingUser())
+	}
+
+	return userID
+}
+
+func missingUser() gin.H {
+	return gin.H{"error": auth.UserIDHeader + " header required", "code": "MISSING_USER_ID"}
+}
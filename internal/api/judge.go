@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/judge"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// RegisterJudgeRoutes registers the judging routes
+func RegisterJudgeRoutes(r *gin.Engine, store storage.Store, cfg routeConfig) {
+	r.POST("/conversations/:id/judge", judgeConversation(store, cfg))
+	r.GET("/judge/rubric", getRubric(cfg.judge))
+}
+
+// judgeConversation handles POST /conversations/:id/judge. Judging ends the debate; judging
+// it again replaces the verdict. Only those who may read a human debate may judge it, since
+// the verdict summarizes it. Like every access check here, this trusts the X-User-ID header
+// (see auth.UserID): it keeps a client's users apart, not clients that share the API key.
+func judgeConversation(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+		defer cancel()
+
+		conversation, err := store.GetConversation(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "conversation not found"})
+			return
+		}
+
+		if !conversation.CanRead(auth.UserID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "you may not judge this debate"})
+			return
+		}
+
+		mark := markUpdates(cfg, conversation)
+
+		judgement, err := cfg.judge.Judge(ctx, conversation)
+		if err != nil {
+			if errors.Is(err, judge.ErrNothingToJudge) {
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+
+			c.JSON(http.StatusBadGateway, gin.H{"error": "judge error: " + err.Error()})
+
+			return
+		}
+
+		conversation.Judgement = judgement
+
+		// only the verdict is written: turns saved while the judge was thinking must survive
+		if err := store.SaveJudgement(ctx, conversation.ID, judgement); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save judgement: " + err.Error()})
+			return
+		}
+
+		publishUpdates(ctx, cfg, conversation, mark)
+
+		c.JSON(http.StatusOK, gin.H{"conversation_id": conversation.ID, "judgement": judgement})
+	}
+}
+
+// getRubric handles GET /judge/rubric
+func getRubric(j *judge.Judge) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, j.Rubric())
+	}
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"github.com/nikoremi97/debate/internal/argmap"
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/brief"
+	"github.com/nikoremi97/debate/internal/coach"
+	"github.com/nikoremi97/debate/internal/events"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/experiments"
+	"github.com/nikoremi97/debate/internal/formats"
+	"github.com/nikoremi97/debate/internal/judge"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/personas"
+	"github.com/nikoremi97/debate/internal/prompts"
+	"github.com/nikoremi97/debate/internal/referee"
+	"github.com/nikoremi97/debate/internal/summary"
+	"github.com/nikoremi97/debate/internal/topics"
+)
+
+// routeConfig holds the optional collaborators of the API handlers.
+type routeConfig struct {
+	classifier  bot.StanceClassifier
+	topicPolicy *moderation.Policy
+	matcher     *topics.Matcher
+	moderator   moderation.Moderator
+	experiments *experiments.Config
+	personas    *personas.Catalog
+	formats     *formats.Catalog
+	judge       *judge.Judge
+	argmap      *argmap.Analyzer
+	coach       coach.Classifier
+	prompts     *prompts.Registry
+	briefs      *brief.Generator
+	summarizer  *summary.Summarizer
+	referee     *referee.Referee
+	events      events.Broker
+	evidence    evidence.Retriever
+	toolCalling bool
+}
+
+// RouteOption customizes RegisterRoutes.
+type RouteOption func(*routeConfig)
+
+// WithStanceClassifier replaces the default LLM stance classifier.
+func WithStanceClassifier(c bot.StanceClassifier) RouteOption {
+	return func(cfg *routeConfig) { cfg.classifier = c }
+}
+
+// WithTopicPolicy replaces the embedded moderation policy used to vet user topics.
+func WithTopicPolicy(p *moderation.Policy) RouteOption {
+	return func(cfg *routeConfig) { cfg.topicPolicy = p }
+}
+
+// WithTopicMatcher replaces the default matcher that maps free-text topics to the catalog.
+func WithTopicMatcher(m *topics.Matcher) RouteOption {
+	return func(cfg *routeConfig) { cfg.matcher = m }
+}
+
+// WithModerator sets the moderator applied to user messages and bot replies.
+func WithModerator(m moderation.Moderator) RouteOption {
+	return func(cfg *routeConfig) { cfg.moderator = m }
+}
+
+// WithExperiments enables prompt A/B experiments.
+func WithExperiments(c *experiments.Config) RouteOption {
+	return func(cfg *routeConfig) { cfg.experiments = c }
+}
+
+// WithPersonas replaces the embedded persona and difficulty catalog.
+func WithPersonas(c *personas.Catalog) RouteOption {
+	return func(cfg *routeConfig) { cfg.personas = c }
+}
+
+// WithFormats replaces the embedded debate formats.
+func WithFormats(c *formats.Catalog) RouteOption {
+	return func(cfg *routeConfig) { cfg.formats = c }
+}
+
+// WithJudge replaces the default judge, which uses the chat engine and the embedded rubric.
+func WithJudge(j *judge.Judge) RouteOption {
+	return func(cfg *routeConfig) { cfg.judge = j }
+}
+
+// WithArgumentMapper replaces the default argument map analyzer, which uses the chat engine.
+func WithArgumentMapper(a *argmap.Analyzer) RouteOption {
+	return func(cfg *routeConfig) { cfg.argmap = a }
+}
+
+// WithCoach replaces the default fallacy classifier of coach mode, which uses the chat engine.
+func WithCoach(c coach.Classifier) RouteOption {
+	return func(cfg *routeConfig) { cfg.coach = c }
+}
+
+// WithPrompts sets the prompt templates of the prompts the API renders itself, such as topic
+// briefs. The chat engine has its own registry.
+func WithPrompts(r *prompts.Registry) RouteOption {
+	return func(cfg *routeConfig) { cfg.prompts = r }
+}
+
+// WithBriefs replaces the default topic brief generator, which uses the chat engine, the
+// prompt templates and the evidence corpus.
+func WithBriefs(g *brief.Generator) RouteOption {
+	return func(cfg *routeConfig) { cfg.briefs = g }
+}
+
+// WithSummarizer replaces the default summarizer, which uses the chat engine and refreshes
+// titles and summaries every summary.DefaultEvery turns.
+func WithSummarizer(s *summary.Summarizer) RouteOption {
+	return func(cfg *routeConfig) { cfg.summarizer = s }
+}
+
+// WithReferee replaces the default moderator of human-vs-human debates.
+func WithReferee(r *referee.Referee) RouteOption {
+	return func(cfg *routeConfig) { cfg.referee = r }
+}
+
+// WithEvents replaces the in-process broker behind live spectator streams, e.g. with Redis
+// pub/sub when several replicas run.
+func WithEvents(b events.Broker) RouteOption {
+	return func(cfg *routeConfig) { cfg.events = b }
+}
+
+// WithEvidence grounds bot replies in passages retrieved from a document corpus, which the
+// replies cite. Without it the bot gets no evidence.
+func WithEvidence(r evidence.Retriever) RouteOption {
+	return func(cfg *routeConfig) { cfg.evidence = r }
+}
+
+// WithToolCalling lets the bot call tools mid-reply: a calculator, the topic description and,
+// with WithEvidence, a search of the evidence corpus. The calls are kept on the reply.
+func WithToolCalling() RouteOption {
+	return func(cfg *routeConfig) { cfg.toolCalling = true }
+}
+
+func newRouteConfig(engine bot.Engine, opts []RouteOption) routeConfig {
+	cfg := routeConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.classifier == nil {
+		cfg.classifier = bot.NewLLMClassifier(engine, bot.KeywordClassifier{})
+	}
+
+	if cfg.matcher == nil {
+		cfg.matcher = topics.NewMatcher(topics.DefaultThreshold)
+	}
+
+	if cfg.personas == nil {
+		cfg.personas = personas.Default()
+	}
+
+	if cfg.formats == nil {
+		cfg.formats = formats.Default()
+	}
+
+	if cfg.judge == nil {
+		cfg.judge = judge.New(engine, nil)
+	}
+
+	if cfg.argmap == nil {
+		cfg.argmap = argmap.New(engine)
+	}
+
+	if cfg.coach == nil {
+		cfg.coach = coach.NewLLMClassifier(engine)
+	}
+
+	if cfg.prompts == nil {
+		cfg.prompts = prompts.Default()
+	}
+
+	if cfg.briefs == nil {
+		cfg.briefs = brief.New(engine, cfg.prompts, cfg.evidence)
+	}
+
+	if cfg.summarizer == nil {
+		cfg.summarizer = summary.New(engine, summary.DefaultEvery)
+	}
+
+	if cfg.referee == nil {
+		cfg.referee = referee.New(engine)
+	}
+
+	if cfg.events == nil {
+		cfg.events = events.NewMemoryBroker()
+	}
+
+	if cfg.topicPolicy == nil {
+		cfg.topicPolicy = moderation.Default()
+	}
+
+	if cfg.moderator == nil {
+		// messages only get blocked for the most severe categories by default
+		cfg.moderator = moderation.NewPolicyModerator(cfg.topicPolicy, moderation.SeverityCritical)
+	}
+
+	return cfg
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/personas"
+)
+
+// RegisterPersonaRoutes registers the persona catalog routes
+func RegisterPersonaRoutes(r *gin.Engine, catalog *personas.Catalog) {
+	r.GET("/personas", listPersonas(catalog))
+}
+
+// listPersonas handles GET /personas
+func listPersonas(catalog *personas.Catalog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, catalog)
+	}
+}
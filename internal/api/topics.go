@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+	"github.com/nikoremi97/debate/internal/topics"
+)
+
+// TopicRequest creates or replaces a catalog topic
+type TopicRequest struct {
+	Name        string `json:"name" binding:"required,max=255"`
+	Description string `json:"description"`
+	Category    string `json:"category" binding:"max=100"`
+	Active      *bool  `json:"active"` // defaults to true
+}
+
+// RegisterTopicRoutes registers the topic catalog routes
+func RegisterTopicRoutes(r *gin.Engine, store storage.Store, cfg routeConfig) {
+	topics := r.Group("/topics")
+	{
+		topics.GET("", listTopics(store))
+		topics.GET("/categories", listTopicCategories(store))
+		topics.GET("/match", matchTopic(store, cfg))
+		topics.GET("/:id", getTopic(store))
+		topics.POST("", saveTopic(store, cfg))
+		topics.PUT("/:id", saveTopic(store, cfg))
+		topics.DELETE("/:id", deleteTopic(store))
+		topics.POST("/:id/brief", topicBrief(store, cfg))
+	}
+}
+
+// listTopics handles GET /topics. ?category= narrows the list and ?include_inactive=true adds
+// the topics that are no longer offered.
+func listTopics(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := storage.TopicFilter{Category: c.Query("category"), IncludeInactive: c.Query("include_inactive") == "true"}
+
+		topics, err := store.ListTopics(c.Request.Context(), filter)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list topics: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"topics": topics})
+	}
+}
+
+// listTopicCategories handles GET /topics/categories
+func listTopicCategories(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		categories, err := store.TopicCategories(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list topic categories: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"categories": categories})
+	}
+}
+
+// matchTopic handles GET /topics/match?q=, returning the catalog topic a free-text topic would
+// be counted under, or a null match when none is close enough.
+func matchTopic(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		q := strings.TrimSpace(c.Query("q"))
+		if q == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: q is required"})
+			return
+		}
+
+		catalog, err := store.ListTopics(c.Request.Context(), storage.TopicFilter{})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list topics: " + err.Error()})
+			return
+		}
+
+		resp := gin.H{"query": q, "normalized": topics.Normalize(q), "match": nil}
+		if match, ok := cfg.matcher.Match(catalog, q); ok {
+			resp["match"] = match
+		}
+
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// getTopic handles GET /topics/:id
+func getTopic(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topic, err := store.GetTopic(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+
+		c.JSON(http.StatusOK, topic)
+	}
+}
+
+// saveTopic handles POST /topics and PUT /topics/:id. Topic names go through the same
+// moderation policy as the topics users bring to a debate.
+func saveTopic(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TopicRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: " + err.Error()})
+			return
+		}
+
+		topic := &models.Topic{
+			ID:          c.Param("id"),
+			Name:        strings.TrimSpace(req.Name),
+			Description: strings.TrimSpace(req.Description),
+			Category:    strings.ToLower(strings.TrimSpace(req.Category)),
+			Active:      req.Active == nil || *req.Active,
+		}
+
+		if topic.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request: name must not be blank"})
+			return
+		}
+
+		if verdict := cfg.topicPolicy.CheckTopic(topic.Name); !verdict.Allowed {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "topic rejected by moderation policy", "moderation": verdict})
+			return
+		}
+
+		status, save := http.StatusOK, store.UpdateTopic
+		if topic.ID == "" {
+			status, save = http.StatusCreated, store.CreateTopic
+		}
+
+		if err := save(c.Request.Context(), topic); err != nil {
+			c.JSON(topicErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(status, topic)
+	}
+}
+
+// deleteTopic handles DELETE /topics/:id. Debates on the topic keep its name; deactivate the
+// topic instead to keep the link.
+func deleteTopic(store storage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := store.DeleteTopic(c.Request.Context(), c.Param("id")); err != nil {
+			c.JSON(topicErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func topicErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, storage.ErrTopicNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, storage.ErrTopicExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// topicBrief handles POST /topics/:id/brief. Briefs are cached per topic and prompt version,
+// so editing the brief template writes new ones; ?refresh=true writes a new one anyway. Like
+// transcripts, briefs are served as JSON.
+func topicBrief(store storage.Store, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+		defer cancel()
+
+		topic, err := store.GetTopic(ctx, c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "topic not found"})
+			return
+		}
+
+		b, err := store.GetBrief(ctx, topic.ID, cfg.briefs.Version())
+		if err != nil || c.Query("refresh") == "true" {
+			b, err = cfg.briefs.Generate(ctx, topic)
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "brief error: " + err.Error()})
+				return
+			}
+
+			if err := store.SaveBrief(ctx, b); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save brief: " + err.Error()})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, b)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+
+	"github.com/nikoremi97/debate/internal/auth"
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// WebSocket frame types.
+const (
+	frameMessage = "message" // client: a chat turn, with the fields of POST /chat
+	framePing    = "ping"    // client: keep the connection alive
+	frameDelta   = "delta"   // server: a chunk of the bot's reply, sent once the turn has passed its checks
+	frameReply   = "reply"   // server: the turn is done; carries the POST /chat response
+	frameError   = "error"   // server: the turn or frame was rejected; carries the error body
+	framePong    = "pong"    // server: answers a ping
+)
+
+const (
+	wsIdleTimeout  = 2 * time.Minute  // close connections that send nothing for this long
+	wsWriteTimeout = 10 * time.Second // drop clients that stop reading
+	wsSendBuffer   = 64               // frames queued for a slow client before the turn waits for it
+	wsMaxFrameSize = 64 << 10
+)
+
+// WSRequest is a frame sent by the client over /ws.
+type WSRequest struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"` // chosen by the client and echoed on the frames answering it
+	models.ChatRequest
+}
+
+// WSResponse is a frame sent by the server over /ws.
+type WSResponse struct {
+	Type   string `json:"type"`
+	ID     string `json:"id,omitempty"`
+	Delta  string `json:"delta,omitempty"`
+	Status int    `json:"status,omitempty"` // the status POST /chat would have answered with
+	Body   any    `json:"body,omitempty"`   // the POST /chat response or error body
+}
+
+// handleWebSocket serves /ws: one persistent connection per debate that plays the same turns
+// as POST /chat and sends the bot's reply in chunks. The handshake is an ordinary request, so the
+// X-API-Key middleware authenticates it; X-User-ID names the user for human debates.
+func handleWebSocket(store storage.Store, engine bot.Engine, cfg routeConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := auth.UserID(c)
+
+		server := websocket.Server{
+			// clients authenticate with the API key rather than cookies, so any origin may connect
+			Handshake: func(*websocket.Config, *http.Request) error { return nil },
+			Handler: func(conn *websocket.Conn) {
+				conn.MaxPayloadBytes = wsMaxFrameSize
+
+				session := &wsSession{conn: conn, store: store, engine: engine, cfg: cfg, userID: userID, out: make(chan WSResponse, wsSendBuffer)}
+				session.serve(c.Request.Context())
+			},
+		}
+
+		server.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// wsSession is one client connection. A single goroutine writes to the connection; turns run
+// one at a time next to the read loop so pings are still answered while the bot thinks.
+type wsSession struct {
+	conn   *websocket.Conn
+	store  storage.Store
+	engine bot.Engine
+	cfg    routeConfig
+	userID string
+	out    chan WSResponse
+	busy   atomic.Bool
+}
+
+func (s *wsSession) serve(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	var wg sync.WaitGroup
+
+	defer wg.Wait()
+	defer cancel()
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+		s.writeLoop(ctx, cancel)
+	}()
+
+	for ctx.Err() == nil {
+		_ = s.conn.SetReadDeadline(time.Now().Add(wsIdleTimeout))
+
+		var req WSRequest
+
+		if err := websocket.JSON.Receive(s.conn, &req); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+
+			switch {
+			case errors.Is(err, websocket.ErrFrameTooLarge):
+				s.send(ctx, errorFrame("", http.StatusRequestEntityTooLarge, "frame too large"))
+			case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+				s.send(ctx, errorFrame("", http.StatusBadRequest, "invalid frame: "+err.Error()))
+			default: // closed by the client, idle for too long or broken
+				return
+			}
+
+			continue
+		}
+
+		switch req.Type {
+		case framePing:
+			s.send(ctx, WSResponse{Type: framePong, ID: req.ID})
+		case frameMessage:
+			if !s.busy.CompareAndSwap(false, true) {
+				s.send(ctx, errorFrame(req.ID, http.StatusTooManyRequests, "a turn is already in progress on this connection"))
+				continue
+			}
+
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				defer s.busy.Store(false)
+
+				s.turn(ctx, req)
+			}()
+		default:
+			s.send(ctx, errorFrame(req.ID, http.StatusBadRequest, "unknown frame type "+req.Type))
+		}
+	}
+}
+
+// turn plays a chat turn, sending the reply as delta frames before the final reply frame.
+// The deltas are only sent once the whole turn is done: the stance guard and moderation judge
+// the complete reply, so nothing is streamed while it is generated and text the bot withholds
+// never reaches the client. The reply frame then carries the verdict instead.
+func (s *wsSession) turn(ctx context.Context, req WSRequest) {
+	turnCtx, cancel := context.WithTimeout(ctx, 25*time.Second)
+	defer cancel()
+
+	var deltas []string
+
+	stream := bot.WithStream(func(delta string) {
+		deltas = append(deltas, delta)
+	})
+
+	status, body := chatTurn(turnCtx, s.store, s.engine, s.cfg, req.ChatRequest, s.userID, stream)
+
+	if resp, ok := body.(models.ChatResponse); ok && status == http.StatusOK && resp.Moderation == nil {
+		for _, delta := range deltas {
+			s.send(turnCtx, WSResponse{Type: frameDelta, ID: req.ID, Delta: delta})
+		}
+	}
+
+	frame := WSResponse{Type: frameReply, ID: req.ID, Status: status, Body: body}
+	if status >= http.StatusBadRequest {
+		frame.Type = frameError
+	}
+
+	s.send(ctx, frame)
+}
+
+// send queues a frame. A full queue blocks the caller, so frames for a slow client don't pile
+// up in memory, and a client that stops reading hits the write timeout.
+func (s *wsSession) send(ctx context.Context, frame WSResponse) {
+	select {
+	case s.out <- frame:
+	case <-ctx.Done():
+	}
+}
+
+func (s *wsSession) writeLoop(ctx context.Context, cancel context.CancelFunc) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case frame := <-s.out:
+			_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+
+			if err := websocket.JSON.Send(s.conn, frame); err != nil {
+				cancel()
+				return
+			}
+		}
+	}
+}
+
+func errorFrame(id string, status int, msg string) WSResponse {
+	return WSResponse{Type: frameError, ID: id, Status: status, Body: gin.H{"error": msg}}
+}
@@ -0,0 +1,167 @@
+// Package argmap extracts the claims of a debate and the support and attack relations
+// between them, and renders the result as a graph.
+package argmap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// ErrNothingToMap is returned for conversations without a single turn.
+var ErrNothingToMap = errors.New("the debate has no turns to map yet")
+
+// Analyzer builds argument maps with a structured-output prompt.
+type Analyzer struct {
+	engine bot.Engine
+	opts   []bot.Option
+}
+
+// New returns an analyzer. opts tune the extraction call, e.g. a stronger model.
+func New(engine bot.Engine, opts ...bot.Option) *Analyzer {
+	return &Analyzer{engine: engine, opts: opts}
+}
+
+// Stale reports whether the conversation has changed since its argument map was built.
+func Stale(conv *models.Conversation) bool {
+	if conv.ArgumentMap == nil {
+		return true
+	}
+
+	_, last := transcript(conv)
+
+	return len(last) == 0 || conv.ArgumentMap.UpTo != last[len(last)-1].ID
+}
+
+// Analyze extracts the claims of every turn and links them.
+func (a *Analyzer) Analyze(ctx context.Context, conv *models.Conversation) (*models.ArgumentMap, error) {
+	text, turns := transcript(conv)
+	if len(turns) == 0 {
+		return nil, ErrNothingToMap
+	}
+
+	var info bot.ReplyInfo
+
+	opts := append([]bot.Option{bot.WithJSON(), bot.WithTemperature(0), bot.WithMaxTokens(2000)}, a.opts...)
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	out, err := a.engine.Complete(ctx, []map[string]string{
+		{"role": "system", "content": systemPrompt(conv)},
+		{"role": "user", "content": text},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("argument map: %w", err)
+	}
+
+	m, err := parse(out, turns)
+	if err != nil {
+		return nil, err
+	}
+
+	m.UpTo = turns[len(turns)-1].ID
+	m.Model = info.Model
+	m.MappedAt = time.Now().UnixMilli()
+
+	return m, nil
+}
+
+func systemPrompt(conv *models.Conversation) string {
+	return "You map the arguments of a debate. Topic: " + conv.Topic + ".\n" +
+		"The transcript lists numbered turns; system notes are context only.\n\n" +
+		"Extract the distinct claims each turn makes, in a short sentence each, and skip filler " +
+		"and repetition. Then link the claims: a claim supports another when it gives a reason " +
+		"for it, and attacks another when it disputes or rebuts it. Only link claims that engage " +
+		"each other.\n" +
+		`Reply with a JSON object only:
+{"claims": [{"id": string, "turn": number, "text": string}],
+ "relations": [{"from": claim id, "to": claim id, "type": "support" | "attack"}]}`
+}
+
+// transcript numbers the turns the analyzer sees, leaving out flagged turns, moderation
+// notices and the moderator's reviews like the judge does. It returns the numbered turns.
+func transcript(conv *models.Conversation) (string, []models.Message) {
+	var (
+		b     strings.Builder
+		turns []models.Message
+	)
+
+	for _, m := range conv.Messages {
+		if m.Flagged() || m.Event == models.EventModeration || m.Role == models.RoleModerator {
+			continue
+		}
+
+		if m.Role == "system" {
+			b.WriteString("(system note: " + m.Message + ")\n")
+			continue
+		}
+
+		turns = append(turns, m)
+		b.WriteString("[" + strconv.Itoa(len(turns)) + "] " + strings.ToUpper(m.Role) + ": " + m.Message + "\n")
+	}
+
+	return b.String(), turns
+}
+
+// parse validates the model's JSON. Claims about turns that don't exist are dropped, along
+// with relations that don't join two kept claims; the kept claims are renumbered c1, c2, ...
+func parse(out string, turns []models.Message) (*models.ArgumentMap, error) {
+	var raw struct {
+		Claims []struct {
+			ID   string `json:"id"`
+			Turn int    `json:"turn"`
+			Text string `json:"text"`
+		} `json:"claims"`
+		Relations []models.Relation `json:"relations"`
+	}
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &raw); err != nil {
+		return nil, fmt.Errorf("invalid argument map: %w", err)
+	}
+
+	m := &models.ArgumentMap{Claims: []models.Claim{}, Relations: []models.Relation{}}
+	ids := map[string]string{}
+
+	for _, c := range raw.Claims {
+		text := strings.TrimSpace(c.Text)
+		if c.Turn < 1 || c.Turn > len(turns) || text == "" || c.ID == "" || ids[c.ID] != "" {
+			continue
+		}
+
+		turn := turns[c.Turn-1]
+		id := "c" + strconv.Itoa(len(m.Claims)+1)
+		ids[c.ID] = id
+
+		m.Claims = append(m.Claims, models.Claim{ID: id, MessageID: turn.ID, Turn: c.Turn, Side: turn.Role, Text: text})
+	}
+
+	if len(m.Claims) == 0 {
+		return nil, errors.New("invalid argument map: no claims were extracted")
+	}
+
+	seen := map[models.Relation]bool{}
+
+	for _, r := range raw.Relations {
+		r.From, r.To = ids[r.From], ids[r.To]
+		r.Type = strings.ToLower(strings.TrimSpace(r.Type))
+
+		if r.From == "" || r.To == "" || r.From == r.To || seen[r] {
+			continue
+		}
+
+		if r.Type != models.RelationSupport && r.Type != models.RelationAttack {
+			continue
+		}
+
+		seen[r] = true
+		m.Relations = append(m.Relations, r)
+	}
+
+	return m, nil
+}
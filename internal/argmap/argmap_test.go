@@ -0,0 +1,143 @@
+package argmap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+)
+
+// cannedEngine returns out from Complete and records the messages it was sent.
+type cannedEngine struct {
+	out  string
+	sent *[]map[string]string
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, _ ...bot.Option) (string, error) {
+	if e.sent != nil {
+		*e.sent = messages
+	}
+
+	return e.out, nil
+}
+
+func debate() *models.Conversation {
+	conv := models.NewConversation("c1")
+	conv.Topic = "Tabs are better than spaces"
+	conv.SetSides(models.StancePro)
+	conv.Append(models.Message{Role: "user", Message: "Spaces render the same everywhere."})
+	conv.Append(models.Message{Role: "bot", Message: "Tabs let every reader pick their width."})
+	conv.Append(models.Message{Role: "user", Message: "secret insult", Moderation: &moderation.Verdict{Allowed: false}})
+	conv.Append(models.Message{Role: "user", Message: "Width settings differ between tools."})
+
+	return conv
+}
+
+func TestAnalyze(t *testing.T) {
+	var sent []map[string]string
+
+	out := "```json\n" + `{"claims": [
+	   {"id": "a", "turn": 1, "text": "Spaces render the same everywhere."},
+	   {"id": "b", "turn": 2, "text": " Readers can pick the tab width. "},
+	   {"id": "x", "turn": 7, "text": "No such turn."},
+	   {"id": "b", "turn": 3, "text": "Duplicate id."},
+	   {"id": "c", "turn": 3, "text": "Tools disagree on tab width."}],
+	 "relations": [
+	   {"from": "b", "to": "a", "type": "Attack"},
+	   {"from": "c", "to": "a", "type": "support"},
+	   {"from": "c", "to": "a", "type": "support"},
+	   {"from": "c", "to": "x", "type": "attack"},
+	   {"from": "c", "to": "c", "type": "attack"},
+	   {"from": "c", "to": "b", "type": "agrees"}]}` + "\n```"
+
+	conv := debate()
+
+	m, err := New(cannedEngine{out: out, sent: &sent}).Analyze(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	if len(m.Claims) != 3 {
+		t.Fatalf("expected 3 valid claims, got %+v", m.Claims)
+	}
+
+	if c := m.Claims[1]; c.ID != "c2" || c.Side != "bot" || c.MessageID != conv.Messages[1].ID || c.Text != "Readers can pick the tab width." {
+		t.Fatalf("expected the bot's claim renumbered and tied to its message, got %+v", c)
+	}
+
+	if c := m.Claims[2]; c.ID != "c3" || c.Turn != 3 || c.MessageID != conv.Messages[3].ID {
+		t.Fatalf("expected turn 3 to be the message after the flagged one, got %+v", c)
+	}
+
+	want := []models.Relation{{From: "c2", To: "c1", Type: "attack"}, {From: "c3", To: "c1", Type: "support"}}
+	if len(m.Relations) != len(want) || m.Relations[0] != want[0] || m.Relations[1] != want[1] {
+		t.Fatalf("expected relations %+v, got %+v", want, m.Relations)
+	}
+
+	if m.UpTo != conv.Messages[3].ID || m.MappedAt == 0 {
+		t.Fatalf("expected the map to cover the last turn, got %+v", m)
+	}
+
+	if strings.Contains(sent[1]["content"], "secret insult") {
+		t.Fatalf("expected flagged turns to be left out of the transcript:\n%s", sent[1]["content"])
+	}
+
+	conv.ArgumentMap = m
+	if Stale(conv) {
+		t.Fatal("expected a fresh map not to be stale")
+	}
+
+	conv.Append(models.Message{Role: "system", Event: models.EventModeration, Message: "withheld"})
+	if Stale(conv) {
+		t.Fatal("expected a moderation notice not to make the map stale")
+	}
+
+	conv.Append(models.Message{Role: "bot", Message: "Editors normalize that."})
+	if !Stale(conv) {
+		t.Fatal("expected a new turn to make the map stale")
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	empty := models.NewConversation("empty")
+	if _, err := New(cannedEngine{}).Analyze(context.Background(), empty); !errors.Is(err, ErrNothingToMap) {
+		t.Fatalf("expected ErrNothingToMap, got %v", err)
+	}
+
+	for _, out := range []string{"not json", `{"claims": [{"id": "a", "turn": 9, "text": "x"}]}`} {
+		if _, err := New(cannedEngine{out: out}).Analyze(context.Background(), debate()); err == nil {
+			t.Fatalf("expected %q to be rejected", out)
+		}
+	}
+}
+
+func TestDOT(t *testing.T) {
+	m := &models.ArgumentMap{
+		Claims: []models.Claim{
+			{ID: "c1", Turn: 1, Side: "pro", Text: `Spaces are "portable"`},
+			{ID: "c2", Turn: 2, Side: "con", Text: "Tabs are configurable per reader, which matters for accessibility"},
+		},
+		Relations: []models.Relation{{From: "c2", To: "c1", Type: models.RelationAttack}},
+	}
+
+	dot := DOT(m)
+
+	for _, want := range []string{
+		"digraph argument_map {",
+		`c1 [label="PRO (turn 1)\nSpaces are \"portable\"", fillcolor=lightblue];`,
+		`Tabs are configurable per reader, which\nmatters for accessibility`,
+		"c2 -> c1 [label=attack, color=firebrick, style=dashed];",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Fatalf("expected %q in:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package argmap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// labelWidth is where claim labels wrap, in characters.
+const labelWidth = 40
+
+// sideColors fills claim nodes by side; the first side of either kind of debate is blue.
+var sideColors = map[string]string{
+	"user":         "lightblue",
+	models.RolePro: "lightblue",
+	"bot":          "lightsalmon",
+	models.RoleCon: "lightsalmon",
+}
+
+// DOT renders an argument map as a Graphviz digraph. Support edges are solid green, attack
+// edges dashed red.
+func DOT(m *models.ArgumentMap) string {
+	var b strings.Builder
+
+	b.WriteString("digraph argument_map {\n")
+	b.WriteString("  rankdir=BT;\n")
+	b.WriteString("  node [shape=box, style=\"rounded,filled\", fontname=\"Helvetica\"];\n")
+
+	for _, c := range m.Claims {
+		color, ok := sideColors[c.Side]
+		if !ok {
+			color = "white"
+		}
+
+		label := fmt.Sprintf("%s (turn %d)\n%s", strings.ToUpper(c.Side), c.Turn, wrap(c.Text, labelWidth))
+		fmt.Fprintf(&b, "  %s [label=%s, fillcolor=%s];\n", c.ID, quote(label), color)
+	}
+
+	for _, r := range m.Relations {
+		style := "color=darkgreen"
+		if r.Type == models.RelationAttack {
+			style = "color=firebrick, style=dashed"
+		}
+
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, %s];\n", r.From, r.To, r.Type, style)
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+// quote makes s a DOT string literal; newlines become line breaks in the label.
+func quote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+// wrap breaks text into lines of at most width characters where it can.
+func wrap(text string, width int) string {
+	var (
+		lines []string
+		line  string
+	)
+
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+
+		if line != "" {
+			line += " "
+		}
+
+		line += word
+	}
+
+	if line != "" {
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UserIDHeader carries the ID of the end user acting through an authenticated client. The
+// API key vouches for the client, so the client is trusted to identify its users. Nothing
+// binds the ID to a credential: any holder of the API key can act as any user, so the
+// per-user checks built on it only hold against clients that identify their users honestly.
+const UserIDHeader = "X-User-ID"
+
+// UserID returns the end user making the request, or "" when none was given.
+func UserID(c *gin.Context) string {
+	return strings.TrimSpace(c.GetHeader(UserIDHeader))
+}
@@ -0,0 +1,199 @@
+package autoplay
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/personas"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+type call struct {
+	stance      string
+	history     []bot.HistoryItem
+	userMessage string
+	opts        bot.Options
+}
+
+// scriptEngine replies with the stance and turn number and records every call.
+type scriptEngine struct {
+	mu    sync.Mutex
+	calls []call
+	block bool // wait for cancellation instead of replying
+}
+
+func (e *scriptEngine) Generate(ctx context.Context, topic, stance string, history []bot.HistoryItem, userMessage string, opts ...bot.Option) (string, error) {
+	if e.block {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.calls = append(e.calls, call{stance: stance, history: history, userMessage: userMessage, opts: bot.ApplyOptions(opts...)})
+
+	return stance + " argument", nil
+}
+
+func (e *scriptEngine) Complete(context.Context, []map[string]string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func newRunner(engine bot.Engine, store storage.Store) *Runner {
+	return NewRunner(engine, store, moderation.NewPolicyModerator(moderation.Default(), moderation.SeverityCritical), personas.Default())
+}
+
+func TestRunAlternatesSides(t *testing.T) {
+	engine := &scriptEngine{}
+	store := storage.NewMemoryStore()
+	temp := 0.3
+
+	conv := NewConversation("auto-1", "Tabs are better than spaces", &models.Autoplay{
+		Rounds: 2,
+		Pro:    models.AutoplaySide{Persona: "lawyer", Model: "model-a"},
+		Con:    models.AutoplaySide{Persona: "scientist", Temperature: &temp},
+	})
+
+	turns := 0
+	if err := newRunner(engine, store).Run(context.Background(), conv, func() { turns++ }); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if turns != 4 || len(engine.calls) != 4 {
+		t.Fatalf("expected 4 turns, got %d (%d calls)", turns, len(engine.calls))
+	}
+
+	first, second := engine.calls[0], engine.calls[1]
+
+	if first.stance != models.StancePro || first.userMessage != openingPrompt || first.opts.Model != "model-a" {
+		t.Fatalf("PRO should open with its own setup, got %+v", first)
+	}
+
+	if second.stance != models.StanceCon || second.userMessage != "PRO argument" || *second.opts.Temperature != 0.3 {
+		t.Fatalf("CON should answer PRO with its own setup, got %+v", second)
+	}
+
+	// the third call is PRO again: its own turn is the bot's, CON's latest is the message to answer
+	third := engine.calls[2]
+	if len(third.history) != 1 || third.history[0].Role != "bot" || third.userMessage != "CON argument" {
+		t.Fatalf("unexpected PRO perspective %+v", third)
+	}
+
+	stored, err := store.GetConversation(context.Background(), "auto-1")
+	if err != nil || len(stored.Messages) != 4 || stored.Messages[0].Role != "user" || stored.Stance != models.StanceCon {
+		t.Fatalf("expected the transcript to be stored as a conversation, got %+v (%v)", stored, err)
+	}
+}
+
+func TestRunStopsOnFlaggedTurn(t *testing.T) {
+	policy, err := moderation.ParsePolicy([]byte(`{"block_severity": "low", "categories": [{"name": "test", "severity": "critical", "terms": ["argument"]}]}`))
+	if err != nil {
+		t.Fatalf("policy: %v", err)
+	}
+
+	runner := NewRunner(&scriptEngine{}, storage.NewMemoryStore(), moderation.NewPolicyModerator(policy, moderation.SeverityCritical), personas.Default())
+	conv := NewConversation("auto-2", "Tabs", &models.Autoplay{Rounds: 3})
+
+	if err := runner.Run(context.Background(), conv, nil); !errors.Is(err, ErrFlagged) {
+		t.Fatalf("expected ErrFlagged, got %v", err)
+	}
+
+	if len(conv.Messages) != 1 || !conv.Messages[0].Flagged() {
+		t.Fatalf("expected the flagged turn to be stored, got %+v", conv.Messages)
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	m := NewManager(newRunner(&scriptEngine{block: true}, storage.NewMemoryStore()), 1)
+
+	job, _, err := m.Start(NewConversation("auto-3", "Tabs", &models.Autoplay{Rounds: 1}), "")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	if _, _, err := m.Start(NewConversation("auto-4", "Tabs", &models.Autoplay{Rounds: 1}), ""); !errors.Is(err, ErrTooManyJobs) {
+		t.Fatalf("expected ErrTooManyJobs, got %v", err)
+	}
+
+	cancelled, ok := m.Cancel(job.ID)
+	if !ok || cancelled.Status != StatusCancelled || cancelled.FinishedAt == 0 {
+		t.Fatalf("expected a cancelled job, got %+v", cancelled)
+	}
+
+	// the slot is free again
+	next, done, err := m.Start(NewConversation("auto-5", "Tabs", &models.Autoplay{Rounds: 1}), "")
+	if err != nil {
+		t.Fatalf("start after cancel: %v", err)
+	}
+
+	m.Cancel(next.ID)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop")
+	}
+
+	if _, ok := m.Get("missing"); ok {
+		t.Fatal("unknown jobs should not be found")
+	}
+}
+
+func TestManagerDropsOldJobs(t *testing.T) {
+	m := NewManager(newRunner(&scriptEngine{}, storage.NewMemoryStore()), 1)
+
+	old, done, err := m.Start(NewConversation("auto-6", "Tabs", &models.Autoplay{Rounds: 1}), "")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	<-done
+
+	recent, done, err := m.Start(NewConversation("auto-7", "Tabs", &models.Autoplay{Rounds: 1}), "")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	<-done
+
+	// the first job finished past the retention period, the second just now
+	m.mu.Lock()
+	m.jobs[old.ID].FinishedAt = time.Now().Add(-jobRetention - time.Minute).UnixMilli()
+	m.mu.Unlock()
+
+	next, done, err := m.Start(NewConversation("auto-8", "Tabs", &models.Autoplay{Rounds: 1}), "")
+	if err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	<-done
+
+	if _, ok := m.Get(old.ID); ok {
+		t.Fatal("expected the old job to be dropped")
+	}
+
+	for _, id := range []string{recent.ID, next.ID} {
+		if j, ok := m.Get(id); !ok || j.Status != StatusCompleted {
+			t.Fatalf("expected job %s to be kept, got %+v", id, j)
+		}
+	}
+}
+
+func TestPerspectiveSkipsFlaggedTurns(t *testing.T) {
+	conv := NewConversation("auto-6", "Tabs", &models.Autoplay{Rounds: 1})
+	conv.Append(models.Message{Role: "user", Message: "PRO opening"})
+	conv.Append(models.Message{Role: "bot", Message: "bad", Moderation: &moderation.Verdict{Allowed: false}})
+
+	history, msg := perspective(conv, "bot")
+	if len(history) != 0 || !strings.Contains(msg, "PRO opening") {
+		t.Fatalf("unexpected perspective %+v %q", history, msg)
+	}
+}
@@ -0,0 +1,170 @@
+package autoplay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/oklog/ulid/v2"
+)
+
+// Job states.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// jobTimeout bounds a single autoplay debate.
+const jobTimeout = 10 * time.Minute
+
+// jobRetention is how long a finished job stays available to Get before it is dropped.
+const jobRetention = time.Hour
+
+// ErrTooManyJobs is returned when the manager is already running its maximum number of debates.
+var ErrTooManyJobs = errors.New("too many autoplay debates are running")
+
+// Job is a snapshot of an autoplay debate's progress.
+type Job struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	Owner          string `json:"owner,omitempty"` // the user who started it, if one was named
+	Status         string `json:"status"`
+	Turns          int    `json:"turns"`       // turns played so far
+	TotalTurns     int    `json:"total_turns"` // two per round
+	Error          string `json:"error,omitempty"`
+	StartedAt      int64  `json:"started_at"`            // unix ms
+	FinishedAt     int64  `json:"finished_at,omitempty"` // unix ms
+}
+
+// CanAccess reports whether userID may read or cancel the job. A job started without naming
+// a user is open to every client, like debates against the bot.
+func (j Job) CanAccess(userID string) bool {
+	return j.Owner == "" || j.Owner == userID
+}
+
+type job struct {
+	Job
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Manager runs autoplay debates in the background and keeps their status in memory, so a
+// job is only visible on the replica that runs it. Finished jobs are kept for jobRetention.
+type Manager struct {
+	runner  *Runner
+	maxJobs int
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	running int
+}
+
+func NewManager(runner *Runner, maxJobs int) *Manager {
+	return &Manager{runner: runner, maxJobs: maxJobs, jobs: map[string]*job{}}
+}
+
+// Start begins playing conv in the background on behalf of owner, who may be empty. The
+// returned channel is closed when it ends.
+func (m *Manager) Start(conv *models.Conversation, owner string) (Job, <-chan struct{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.running >= m.maxJobs {
+		return Job{}, nil, ErrTooManyJobs
+	}
+
+	m.prune(time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+
+	j := &job{
+		Job: Job{
+			ID:             ulid.Make().String(),
+			ConversationID: conv.ID,
+			Owner:          owner,
+			Status:         StatusRunning,
+			TotalTurns:     2 * conv.Autoplay.Rounds,
+			StartedAt:      time.Now().UnixMilli(),
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	m.jobs[j.ID] = j
+	m.running++
+
+	go m.run(ctx, j, conv)
+
+	return j.Job, j.done, nil
+}
+
+func (m *Manager) run(ctx context.Context, j *job, conv *models.Conversation) {
+	defer close(j.done)
+	defer j.cancel()
+
+	err := m.runner.Run(ctx, conv, func() {
+		m.mu.Lock()
+		j.Turns++
+		m.mu.Unlock()
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.running--
+	j.FinishedAt = time.Now().UnixMilli()
+
+	switch {
+	case err == nil:
+		j.Status = StatusCompleted
+	case errors.Is(err, context.Canceled):
+		j.Status = StatusCancelled
+	default:
+		j.Status = StatusFailed
+		j.Error = err.Error()
+	}
+}
+
+// prune drops the jobs that finished more than jobRetention before now. The caller holds m.mu.
+func (m *Manager) prune(now time.Time) {
+	cutoff := now.Add(-jobRetention).UnixMilli()
+
+	for id, j := range m.jobs {
+		if j.FinishedAt != 0 && j.FinishedAt < cutoff {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// Get returns the job's current state.
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+
+	return j.Job, true
+}
+
+// Cancel stops a running job. The turns played so far stay in the conversation.
+func (m *Manager) Cancel(id string) (Job, bool) {
+	m.mu.Lock()
+	j, ok := m.jobs[id]
+	m.mu.Unlock()
+
+	if !ok {
+		return Job{}, false
+	}
+
+	j.cancel()
+	<-j.done
+
+	return m.Get(id)
+}
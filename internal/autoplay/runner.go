@@ -0,0 +1,167 @@
+package autoplay
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/events"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/personas"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+// MaxRounds caps the length of an autoplay debate.
+const MaxRounds = 10
+
+// openingPrompt stands in for the opponent's message on the very first turn.
+const openingPrompt = "The debate begins. Present your opening argument."
+
+// ErrFlagged stops a debate when moderation withholds a generated turn.
+var ErrFlagged = errors.New("a turn was withheld by moderation")
+
+// Runner plays both sides of a debate against each other. The PRO side is stored with the
+// "user" role and the CON side with the "bot" role, so the transcript reads like any other
+// conversation and can be judged as one.
+type Runner struct {
+	engine    bot.Engine
+	store     storage.Store
+	moderator moderation.Moderator
+	personas  *personas.Catalog
+	events    events.Broker
+}
+
+func NewRunner(engine bot.Engine, store storage.Store, moderator moderation.Moderator, catalog *personas.Catalog) *Runner {
+	return &Runner{engine: engine, store: store, moderator: moderator, personas: catalog}
+}
+
+// WithEvents publishes every turn to the debate's spectators.
+func (r *Runner) WithEvents(b events.Broker) *Runner {
+	r.events = b
+	return r
+}
+
+// NewConversation prepares the conversation an autoplay debate is stored in.
+func NewConversation(id, topic string, settings *models.Autoplay) *models.Conversation {
+	conv := models.NewConversation(id)
+	conv.Topic = topic
+	conv.SetSides(models.StanceCon)
+	conv.Autoplay = settings
+
+	return conv
+}
+
+// Run alternates PRO and CON turns until the configured rounds are done or ctx is cancelled.
+// The conversation is saved after every turn so progress is visible while it runs.
+func (r *Runner) Run(ctx context.Context, conv *models.Conversation, onTurn func()) error {
+	for round := 0; round < conv.Autoplay.Rounds; round++ {
+		for _, stance := range []string{models.StancePro, models.StanceCon} {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			msg, err := r.turn(ctx, conv, stance)
+			if err != nil {
+				return err
+			}
+
+			conv.Append(msg)
+			r.save(ctx, conv)
+
+			if r.events != nil {
+				events.Notify(context.WithoutCancel(ctx), r.events, events.NewMessage(conv.ID, msg))
+			}
+
+			if onTurn != nil {
+				onTurn()
+			}
+
+			if msg.Flagged() {
+				return ErrFlagged
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Runner) turn(ctx context.Context, conv *models.Conversation, stance string) (models.Message, error) {
+	side := conv.Autoplay.Side(stance)
+	role := roleFor(stance)
+
+	history, opponent := perspective(conv, role)
+
+	var info bot.ReplyInfo
+
+	opts := r.personas.Options(side.Persona, side.Difficulty)
+	if side.Model != "" {
+		opts = append(opts, bot.WithModel(side.Model))
+	}
+
+	if side.Temperature != nil {
+		opts = append(opts, bot.WithTemperature(*side.Temperature))
+	}
+
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	reply, err := r.engine.Generate(ctx, conv.Topic, stance, history, opponent, opts...)
+	if err != nil {
+		return models.Message{}, err
+	}
+
+	msg := models.Message{Role: role, Message: reply, PromptVersion: info.PromptVersion}
+
+	verdict, err := r.moderator.Moderate(ctx, reply)
+	if err != nil {
+		log.Printf("autoplay moderation error (allowing turn): %v", err)
+	} else if !verdict.Allowed {
+		msg.Moderation = &verdict
+	}
+
+	return msg, nil
+}
+
+// perspective maps the transcript to the history one side sees: its own turns as the bot's,
+// the opponent's as the user's. The opponent's latest turn is returned separately as the
+// message to answer.
+func perspective(conv *models.Conversation, role string) ([]bot.HistoryItem, string) {
+	history := make([]bot.HistoryItem, 0, len(conv.Messages))
+
+	for _, m := range conv.Messages {
+		if m.Flagged() || m.Event == models.EventModeration {
+			continue
+		}
+
+		switch {
+		case m.Role == "system":
+			history = append(history, bot.HistoryItem{Role: m.Role, Message: m.Message})
+		case m.Role == role:
+			history = append(history, bot.HistoryItem{Role: "bot", Message: m.Message})
+		default:
+			history = append(history, bot.HistoryItem{Role: "user", Message: m.Message})
+		}
+	}
+
+	if n := len(history); n > 0 && history[n-1].Role == "user" {
+		return history[:n-1], history[n-1].Message
+	}
+
+	return history, openingPrompt
+}
+
+// save persists progress even after ctx is cancelled, so a stopped debate keeps its transcript.
+func (r *Runner) save(ctx context.Context, conv *models.Conversation) {
+	if err := r.store.SaveConversation(context.WithoutCancel(ctx), conv); err != nil {
+		log.Printf("autoplay: failed to save conversation %s: %v", conv.ID, err)
+	}
+}
+
+func roleFor(stance string) string {
+	if stance == models.StancePro {
+		return "user"
+	}
+
+	return "bot"
+}
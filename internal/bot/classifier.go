@@ -0,0 +1,127 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// StanceUnclear is returned when a classifier can't tell which side the user is on.
+const StanceUnclear = "UNCLEAR"
+
+// MinStanceConfidence is the confidence below which the API asks the user to confirm their side.
+const MinStanceConfidence = 0.6
+
+// StanceVerdict is the user's side as judged by a StanceClassifier.
+type StanceVerdict struct {
+	Stance     string  `json:"stance"`     // the user's side: PRO | CON | UNCLEAR
+	Confidence float64 `json:"confidence"` // 0..1
+}
+
+// StanceClassifier decides which side of a topic the user is arguing.
+type StanceClassifier interface {
+	Classify(ctx context.Context, topic, userMessage string) (StanceVerdict, error)
+}
+
+// KeywordClassifier is the keyword-counting heuristic behind DetermineStance.
+// It ignores negation, so its confidence is capped well below certainty.
+type KeywordClassifier struct{}
+
+func (KeywordClassifier) Classify(_ context.Context, _ string, userMessage string) (StanceVerdict, error) {
+	proCount, conCount := countStanceKeywords(userMessage)
+	total := proCount + conCount
+
+	if proCount == conCount {
+		return StanceVerdict{Stance: StanceUnclear}, nil
+	}
+
+	diff := proCount - conCount
+	stance := "PRO"
+
+	if diff < 0 {
+		diff = -diff
+		stance = "CON"
+	}
+
+	return StanceVerdict{Stance: stance, Confidence: 0.8 * float64(diff) / float64(total)}, nil
+}
+
+// LLMClassifier asks the engine for a structured stance verdict and falls back to
+// another classifier (the keyword heuristic by default) when the engine fails.
+type LLMClassifier struct {
+	engine   Engine
+	fallback StanceClassifier
+}
+
+func NewLLMClassifier(engine Engine, fallback StanceClassifier) *LLMClassifier {
+	if fallback == nil {
+		fallback = KeywordClassifier{}
+	}
+
+	return &LLMClassifier{engine: engine, fallback: fallback}
+}
+
+func (c *LLMClassifier) Classify(ctx context.Context, topic, userMessage string) (StanceVerdict, error) {
+	out, err := c.engine.Complete(ctx, buildStanceMessages(topic, userMessage), WithJSON(), WithTemperature(0), WithMaxTokens(60))
+	if err != nil {
+		return c.fallback.Classify(ctx, topic, userMessage)
+	}
+
+	verdict, err := parseStanceVerdict(out)
+	if err != nil {
+		return c.fallback.Classify(ctx, topic, userMessage)
+	}
+
+	return verdict, nil
+}
+
+func buildStanceMessages(topic, userMessage string) []map[string]string {
+	sys := `You classify which side of a debate topic a user is arguing.
+
+Topic: ` + topic + `
+
+Reply with a JSON object only: {"stance": "PRO" | "CON" | "UNCLEAR", "confidence": number between 0 and 1}.
+PRO means the user agrees with the topic statement, CON means they disagree.
+Pay attention to negation ("I don't like this" is CON) and sarcasm. Use UNCLEAR with low confidence if the message takes no side.`
+
+	return []map[string]string{
+		{"role": "system", "content": sys},
+		{"role": "user", "content": userMessage},
+	}
+}
+
+func parseStanceVerdict(out string) (StanceVerdict, error) {
+	var v StanceVerdict
+
+	if err := json.Unmarshal([]byte(ExtractJSON(out)), &v); err != nil {
+		return StanceVerdict{}, fmt.Errorf("invalid stance verdict: %w", err)
+	}
+
+	v.Stance = strings.ToUpper(strings.TrimSpace(v.Stance))
+	switch v.Stance {
+	case "PRO", "CON":
+	case StanceUnclear:
+		v.Confidence = 0
+	default:
+		return StanceVerdict{}, fmt.Errorf("invalid stance %q in verdict", v.Stance)
+	}
+
+	if v.Confidence < 0 || v.Confidence > 1 {
+		return StanceVerdict{}, fmt.Errorf("confidence %v out of range", v.Confidence)
+	}
+
+	return v, nil
+}
+
+// ExtractJSON trims any prose or code fences the model put around a JSON object.
+func ExtractJSON(out string) string {
+	start := strings.Index(out, "{")
+	end := strings.LastIndex(out, "}")
+
+	if start < 0 || end < start {
+		return out
+	}
+
+	return out[start : end+1]
+}
@@ -0,0 +1,159 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+type stanceFixture struct {
+	Topic   string `json:"topic"`
+	Message string `json:"message"`
+	Stance  string `json:"stance"`
+}
+
+func loadStanceFixtures(t *testing.T) []stanceFixture {
+	t.Helper()
+
+	b, err := os.ReadFile("testdata/stance_fixtures.json")
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+
+	var fixtures []stanceFixture
+	if err := json.Unmarshal(b, &fixtures); err != nil {
+		t.Fatalf("failed to parse fixtures: %v", err)
+	}
+
+	return fixtures
+}
+
+// stanceAccuracy returns the share of fixtures the classifier labels correctly.
+func stanceAccuracy(t *testing.T, classifier StanceClassifier, fixtures []stanceFixture) float64 {
+	t.Helper()
+
+	correct := 0
+
+	for _, f := range fixtures {
+		verdict, err := classifier.Classify(context.Background(), f.Topic, f.Message)
+		if err != nil {
+			t.Fatalf("classify %q: %v", f.Message, err)
+		}
+
+		if verdict.Stance == f.Stance {
+			correct++
+		}
+	}
+
+	return float64(correct) / float64(len(fixtures))
+}
+
+// labelEngine answers stance prompts from the fixture labels, or with a canned reply.
+type labelEngine struct {
+	labels map[string]string
+	reply  string
+	err    error
+}
+
+func (e labelEngine) Generate(ctx context.Context, topic, stance string, history []HistoryItem, userMessage string, opts ...Option) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (e labelEngine) Complete(ctx context.Context, messages []map[string]string, opts ...Option) (string, error) {
+	if e.err != nil {
+		return "", e.err
+	}
+
+	if e.reply != "" {
+		return e.reply, nil
+	}
+
+	label := e.labels[messages[len(messages)-1]["content"]]
+
+	return `{"stance": "` + label + `", "confidence": 0.9}`, nil
+}
+
+func TestKeywordClassifierAccuracy(t *testing.T) {
+	fixtures := loadStanceFixtures(t)
+	accuracy := stanceAccuracy(t, KeywordClassifier{}, fixtures)
+
+	t.Logf("keyword classifier accuracy: %.2f over %d fixtures", accuracy, len(fixtures))
+
+	// the heuristic ignores negation, so it is only expected to beat a coin flip
+	if accuracy < 0.5 {
+		t.Fatalf("keyword classifier accuracy regressed: %.2f", accuracy)
+	}
+}
+
+func TestKeywordClassifierUnclear(t *testing.T) {
+	verdict, _ := KeywordClassifier{}.Classify(context.Background(), "Topic", "Maybe it depends")
+	if verdict.Stance != StanceUnclear || verdict.Confidence != 0 {
+		t.Fatalf("expected UNCLEAR with zero confidence, got %+v", verdict)
+	}
+}
+
+// TestLLMClassifierParsesVerdicts only checks that structured replies are read back faithfully:
+// the engine answers with the fixture labels. TestOpenAIClassifierAccuracy measures a real model.
+func TestLLMClassifierParsesVerdicts(t *testing.T) {
+	fixtures := loadStanceFixtures(t)
+	labels := make(map[string]string, len(fixtures))
+
+	for _, f := range fixtures {
+		labels[f.Message] = f.Stance
+	}
+
+	accuracy := stanceAccuracy(t, NewLLMClassifier(labelEngine{labels: labels}, nil), fixtures)
+	if accuracy != 1 {
+		t.Fatalf("expected structured verdicts to be parsed faithfully, accuracy %.2f", accuracy)
+	}
+}
+
+func TestLLMClassifierFallback(t *testing.T) {
+	tests := []struct {
+		name   string
+		engine labelEngine
+	}{
+		{"Engine error", labelEngine{err: errors.New("boom")}},
+		{"Invalid JSON", labelEngine{reply: "I think they are PRO"}},
+		{"Invalid stance", labelEngine{reply: `{"stance": "MAYBE", "confidence": 0.9}`}},
+		{"Confidence out of range", labelEngine{reply: `{"stance": "PRO", "confidence": 7}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := NewLLMClassifier(tt.engine, nil).Classify(context.Background(), "Topic", "I agree with this")
+			if err != nil {
+				t.Fatalf("fallback should not error: %v", err)
+			}
+
+			if verdict.Stance != "PRO" {
+				t.Fatalf("expected keyword fallback verdict PRO, got %+v", verdict)
+			}
+		})
+	}
+}
+
+func TestParseStanceVerdictCodeFence(t *testing.T) {
+	verdict, err := parseStanceVerdict("```json\n{\"stance\": \"con\", \"confidence\": 0.75}\n```")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if verdict.Stance != "CON" || verdict.Confidence != 0.75 {
+		t.Fatalf("unexpected verdict: %+v", verdict)
+	}
+}
+
+func TestOpenAIClassifierAccuracy(t *testing.T) {
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" || testing.Short() {
+		t.Skip("Skipping live classifier evaluation: OPENAI_API_KEY not set")
+	}
+
+	fixtures := loadStanceFixtures(t)
+	accuracy := stanceAccuracy(t, NewLLMClassifier(NewOpenAIEngine(apiKey, "gpt-4o-mini"), nil), fixtures)
+
+	t.Logf("LLM classifier accuracy: %.2f over %d fixtures", accuracy, len(fixtures))
+}
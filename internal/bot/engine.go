@@ -5,11 +5,14 @@ import "context"
 // Engine is the debate text generator.
 type Engine interface {
 	// Generate returns the bot's reply given the topic, stance, history and latest user input.
-	Generate(ctx context.Context, topic, stance string, history []HistoryItem, userMessage string) (string, error)
+	Generate(ctx context.Context, topic, stance string, history []HistoryItem, userMessage string, opts ...Option) (string, error)
+	// Complete runs a raw chat completion over role/content messages. It backs auxiliary
+	// prompts (classifiers, judges, summaries) that don't use the debate persona.
+	Complete(ctx context.Context, messages []map[string]string, opts ...Option) (string, error)
 }
 
 // HistoryItem is a compact view for prompts.
 type HistoryItem struct {
-	Role    string // "user" or "bot"
+	Role    string // "user", "bot" or "system"
 	Message string
 }
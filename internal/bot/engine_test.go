@@ -3,10 +3,14 @@ package bot
 import (
 	"strings"
 	"testing"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/prompts"
 )
 
 func TestPickTopicAndStance(t *testing.T) {
-	topic, stance := PickTopicAndStance()
+	picked, stance := PickTopicAndStance(nil, "")
+	topic := picked.Name
 
 	if topic == "" {
 		t.Fatal("topic should not be empty")
@@ -41,6 +45,28 @@ func TestPickTopicAndStance(t *testing.T) {
 	}
 }
 
+func TestGetFallbackTopicDrawsFromCatalog(t *testing.T) {
+	catalog := []models.Topic{
+		{ID: "t1", Name: "Electric Vehicles", Category: "technology", Active: true},
+		{ID: "t2", Name: "Universal Basic Income", Category: "politics", Active: true},
+		{ID: "t3", Name: "Space Exploration", Category: "science"},
+	}
+
+	for i := 0; i < 20; i++ {
+		if topic := GetFallbackTopic(catalog, "Politics"); topic.ID != "t2" {
+			t.Fatalf("expected the only politics topic, got %+v", topic)
+		}
+
+		if topic := GetFallbackTopic(catalog, ""); topic.ID == "t3" || topic.ID == "" {
+			t.Fatalf("expected an active catalog topic, got %+v", topic)
+		}
+	}
+
+	if topic := GetFallbackTopic(catalog, "science"); topic.ID != "" || topic.Name == "" {
+		t.Fatalf("expected a built-in topic when the category has no active ones, got %+v", topic)
+	}
+}
+
 func TestBuildMessages(t *testing.T) {
 	topic := "Test topic"
 	stance := "PRO"
@@ -50,7 +76,14 @@ func TestBuildMessages(t *testing.T) {
 	}
 	userMessage := "Tell me more"
 
-	messages := buildMessages(topic, stance, history, userMessage)
+	messages, version, err := buildMessages(prompts.Default(), "", prompts.DebateData{Topic: topic, Stance: stance}, history, userMessage)
+	if err != nil {
+		t.Fatalf("buildMessages: %v", err)
+	}
+
+	if !strings.HasPrefix(version, prompts.DebateSystem+"@") {
+		t.Fatalf("expected a debate_system version, got %q", version)
+	}
 
 	// Should have system message + history + user message
 	expectedLength := 1 + len(history) + 1
@@ -88,8 +121,8 @@ func TestBuildMessages(t *testing.T) {
 		t.Fatalf("last message should be user, got %s", lastMessage["role"])
 	}
 
-	if lastMessage["content"] != userMessage {
-		t.Fatalf("last message content should be %s, got %s", userMessage, lastMessage["content"])
+	if lastMessage["content"] != wrapUserContent(userMessage) {
+		t.Fatalf("last message content should be the delimited %s, got %s", userMessage, lastMessage["content"])
 	}
 }
 
@@ -104,7 +137,9 @@ func TestValidateTopic(t *testing.T) {
 		{"Empty topic", "", false},
 		{"Whitespace only", "   ", false},
 		{"Violence content", "Violence is good", false},
-		{"Sexual content", "Sex education in schools", false},
+		{"Sexual content", "Pornography should be everywhere", false},
+		{"Educational phrasing", "Sex education in schools", true},
+		{"Policy phrasing", "Should hate speech laws exist", true},
 		{"Hate content", "Racism is acceptable", false},
 		{"Drug content", "Drugs should be legal", false},
 		{"Illegal content", "Theft is justified", false},
@@ -180,6 +215,18 @@ func TestProcessUserTopic(t *testing.T) {
 	}
 }
 
+func TestProcessUserTopicMatchesCatalog(t *testing.T) {
+	catalog := []models.Topic{{ID: "1", Name: "Artificial Intelligence Regulation", Active: true}}
+
+	if topic, _ := ProcessUserTopic("Should AI be regulated?", "I agree", catalog...); topic != "Artificial Intelligence Regulation" {
+		t.Errorf("expected the catalog topic, got %q", topic)
+	}
+
+	if topic, _ := ProcessUserTopic("Remote work is better", "I agree", catalog...); topic != "Remote work is better" {
+		t.Errorf("expected an unmatched topic to be kept, got %q", topic)
+	}
+}
+
 func assertValidFallbackTopic(t *testing.T, topic, inputTopic, inputMessage string) {
 	t.Helper()
 
@@ -196,3 +243,64 @@ func assertValidFallbackTopic(t *testing.T, topic, inputTopic, inputMessage stri
 		t.Errorf("ProcessUserTopic(%q, %q) returned topic %q, expected a fallback topic", inputTopic, inputMessage, topic)
 	}
 }
+
+func TestParseUserStance(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		wantErr  bool
+	}{
+		{"PRO", "PRO", false},
+		{" con ", "CON", false},
+		{"random", StanceRandom, false},
+		{"maybe", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := ParseUserStance(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUserStance(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+
+			if result != tt.expected {
+				t.Errorf("ParseUserStance(%q) = %q, expected %q", tt.input, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestResolveUserStance(t *testing.T) {
+	if got := ResolveUserStance("CON"); got != "CON" {
+		t.Fatalf("expected explicit stance to be kept, got %s", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		got := ResolveUserStance(StanceRandom)
+		if got != "PRO" && got != "CON" {
+			t.Fatalf("RANDOM should resolve to PRO or CON, got %s", got)
+		}
+	}
+}
+
+func TestBuildMessagesKeepsSystemNotes(t *testing.T) {
+	history := []HistoryItem{
+		{Role: "user", Message: "Hello"},
+		{Role: "bot", Message: "Hi there"},
+		{Role: "system", Message: "Sides switched: the user now argues PRO and the bot argues CON."},
+	}
+
+	messages, _, err := buildMessages(prompts.Default(), "", prompts.DebateData{Topic: "Test topic", Stance: "CON"}, history, "Go on")
+	if err != nil {
+		t.Fatalf("buildMessages: %v", err)
+	}
+
+	if messages[3]["role"] != "system" {
+		t.Fatalf("side switch note should stay a system message, got %s", messages[3]["role"])
+	}
+
+	if !strings.Contains(messages[0]["content"], "Your stance: CON") {
+		t.Fatalf("system prompt should follow the current stance: %s", messages[0]["content"])
+	}
+}
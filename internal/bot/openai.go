@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,7 +9,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/nikoremi97/debate/internal/prompts"
 )
 
 // openAIResponse represents the response from OpenAI Chat Completions API
@@ -23,33 +27,80 @@ type openAIChoice struct {
 
 // openAIMessage represents a message in the OpenAI response
 type openAIMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []openAIToolCall `json:"tool_calls,omitempty"`
+}
+
+// openAIToolCall is a function call requested by the model
+type openAIToolCall struct {
+	Index    int            `json:"index"` // position of the call while it is streamed
+	ID       string         `json:"id"`
+	Function openAIFunction `json:"function"`
+}
+
+// openAIFunction names the function to call and its JSON arguments
+type openAIFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
+// openAIChunk is one server-sent event of a streamed completion
+type openAIChunk struct {
+	Choices []struct {
+		Delta openAIMessage `json:"delta"`
+	} `json:"choices"`
+}
+
+// maxToolRounds bounds the tool-calling loop; after that the model has to answer.
+const maxToolRounds = 3
+
 // OpenAIEngine calls OpenAI's Chat Completions API (simple, cheap, effective).
 type OpenAIEngine struct {
-	apiKey string
-	model  string
-	url    string
-	client *http.Client
+	apiKey  string
+	model   string
+	url     string
+	client  *http.Client
+	prompts *prompts.Registry
 }
 
 func NewOpenAIEngine(apiKey, model string) *OpenAIEngine {
 	return &OpenAIEngine{
-		apiKey: apiKey,
-		model:  model,
-		url:    "https://api.openai.com/v1/chat/completions",
-		client: &http.Client{Timeout: 22 * time.Second},
+		apiKey:  apiKey,
+		model:   model,
+		url:     "https://api.openai.com/v1/chat/completions",
+		client:  &http.Client{Timeout: 22 * time.Second},
+		prompts: prompts.Default(),
+	}
+}
+
+// WithPrompts renders system prompts from reg instead of the embedded templates.
+func (e *OpenAIEngine) WithPrompts(reg *prompts.Registry) *OpenAIEngine {
+	e.prompts = reg
+	return e
+}
+
+func (e *OpenAIEngine) Generate(ctx context.Context, topic, stance string, history []HistoryItem, userMessage string, opts ...Option) (string, error) {
+	o := ApplyOptions(opts...)
+
+	messages, version, err := buildMessages(e.prompts, o.Template, prompts.DebateData{Topic: topic, Stance: stance, Style: o.Style, Phase: o.Phase, Evidence: o.Evidence}, history, userMessage)
+	if err != nil {
+		return "", err
+	}
+
+	if o.Info != nil {
+		o.Info.PromptVersion = version
 	}
+
+	return e.Complete(ctx, messages, opts...)
 }
 
-func (e *OpenAIEngine) Generate(ctx context.Context, topic, stance string, history []HistoryItem, userMessage string) (string, error) {
+func (e *OpenAIEngine) Complete(ctx context.Context, messages []map[string]string, opts ...Option) (string, error) {
 	if e.apiKey == "" {
 		return "", errors.New("OPENAI_API_KEY is missing")
 	}
 
-	messages := buildMessages(topic, stance, history, userMessage)
+	o := ApplyOptions(opts...)
 
 	payload := map[string]any{
 		"model":       e.model,
@@ -57,11 +108,119 @@ func (e *OpenAIEngine) Generate(ctx context.Context, topic, stance string, histo
 		"temperature": 0.9,
 		"max_tokens":  400,
 	}
+
+	if o.Model != "" {
+		payload["model"] = o.Model
+	}
+
+	if o.Temperature != nil {
+		payload["temperature"] = *o.Temperature
+	}
+
+	if o.MaxTokens > 0 {
+		payload["max_tokens"] = o.MaxTokens
+	}
+
+	switch {
+	case o.Schema != nil:
+		payload["response_format"] = map[string]any{
+			"type":        "json_schema",
+			"json_schema": map[string]any{"name": o.Schema.Name, "schema": o.Schema.Schema, "strict": true},
+		}
+	case o.JSON:
+		payload["response_format"] = map[string]string{"type": "json_object"}
+	}
+
+	if o.Info != nil {
+		o.Info.Model, _ = payload["model"].(string)
+	}
+
+	if o.Stream != nil {
+		payload["stream"] = true
+	}
+
+	if o.Tools.Len() > 0 {
+		return e.completeWithTools(ctx, payload, messages, o)
+	}
+
+	msg, err := e.send(ctx, payload, o.Stream)
+
+	return msg.Content, err
+}
+
+// completeWithTools runs the function-calling loop: while the model asks for tools, their
+// results are added to the conversation and the model is asked again. After maxToolRounds it
+// has to answer without them. A round is only known to be the answer once it has ended, so
+// its deltas are held until then; text written alongside tool calls is never streamed.
+func (e *OpenAIEngine) completeWithTools(ctx context.Context, payload map[string]any, messages []map[string]string, o Options) (string, error) {
+	conversation := make([]any, 0, len(messages)+4)
+	for _, m := range messages {
+		conversation = append(conversation, m)
+	}
+
+	payload["tools"] = o.Tools.definitions()
+
+	if o.Info != nil {
+		o.Info.ToolCalls = nil
+	}
+
+	for round := 0; ; round++ {
+		if round == maxToolRounds {
+			payload["tool_choice"] = "none"
+		}
+
+		payload["messages"] = conversation
+
+		var deltas []string
+
+		var stream func(string)
+		if o.Stream != nil {
+			stream = func(d string) { deltas = append(deltas, d) }
+		}
+
+		msg, err := e.send(ctx, payload, stream)
+		if err != nil {
+			return "", err
+		}
+
+		if len(msg.ToolCalls) == 0 || round == maxToolRounds {
+			for _, d := range deltas {
+				o.Stream(d)
+			}
+
+			return msg.Content, nil
+		}
+
+		conversation = append(conversation, toolRequest(msg))
+
+		for _, tc := range msg.ToolCalls {
+			call := o.Tools.Call(ctx, tc.Function.Name, tc.Function.Arguments)
+			if o.Info != nil {
+				o.Info.ToolCalls = append(o.Info.ToolCalls, call)
+			}
+
+			conversation = append(conversation, map[string]any{"role": "tool", "tool_call_id": tc.ID, "content": call.content()})
+		}
+	}
+}
+
+// toolRequest echoes the model's tool calls back into the conversation.
+func toolRequest(msg openAIMessage) map[string]any {
+	calls := make([]map[string]any, len(msg.ToolCalls))
+	for i, tc := range msg.ToolCalls {
+		calls[i] = map[string]any{"id": tc.ID, "type": "function", "function": tc.Function}
+	}
+
+	return map[string]any{"role": "assistant", "content": msg.Content, "tool_calls": calls}
+}
+
+// send posts one completion request and returns the model's message.
+func (e *OpenAIEngine) send(ctx context.Context, payload map[string]any, stream func(string)) (openAIMessage, error) {
 	b, _ := json.Marshal(payload)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(b))
 	if err != nil {
-		return "", err
+		return openAIMessage{}, err
 	}
 
 	req.Header.Set("Authorization", "Bearer "+e.apiKey)
@@ -69,25 +228,83 @@ func (e *OpenAIEngine) Generate(ctx context.Context, topic, stance string, histo
 
 	resp, err := e.client.Do(req)
 	if err != nil {
-		return "", err
+		return openAIMessage{}, err
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openai http %d: %s", resp.StatusCode, string(body))
+		return openAIMessage{}, fmt.Errorf("openai http %d: %s", resp.StatusCode, string(body))
+	}
+
+	if stream != nil {
+		return readStream(resp.Body, stream)
 	}
 
 	var out openAIResponse
 
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return "", err
+		return openAIMessage{}, err
 	}
 
 	if len(out.Choices) == 0 {
-		return "", errors.New("no choices returned")
+		return openAIMessage{}, errors.New("no choices returned")
+	}
+
+	return out.Choices[0].Message, nil
+}
+
+// readStream collects a streamed completion, passing each content delta to fn. Tool calls
+// arrive in pieces keyed by index and are stitched back together.
+func readStream(body io.Reader, fn func(string)) (openAIMessage, error) {
+	var (
+		reply strings.Builder
+		calls []openAIToolCall
+	)
+
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		if data == "[DONE]" {
+			return openAIMessage{Role: "assistant", Content: reply.String(), ToolCalls: calls}, nil
+		}
+
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return openAIMessage{}, fmt.Errorf("invalid stream chunk: %w", err)
+		}
+
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+
+		delta := chunk.Choices[0].Delta
+
+		for _, tc := range delta.ToolCalls {
+			for len(calls) <= tc.Index {
+				calls = append(calls, openAIToolCall{Index: len(calls)})
+			}
+
+			call := &calls[tc.Index]
+			call.ID += tc.ID
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+		}
+
+		if delta.Content != "" {
+			reply.WriteString(delta.Content)
+			fn(delta.Content)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return openAIMessage{}, err
 	}
 
-	return out.Choices[0].Message.Content, nil
+	return openAIMessage{}, errors.New("stream ended before [DONE]")
 }
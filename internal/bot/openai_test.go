@@ -0,0 +1,330 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+// openAIStub records the last chat completion payload and replies with content.
+func openAIStub(t *testing.T, content string, payload *map[string]any) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(openAIResponse{Choices: []openAIChoice{{Message: openAIMessage{Role: "assistant", Content: content}}}})
+	}))
+}
+
+func TestOpenAIEngineGenerateReportsPromptVersion(t *testing.T) {
+	var payload map[string]any
+
+	srv := openAIStub(t, "Pineapple is great.", &payload)
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	var info ReplyInfo
+
+	evidence := WithEvidence([]prompts.Passage{{Marker: 1, Source: "pizza.md", Text: "Hawaiian pizza dates from 1962."}})
+
+	reply, err := e.Generate(context.Background(), "Pineapple belongs on pizza", "PRO", nil, "No way", WithReplyInfo(&info), evidence)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if reply != "Pineapple is great." {
+		t.Fatalf("unexpected reply %q", reply)
+	}
+
+	if info.PromptVersion != prompts.Default().Versions()[prompts.DebateSystem] || info.Model != "gpt-4o-mini" {
+		t.Fatalf("unexpected reply info %+v", info)
+	}
+
+	messages := payload["messages"].([]any)
+	system := messages[0].(map[string]any)["content"].(string)
+
+	if !strings.Contains(system, "Topic: Pineapple belongs on pizza") {
+		t.Fatalf("system prompt should be rendered from the template: %s", system)
+	}
+
+	if !strings.Contains(system, "[1] (pizza.md) Hawaiian pizza dates from 1962.") {
+		t.Fatalf("system prompt should carry the evidence: %s", system)
+	}
+}
+
+func TestOpenAIEngineCompleteOptions(t *testing.T) {
+	var payload map[string]any
+
+	srv := openAIStub(t, `{"ok": true}`, &payload)
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	_, err := e.Complete(context.Background(), []map[string]string{{"role": "user", "content": "hi"}},
+		WithModel("gpt-4o"), WithTemperature(0), WithMaxTokens(50), WithJSON())
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if payload["model"] != "gpt-4o" || payload["temperature"] != float64(0) || payload["max_tokens"] != float64(50) {
+		t.Fatalf("options not applied: %+v", payload)
+	}
+
+	if payload["response_format"] == nil {
+		t.Fatalf("JSON mode should set response_format: %+v", payload)
+	}
+
+	schema := map[string]any{"type": "object", "properties": map[string]any{"ok": map[string]any{"type": "boolean"}}}
+
+	if _, err := e.Complete(context.Background(), []map[string]string{{"role": "user", "content": "hi"}}, WithJSONSchema("check", schema)); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	format, _ := payload["response_format"].(map[string]any)
+	spec, _ := format["json_schema"].(map[string]any)
+
+	if format["type"] != "json_schema" || spec["name"] != "check" || spec["strict"] != true || spec["schema"] == nil {
+		t.Fatalf("schema mode should send the schema: %+v", payload["response_format"])
+	}
+}
+
+func TestOpenAIEngineStream(t *testing.T) {
+	var payload map[string]any
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		for _, delta := range []string{"Pineapple ", "is ", "great."} {
+			chunk, _ := json.Marshal(map[string]any{"choices": []any{map[string]any{"delta": map[string]string{"content": delta}}}})
+			_, _ = w.Write([]byte("data: " + string(chunk) + "\n\n"))
+		}
+
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	var deltas []string
+
+	reply, err := e.Generate(context.Background(), "Pineapple belongs on pizza", "PRO", nil, "No way",
+		WithStream(func(d string) { deltas = append(deltas, d) }))
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if reply != "Pineapple is great." || len(deltas) != 3 || payload["stream"] != true {
+		t.Fatalf("unexpected stream result %q %q %+v", reply, deltas, payload)
+	}
+}
+
+// toolStub answers every request with a call to the "add" tool until it has seen rounds
+// of them, then with content, recording each payload.
+func toolStub(t *testing.T, rounds int, payloads *[]map[string]any) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		*payloads = append(*payloads, payload)
+
+		msg := openAIMessage{Role: "assistant", Content: "The total is 5."}
+		if len(*payloads) <= rounds {
+			msg = openAIMessage{Role: "assistant", ToolCalls: []openAIToolCall{{ID: "call_1", Function: openAIFunction{Name: "add", Arguments: `{"a": 2, "b": 3}`}}}}
+		}
+
+		_ = json.NewEncoder(w).Encode(openAIResponse{Choices: []openAIChoice{{Message: msg}}})
+	}))
+}
+
+func addTool() Tool {
+	return Tool{
+		Name:        "add",
+		Description: "Adds two numbers.",
+		Parameters:  map[string]any{"type": "object", "properties": map[string]any{"a": map[string]any{"type": "number"}, "b": map[string]any{"type": "number"}}},
+		Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct{ A, B float64 }
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", err
+			}
+
+			return fmt.Sprint(in.A + in.B), nil
+		},
+	}
+}
+
+func TestOpenAIEngineToolCalls(t *testing.T) {
+	var payloads []map[string]any
+
+	srv := toolStub(t, 1, &payloads)
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	var info ReplyInfo
+
+	reply, err := e.Complete(context.Background(), []map[string]string{{"role": "user", "content": "2 + 3?"}},
+		WithTools(NewTools(addTool())), WithReplyInfo(&info))
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if reply != "The total is 5." || len(payloads) != 2 {
+		t.Fatalf("expected a reply after one tool round, got %q after %d requests", reply, len(payloads))
+	}
+
+	if tools := payloads[0]["tools"].([]any); len(tools) != 1 {
+		t.Fatalf("expected the tool definitions in the request, got %+v", payloads[0]["tools"])
+	}
+
+	messages := payloads[1]["messages"].([]any)
+	result := messages[len(messages)-1].(map[string]any)
+
+	if result["role"] != "tool" || result["tool_call_id"] != "call_1" || result["content"] != "5" {
+		t.Fatalf("expected the tool result to be sent back, got %+v", result)
+	}
+
+	if len(info.ToolCalls) != 1 || info.ToolCalls[0].Name != "add" || info.ToolCalls[0].Result != "5" {
+		t.Fatalf("expected the call to be reported, got %+v", info.ToolCalls)
+	}
+}
+
+func TestOpenAIEngineStreamsOnlyTheAnswer(t *testing.T) {
+	requests := 0
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		w.Header().Set("Content-Type", "text/event-stream")
+
+		// the first round thinks aloud next to its tool call, the second answers
+		chunks := []string{`{"choices":[{"delta":{"content":"Let me add. "}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"add","arguments":"{\"a\": 2, \"b\": 3}"}}]}}]}`}
+		if requests > 1 {
+			chunks = []string{`{"choices":[{"delta":{"content":"The total "}}]}`, `{"choices":[{"delta":{"content":"is 5."}}]}`}
+		}
+
+		for _, chunk := range chunks {
+			_, _ = w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	var streamed strings.Builder
+
+	reply, err := e.Complete(context.Background(), []map[string]string{{"role": "user", "content": "2 + 3?"}},
+		WithTools(NewTools(addTool())), WithStream(func(d string) { streamed.WriteString(d) }))
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if reply != "The total is 5." || streamed.String() != reply {
+		t.Fatalf("expected only the answer to be streamed, got %q for reply %q", streamed.String(), reply)
+	}
+}
+
+func TestOpenAIEngineToolRoundsAreBounded(t *testing.T) {
+	var payloads []map[string]any
+
+	srv := toolStub(t, 100, &payloads)
+	defer srv.Close()
+
+	e := NewOpenAIEngine("test-key", "gpt-4o-mini")
+	e.url = srv.URL
+
+	_, err := e.Complete(context.Background(), []map[string]string{{"role": "user", "content": "2 + 3?"}}, WithTools(NewTools(addTool())))
+	if err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	if len(payloads) != maxToolRounds+1 || payloads[maxToolRounds]["tool_choice"] != "none" {
+		t.Fatalf("expected the last request to forbid tools, got %d requests", len(payloads))
+	}
+}
+
+func TestToolsCall(t *testing.T) {
+	slow := Tool{Name: "slow", Timeout: 10 * time.Millisecond, Run: func(ctx context.Context, _ json.RawMessage) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}}
+
+	// stuck ignores its context, so only Call can enforce the timeout
+	release := make(chan struct{})
+	defer close(release)
+
+	stuck := Tool{Name: "stuck", Timeout: 10 * time.Millisecond, Run: func(context.Context, json.RawMessage) (string, error) {
+		<-release
+		return "too late", nil
+	}}
+
+	long := Tool{Name: "long", Run: func(context.Context, json.RawMessage) (string, error) {
+		return "a" + strings.Repeat("é", maxToolResult), nil
+	}}
+
+	tools := NewTools(addTool(), slow, stuck, long)
+
+	if call := tools.Call(context.Background(), "add", `{"a": 1, "b": 1}`); call.Result != "2" || call.Error != "" {
+		t.Fatalf("unexpected call %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "slow", ""); !strings.Contains(call.Error, "timed out") {
+		t.Fatalf("expected a timeout, got %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "stuck", ""); !strings.Contains(call.Error, "timed out") {
+		t.Fatalf("expected a tool ignoring its context to time out, got %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "long", ""); !utf8.ValidString(call.Result) || len(call.Result) > maxToolResult+len("…") {
+		t.Fatalf("expected the result to be cut on a rune boundary, got %d bytes", len(call.Result))
+	}
+
+	if call := tools.Call(context.Background(), "missing", "{}"); call.Error == "" || call.content() != "error: unknown tool missing" {
+		t.Fatalf("expected an unknown tool error, got %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "add", "{"); call.Error == "" {
+		t.Fatalf("expected invalid arguments to be rejected, got %+v", call)
+	}
+}
+
+func TestReadStreamToolCalls(t *testing.T) {
+	body := strings.Join([]string{
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"add","arguments":"{\"a\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":" 2}"}}]}}]}`,
+		`data: [DONE]`,
+	}, "\n\n")
+
+	msg, err := readStream(strings.NewReader(body), func(string) { t.Fatal("tool calls aren't content") })
+	if err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "call_1" || msg.ToolCalls[0].Function.Arguments != `{"a": 2}` {
+		t.Fatalf("expected the streamed call to be stitched together, got %+v", msg.ToolCalls)
+	}
+}
@@ -0,0 +1,112 @@
+package bot
+
+import "github.com/nikoremi97/debate/internal/prompts"
+
+// Options tune a single completion call. Zero values mean "use the engine default".
+type Options struct {
+	Model       string
+	Temperature *float64
+	MaxTokens   int
+	JSON        bool          // ask for a JSON object response (structured output)
+	Schema      *Schema       // constrain the JSON response to a schema; implies JSON
+	Template    string        // prompt template for Generate; defaults to prompts.DebateSystem
+	Style       prompts.Style // persona and difficulty for the debate prompt
+	Phase       prompts.Phase // current phase of a structured debate
+	Evidence    []prompts.Passage
+	Info        *ReplyInfo
+	Stream      func(delta string) // receives the reply as it is generated, if the engine streams
+	Tools       *Tools             // functions the model may call before it replies
+}
+
+// Schema is a named JSON schema a structured response must follow.
+type Schema struct {
+	Name   string
+	Schema map[string]any
+}
+
+// ReplyInfo reports how a reply was produced. Engines fill it in when the caller
+// passes WithReplyInfo.
+type ReplyInfo struct {
+	PromptVersion string // name@hash of the system prompt template
+	Model         string
+	ToolCalls     []ToolCall // tools the model called on the way to the reply, in order
+}
+
+// Option mutates Options.
+type Option func(*Options)
+
+// WithModel overrides the engine's default model.
+func WithModel(model string) Option {
+	return func(o *Options) { o.Model = model }
+}
+
+// WithTemperature overrides the sampling temperature.
+func WithTemperature(t float64) Option {
+	return func(o *Options) { o.Temperature = &t }
+}
+
+// WithMaxTokens caps the length of the reply.
+func WithMaxTokens(n int) Option {
+	return func(o *Options) { o.MaxTokens = n }
+}
+
+// WithJSON requests a JSON object response.
+func WithJSON() Option {
+	return func(o *Options) { o.JSON = true }
+}
+
+// WithJSONSchema requests a JSON response that follows schema. Engines without schema
+// support fall back to a plain JSON object response.
+func WithJSONSchema(name string, schema map[string]any) Option {
+	return func(o *Options) {
+		o.JSON = true
+		o.Schema = &Schema{Name: name, Schema: schema}
+	}
+}
+
+// WithTemplate renders the debate system prompt from another template.
+func WithTemplate(name string) Option {
+	return func(o *Options) { o.Template = name }
+}
+
+// WithStyle sets the persona and difficulty the debate prompt is rendered with.
+func WithStyle(style prompts.Style) Option {
+	return func(o *Options) { o.Style = style }
+}
+
+// WithPhase tells the debate prompt which phase of a structured debate is running.
+func WithPhase(phase prompts.Phase) Option {
+	return func(o *Options) { o.Phase = phase }
+}
+
+// WithEvidence gives the debate prompt passages the bot should cite instead of inventing facts.
+func WithEvidence(passages []prompts.Passage) Option {
+	return func(o *Options) { o.Evidence = passages }
+}
+
+// WithReplyInfo asks the engine to describe the reply it produces in info.
+func WithReplyInfo(info *ReplyInfo) Option {
+	return func(o *Options) { o.Info = info }
+}
+
+// WithStream passes each chunk of the reply to fn while it is generated. The returned reply
+// is still the full text; engines that can't stream just return it.
+func WithStream(fn func(delta string)) Option {
+	return func(o *Options) { o.Stream = fn }
+}
+
+// WithTools lets the model call tools before it replies. Engines without function calling
+// ignore them.
+func WithTools(tools *Tools) Option {
+	return func(o *Options) { o.Tools = tools }
+}
+
+// ApplyOptions folds opts into an Options value.
+func ApplyOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -5,20 +5,15 @@ import (
 	"math/big"
 	"regexp"
 	"strings"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/prompts"
+	"github.com/nikoremi97/debate/internal/topics"
 )
 
-// Safety validation patterns for inappropriate content
 var (
-	// Patterns for violence, explicit content, and inappropriate behavior
-	inappropriatePatterns = []*regexp.Regexp{
-		regexp.MustCompile(`(?i)\b(violence|violent|kill|murder|assault|attack|harm|hurt|abuse|torture|suicide|self-harm)\b`),
-		regexp.MustCompile(`(?i)\b(sex|sexual|porn|pornography|nude|naked|prostitution|rape|molest)\b`),
-		regexp.MustCompile(`(?i)\b(hate|racist|racism|discrimination|bigotry|slur|offensive|inappropriate)\b`),
-		regexp.MustCompile(`(?i)\b(drug|drugs|cocaine|heroin|marijuana|weed|alcohol abuse|addiction)\b`),
-		regexp.MustCompile(`(?i)\b(illegal|crime|criminal|theft|fraud|scam|exploit)\b`),
-	}
-
-	// Fallback topics if user topic is inappropriate
+	// Fallback topics if user topic is inappropriate and the catalog has none to offer
 	fallbackTopics = []string{
 		"The Earth is flat",
 		"Pineapple belongs on pizza",
@@ -30,39 +25,53 @@ var (
 	}
 )
 
-// ValidateTopic checks if a topic is appropriate for debate
+// ValidateTopic checks if a topic is appropriate for debate under the default moderation policy
 func ValidateTopic(topic string) bool {
-	if strings.TrimSpace(topic) == "" {
-		return false
-	}
+	return moderation.Default().CheckTopic(topic).Allowed
+}
 
-	// Check against inappropriate patterns
-	for _, pattern := range inappropriatePatterns {
-		if pattern.MatchString(topic) {
-			return false
+// GetFallbackTopic returns a random active catalog topic of the category ("" for any), or a
+// random built-in safe topic, without an ID, when none matches.
+func GetFallbackTopic(catalog []models.Topic, category string) models.Topic {
+	var candidates []models.Topic
+
+	for _, t := range catalog {
+		if t.Active && (category == "" || strings.EqualFold(t.Category, category)) {
+			candidates = append(candidates, t)
 		}
 	}
 
-	// Additional checks for length and content
-	if len(topic) > 200 { // reasonable length limit
-		return false
+	if len(candidates) == 0 {
+		for _, name := range fallbackTopics {
+			candidates = append(candidates, models.Topic{Name: name, Active: true})
+		}
 	}
 
-	return true
-}
-
-// GetFallbackTopic returns a random safe topic
-func GetFallbackTopic() string {
-	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(fallbackTopics))))
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(candidates))))
 	if err != nil {
-		return fallbackTopics[0]
+		return candidates[0]
 	}
 
-	return fallbackTopics[n.Int64()]
+	return candidates[n.Int64()]
 }
 
 // DetermineStance analyzes user message to determine if they're PRO or CON
 func DetermineStance(userMessage string) string {
+	proCount, conCount := countStanceKeywords(userMessage)
+
+	// If user seems to be PRO, bot should be CON, and vice versa
+	if proCount > conCount {
+		return "CON"
+	} else if conCount > proCount {
+		return "PRO"
+	}
+
+	// Default to CON if unclear (bot takes opposite stance)
+	return "CON"
+}
+
+// countStanceKeywords counts PRO and CON keywords in the user message.
+func countStanceKeywords(userMessage string) (int, int) {
 	// Simple keyword-based stance detection
 	// This could be enhanced with more sophisticated NLP
 	proKeywords := []string{"agree", "support", "pro", "for", "yes", "good", "benefit", "advantage", "favor", "like", "love", "prefer", "better", "best", "should", "must", "need", "important", "valuable", "worth", "right", "correct", "true"}
@@ -93,32 +102,31 @@ func DetermineStance(userMessage string) string {
 		}
 	}
 
-	// If user seems to be PRO, bot should be CON, and vice versa
-	if proCount > conCount {
-		return "CON"
-	} else if conCount > proCount {
-		return "PRO"
-	}
-
-	// Default to CON if unclear (bot takes opposite stance)
-	return "CON"
+	return proCount, conCount
 }
 
-// PickTopicAndStance selects a topic and assigns the bot the PRO stance by default.
-// You can randomize stance as well if you prefer.
-func PickTopicAndStance() (string, string) {
-	topic := GetFallbackTopic()
+// PickTopicAndStance selects a topic from the catalog, like GetFallbackTopic, and assigns the
+// bot the PRO stance by default. You can randomize stance as well if you prefer.
+func PickTopicAndStance(catalog []models.Topic, category string) (models.Topic, string) {
+	topic := GetFallbackTopic(catalog, category)
 	stance := "PRO" // keep consistent; change to random if desired
 
 	return topic, stance
 }
 
-// ProcessUserTopic validates and processes a user-provided topic
-func ProcessUserTopic(userTopic string, userMessage string) (string, string) {
+// ProcessUserTopic validates and processes a user-provided topic, falling back to a safe
+// topic when it is rejected. A topic close enough to one of the catalog's becomes that topic,
+// by the same topics.Matcher the API links debates with. The API checks the policy itself so
+// users can rephrase, and keeps the user's wording.
+func ProcessUserTopic(userTopic string, userMessage string, catalog ...models.Topic) (string, string) {
 	// Validate the topic
 	if !ValidateTopic(userTopic) {
 		// Use fallback topic if user topic is inappropriate
-		return GetFallbackTopic(), "PRO"
+		return GetFallbackTopic(nil, "").Name, "PRO"
+	}
+
+	if match, ok := topics.NewMatcher(topics.DefaultThreshold).Match(catalog, userTopic); ok {
+		userTopic = match.Name
 	}
 
 	// Determine bot stance based on user's position
@@ -127,23 +135,39 @@ func ProcessUserTopic(userTopic string, userMessage string) (string, string) {
 	return userTopic, stance
 }
 
-func buildMessages(topic, stance string, history []HistoryItem, userMessage string) []map[string]string {
+// buildMessages renders the debate system prompt from the registry and maps the history to
+// chat messages. It returns the version of the template used.
+func buildMessages(reg *prompts.Registry, template string, data prompts.DebateData, history []HistoryItem, userMessage string) ([]map[string]string, string, error) {
+	if template == "" {
+		template = prompts.DebateSystem
+	}
+
 	// System prompt: fix topic and stance and the debate persona
-	sys := map[string]string{"role": "system", "content": `You are a debate chatbot.\n\nTopic: ` + topic + `\nYour stance: ` + stance + ` (stand your ground, never switch sides).\n\nCRITICAL RULES:\n- You MUST ONLY debate about the specified Topic: ` + topic + `\n- NEVER respond to or engage with different topics mentioned by the user\n- If the user mentions a different topic, politely redirect them back to the original debate topic\n- Stay focused on the original debate topic throughout the entire conversation\n\nGoals:\n- Be persuasive, calm, and structured.\n- Use short evidence and analogies.\n- Acknowledge counterpoints briefly, then reframe.\n- Keep responses concise (3-6 sentences).\n- Always bring the conversation back to the original topic if the user tries to change subjects.`}
+	content, version, err := reg.Render(template, data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sys := map[string]string{"role": "system", "content": content}
 
 	msgs := []map[string]string{sys}
-	// Map history to OpenAI messages (convert "bot" -> "assistant")
+	// Map history to OpenAI messages (convert "bot" -> "assistant"; "system" notes such as side switches pass through)
 	for _, h := range history {
 		role := h.Role
 		if role == "bot" {
 			role = "assistant"
 		}
 
-		msgs = append(msgs, map[string]string{"role": role, "content": h.Message})
+		content := h.Message
+		if role == "user" {
+			content = wrapUserContent(content)
+		}
+
+		msgs = append(msgs, map[string]string{"role": role, "content": content})
 	}
 
 	// Latest user message (already appended in handler, but include here to guide model)
-	msgs = append(msgs, map[string]string{"role": "user", "content": userMessage})
+	msgs = append(msgs, map[string]string{"role": "user", "content": wrapUserContent(userMessage)})
 
-	return msgs
+	return msgs, version, nil
 }
@@ -0,0 +1,36 @@
+package bot
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// StanceRandom lets the user ask for a randomly assigned side.
+const StanceRandom = "RANDOM"
+
+// ParseUserStance normalizes a user-requested stance (PRO, CON or RANDOM).
+func ParseUserStance(stance string) (string, error) {
+	s := strings.ToUpper(strings.TrimSpace(stance))
+	switch s {
+	case "PRO", "CON", StanceRandom:
+		return s, nil
+	default:
+		return "", fmt.Errorf("invalid user_stance %q: expected PRO, CON or RANDOM", stance)
+	}
+}
+
+// ResolveUserStance turns a parsed user stance into a concrete side, picking one at random for RANDOM.
+func ResolveUserStance(stance string) string {
+	if stance != StanceRandom {
+		return stance
+	}
+
+	n, err := rand.Int(rand.Reader, big.NewInt(2))
+	if err != nil || n.Int64() == 0 {
+		return "PRO"
+	}
+
+	return "CON"
+}
@@ -0,0 +1,191 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultToolTimeout bounds a tool call that doesn't set its own timeout.
+const defaultToolTimeout = 5 * time.Second
+
+// maxToolResult caps, in bytes, what a tool hands back to the model.
+const maxToolResult = 4000
+
+// Tool is a Go function the model may call while it writes a reply.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]any // JSON schema of the arguments object
+	Timeout     time.Duration  // per call; defaultToolTimeout when zero
+	Run         func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// ToolCall records a call the model made and what it got back.
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Tools is a registry of tools by name.
+type Tools struct {
+	tools []Tool
+}
+
+// NewTools registers tools; a later tool replaces an earlier one with the same name.
+func NewTools(tools ...Tool) *Tools {
+	t := &Tools{}
+	for _, tool := range tools {
+		t.Add(tool)
+	}
+
+	return t
+}
+
+// Add registers a tool, replacing any tool with the same name.
+func (t *Tools) Add(tool Tool) {
+	for i := range t.tools {
+		if t.tools[i].Name == tool.Name {
+			t.tools[i] = tool
+			return
+		}
+	}
+
+	t.tools = append(t.tools, tool)
+}
+
+// Get returns the tool with the given name.
+func (t *Tools) Get(name string) (Tool, bool) {
+	if t != nil {
+		for _, tool := range t.tools {
+			if tool.Name == name {
+				return tool, true
+			}
+		}
+	}
+
+	return Tool{}, false
+}
+
+// Len returns the number of registered tools.
+func (t *Tools) Len() int {
+	if t == nil {
+		return 0
+	}
+
+	return len(t.tools)
+}
+
+// Call runs a tool within its timeout. Unknown tools, bad arguments and failures come back
+// as errors in the record, which the model sees as the result so it can recover. A tool that
+// ignores ctx is abandoned when the timeout passes; it keeps running but its result is dropped.
+func (t *Tools) Call(ctx context.Context, name, arguments string) ToolCall {
+	call := ToolCall{Name: name, Arguments: arguments}
+
+	tool, ok := t.Get(name)
+	if !ok {
+		call.Error = "unknown tool " + name
+		return call
+	}
+
+	if arguments == "" {
+		arguments = "{}"
+	}
+
+	if !json.Valid([]byte(arguments)) {
+		call.Error = "arguments are not valid JSON"
+		return call
+	}
+
+	timeout := tool.Timeout
+	if timeout <= 0 {
+		timeout = defaultToolTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result string
+		err    error
+	}
+
+	done := make(chan outcome, 1)
+
+	go func() {
+		result, err := tool.Run(ctx, json.RawMessage(arguments))
+		done <- outcome{result, err}
+	}()
+
+	var result string
+	var err error
+
+	select {
+	case out := <-done:
+		result, err = out.result, out.err
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		call.Error = fmt.Sprintf("%s timed out after %s", name, timeout)
+	case err != nil:
+		call.Error = err.Error()
+	default:
+		call.Result = truncateResult(result)
+	}
+
+	return call
+}
+
+// truncateResult cuts result to maxToolResult bytes without splitting a rune.
+func truncateResult(result string) string {
+	if len(result) <= maxToolResult {
+		return result
+	}
+
+	cut := maxToolResult
+	for cut > 0 && !utf8.RuneStart(result[cut]) {
+		cut--
+	}
+
+	return result[:cut] + "…"
+}
+
+// content is what the model is told a call returned.
+func (c ToolCall) content() string {
+	if c.Error != "" {
+		return "error: " + c.Error
+	}
+
+	return c.Result
+}
+
+// definitions describes the tools in the Chat Completions format.
+func (t *Tools) definitions() []map[string]any {
+	defs := make([]map[string]any, 0, t.Len())
+
+	for _, tool := range t.tools {
+		params := tool.Parameters
+		if params == nil {
+			params = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+
+		defs = append(defs, map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  params,
+			},
+		})
+	}
+
+	return defs
+}
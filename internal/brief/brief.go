@@ -0,0 +1,180 @@
+// Package brief writes prep case files for debate topics: the strongest arguments on both
+// sides, the rebuttals to expect, key definitions and evidence from the local corpus.
+package brief
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+// evidencePassages is how many corpus passages a brief is given to cite.
+const evidencePassages = 6
+
+// Generator writes briefs from the topic_brief prompt template.
+type Generator struct {
+	engine   bot.Engine
+	prompts  *prompts.Registry
+	evidence evidence.Retriever
+	opts     []bot.Option
+}
+
+// New returns a generator. corpus may be nil, in which case briefs suggest no evidence. opts
+// tune the call, e.g. a stronger model.
+func New(engine bot.Engine, registry *prompts.Registry, corpus evidence.Retriever, opts ...bot.Option) *Generator {
+	return &Generator{engine: engine, prompts: registry, evidence: corpus, opts: opts}
+}
+
+// Version returns the version of the prompt template briefs are currently written with.
+// A brief cached under another version is out of date.
+func (g *Generator) Version() string {
+	t, ok := g.prompts.Get(prompts.TopicBrief)
+	if !ok {
+		return ""
+	}
+
+	return t.Version()
+}
+
+// Generate writes a brief for topic.
+func (g *Generator) Generate(ctx context.Context, topic *models.Topic) (*models.Brief, error) {
+	passages, err := g.retrieve(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	data := prompts.BriefData{Topic: topic.Name, Description: topic.Description, Evidence: evidence.Prompt(passages)}
+
+	system, version, err := g.prompts.Render(prompts.TopicBrief, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var info bot.ReplyInfo
+
+	opts := append([]bot.Option{bot.WithJSONSchema("topic_brief", schema), bot.WithTemperature(0.4), bot.WithMaxTokens(2500)}, g.opts...)
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	out, err := g.engine.Complete(ctx, []map[string]string{
+		{"role": "system", "content": system},
+		{"role": "user", "content": "Write the brief for: " + topic.Name},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("brief: %w", err)
+	}
+
+	brief, err := parse(out, len(passages))
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range passages {
+		brief.Evidence = append(brief.Evidence, models.Citation{Marker: i + 1, Source: p.Source, Title: p.Title, Excerpt: p.Text})
+	}
+
+	brief.TopicID = topic.ID
+	brief.Topic = topic.Name
+	brief.PromptVersion = version
+	brief.Model = info.Model
+	brief.GeneratedAt = time.Now().UnixMilli()
+
+	return brief, nil
+}
+
+func (g *Generator) retrieve(ctx context.Context, topic *models.Topic) ([]evidence.Passage, error) {
+	if g.evidence == nil {
+		return nil, nil
+	}
+
+	passages, err := g.evidence.Retrieve(ctx, topic.Name, topic.Name+" "+topic.Description, evidencePassages)
+	if err != nil {
+		return nil, fmt.Errorf("brief: failed to retrieve evidence: %w", err)
+	}
+
+	return passages, nil
+}
+
+// parse validates the model's brief. Evidence markers that point at no passage are dropped.
+func parse(out string, passages int) (*models.Brief, error) {
+	var brief models.Brief
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &brief); err != nil {
+		return nil, fmt.Errorf("invalid brief: %w", err)
+	}
+
+	if len(brief.Pro) == 0 || len(brief.Con) == 0 {
+		return nil, errors.New("invalid brief: it needs arguments for both sides")
+	}
+
+	for _, side := range [][]models.BriefArgument{brief.Pro, brief.Con} {
+		for i := range side {
+			markers := side[i].Evidence[:0]
+
+			for _, m := range side[i].Evidence {
+				if m >= 1 && m <= passages {
+					markers = append(markers, m)
+				}
+			}
+
+			side[i].Claim = strings.TrimSpace(side[i].Claim)
+			side[i].Evidence = markers
+		}
+	}
+
+	if brief.Definitions == nil {
+		brief.Definitions = []models.Definition{}
+	}
+
+	brief.Evidence = []models.Citation{}
+
+	return &brief, nil
+}
+
+// schema is the structured-output contract of the brief. Strict schemas need every property
+// listed as required and no extra properties.
+var schema = object(map[string]any{
+	"pro": array(argumentSchema),
+	"con": array(argumentSchema),
+	"definitions": array(object(map[string]any{
+		"term":       str,
+		"definition": str,
+	})),
+})
+
+var (
+	str = map[string]any{"type": "string"}
+
+	argumentSchema = object(map[string]any{
+		"claim":     str,
+		"reasoning": str,
+		"evidence":  array(map[string]any{"type": "integer"}),
+		"rebuttals": array(object(map[string]any{
+			"objection": str,
+			"response":  str,
+		})),
+	})
+)
+
+func object(properties map[string]any) map[string]any {
+	required := make([]string, 0, len(properties))
+	for name := range properties {
+		required = append(required, name)
+	}
+
+	sort.Strings(required)
+
+	return map[string]any{"type": "object", "properties": properties, "required": required, "additionalProperties": false}
+}
+
+func array(items map[string]any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
@@ -0,0 +1,81 @@
+package brief
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/evidence"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+// cannedEngine returns out from Complete and records what it was sent.
+type cannedEngine struct {
+	out  string
+	sent *[]map[string]string
+	opts *bot.Options
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, opts ...bot.Option) (string, error) {
+	*e.sent = messages
+	*e.opts = bot.ApplyOptions(opts...)
+
+	return e.out, nil
+}
+
+const out = `{
+  "pro": [{"claim": " Commutes waste time ", "reasoning": "Hours a week go to travel.", "evidence": [1, 7],
+           "rebuttals": [{"objection": "Commutes separate work and home.", "response": "A walk does that too."}]}],
+  "con": [{"claim": "Offices build culture", "reasoning": "Mentoring happens in person.", "evidence": [],
+           "rebuttals": []}],
+  "definitions": [{"term": "remote work", "definition": "Working away from the employer's premises."}]
+}`
+
+func TestGenerate(t *testing.T) {
+	var (
+		sent []map[string]string
+		opts bot.Options
+	)
+
+	corpus := evidence.NewIndex()
+	corpus.Add("", "commute.md", "# Commuting\n\nThe average commute takes 27 minutes each way.")
+
+	g := New(cannedEngine{out: out, sent: &sent, opts: &opts}, prompts.Default(), corpus)
+	topic := &models.Topic{ID: "t1", Name: "Remote work", Description: "Is remote work better than a daily commute to the office?"}
+
+	b, err := g.Generate(context.Background(), topic)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	if b.TopicID != "t1" || b.PromptVersion != g.Version() || !strings.HasPrefix(b.PromptVersion, prompts.TopicBrief+"@") {
+		t.Fatalf("unexpected brief metadata %+v", b)
+	}
+
+	if a := b.Pro[0]; a.Claim != "Commutes waste time" || len(a.Evidence) != 1 || a.Evidence[0] != 1 || len(a.Rebuttals) != 1 {
+		t.Fatalf("expected the claim trimmed and the unknown marker dropped, got %+v", a)
+	}
+
+	if len(b.Evidence) != 1 || b.Evidence[0].Source != "commute.md" || b.Evidence[0].Title != "Commuting" {
+		t.Fatalf("expected the retrieved passage as evidence, got %+v", b.Evidence)
+	}
+
+	if !strings.Contains(sent[0]["content"], "[1] (commute.md) The average commute takes 27 minutes each way.") {
+		t.Fatalf("expected the evidence in the prompt:\n%s", sent[0]["content"])
+	}
+
+	if opts.Schema == nil || opts.Schema.Name != "topic_brief" || !opts.JSON {
+		t.Fatalf("expected a JSON schema response, got %+v", opts)
+	}
+
+	if _, err := New(cannedEngine{out: `{"pro": [], "con": []}`, sent: &sent, opts: &opts}, prompts.Default(), nil).Generate(context.Background(), topic); err == nil {
+		t.Fatal("expected a one-sided brief to be rejected")
+	}
+}
@@ -0,0 +1,154 @@
+// Package coach spots logical fallacies in the user's arguments and explains them, so
+// debaters can learn from a debate and not only win it.
+package coach
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// MinConfidence is the confidence below which a suspected fallacy isn't reported; a coach
+// that cries wolf gets ignored.
+const MinConfidence = 0.6
+
+// Fallacy is one entry of the catalog the classifier picks from.
+type Fallacy struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Fallacies is the catalog of common fallacies the coach looks for.
+var Fallacies = []Fallacy{
+	{"strawman", "Strawman", "misrepresents the opponent's argument to make it easier to attack"},
+	{"ad_hominem", "Ad hominem", "attacks the person instead of their argument"},
+	{"slippery_slope", "Slippery slope", "claims a first step inevitably leads to an extreme outcome without showing how"},
+	{"false_dilemma", "False dilemma", "presents two options as the only ones when others exist"},
+	{"hasty_generalization", "Hasty generalization", "draws a broad conclusion from too few or unrepresentative cases"},
+	{"appeal_to_authority", "Appeal to authority", "treats an authority's say-so as proof, especially outside their field"},
+	{"appeal_to_emotion", "Appeal to emotion", "swaps reasons for feelings such as fear or pity"},
+	{"bandwagon", "Bandwagon", "argues something is right because many people believe or do it"},
+	{"red_herring", "Red herring", "changes the subject to something that doesn't bear on the point"},
+	{"circular_reasoning", "Circular reasoning", "assumes the conclusion in the premises"},
+	{"tu_quoque", "Tu quoque", "deflects criticism by pointing out the opponent does the same"},
+	{"false_cause", "False cause", "assumes that because one thing followed another, it was caused by it"},
+}
+
+// Lookup returns the catalog entry with the given ID.
+func Lookup(id string) (Fallacy, bool) {
+	for _, f := range Fallacies {
+		if f.ID == id {
+			return f, true
+		}
+	}
+
+	return Fallacy{}, false
+}
+
+// Classifier finds the fallacies in a user message. previous is the opponent's argument the
+// message answers, if any; a strawman can't be spotted without it.
+type Classifier interface {
+	Classify(ctx context.Context, topic, previous, message string) ([]models.FallacyAnnotation, error)
+}
+
+// LLMClassifier asks the model to annotate the message with a structured-output prompt.
+type LLMClassifier struct {
+	engine bot.Engine
+	opts   []bot.Option
+}
+
+// NewLLMClassifier returns a classifier using engine. opts tune the call, e.g. a cheaper model.
+func NewLLMClassifier(engine bot.Engine, opts ...bot.Option) *LLMClassifier {
+	return &LLMClassifier{engine: engine, opts: opts}
+}
+
+func (c *LLMClassifier) Classify(ctx context.Context, topic, previous, message string) ([]models.FallacyAnnotation, error) {
+	opts := append([]bot.Option{bot.WithJSON(), bot.WithTemperature(0), bot.WithMaxTokens(600)}, c.opts...)
+
+	out, err := c.engine.Complete(ctx, buildMessages(topic, previous, message), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("coach: %w", err)
+	}
+
+	return parse(out, message)
+}
+
+func buildMessages(topic, previous, message string) []map[string]string {
+	var b strings.Builder
+
+	b.WriteString("You are a debate coach. A student is debating the topic: " + topic + ".\n")
+	b.WriteString("Check the student's message for these logical fallacies:\n")
+
+	for _, f := range Fallacies {
+		fmt.Fprintf(&b, "- %s (%s): %s\n", f.ID, f.Name, f.Description)
+	}
+
+	b.WriteString(`
+Only report clear cases; strong opinions, sarcasm and emphatic language are not fallacies.
+For each one, quote the words that commit it, explain the problem in one or two sentences
+addressed to the student, and suggest how to make the point soundly.
+Reply with a JSON object only:
+{"fallacies": [{"fallacy": id, "quote": string, "explanation": string, "suggestion": string, "confidence": number between 0 and 1}]}
+Use an empty list if the message has no fallacies.`)
+
+	var user strings.Builder
+	if previous != "" {
+		user.WriteString("Opponent's argument:\n" + previous + "\n\n")
+	}
+
+	user.WriteString("Student's message:\n" + message)
+
+	return []map[string]string{
+		{"role": "system", "content": b.String()},
+		{"role": "user", "content": user.String()},
+	}
+}
+
+// parse validates the model's annotations. Unknown fallacies, low-confidence ones and repeats
+// are dropped, and a quote that isn't in the message is left out rather than shown.
+func parse(out, message string) ([]models.FallacyAnnotation, error) {
+	var raw struct {
+		Fallacies []struct {
+			models.FallacyAnnotation
+			Confidence *float64 `json:"confidence"`
+		} `json:"fallacies"`
+	}
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &raw); err != nil {
+		return nil, fmt.Errorf("invalid coaching notes: %w", err)
+	}
+
+	var (
+		notes []models.FallacyAnnotation
+		seen  = map[string]bool{}
+	)
+
+	for _, r := range raw.Fallacies {
+		note := r.FallacyAnnotation
+		note.Fallacy = strings.ToLower(strings.TrimSpace(note.Fallacy))
+
+		f, ok := Lookup(note.Fallacy)
+		if !ok || seen[f.ID] || strings.TrimSpace(note.Explanation) == "" {
+			continue
+		}
+
+		if r.Confidence != nil && *r.Confidence < MinConfidence {
+			continue
+		}
+
+		if !strings.Contains(strings.ToLower(message), strings.ToLower(note.Quote)) {
+			note.Quote = ""
+		}
+
+		seen[f.ID] = true
+		note.Name = f.Name
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
@@ -0,0 +1,83 @@
+package coach
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+)
+
+// cannedEngine returns out from Complete and records the messages it was sent.
+type cannedEngine struct {
+	out  string
+	err  error
+	sent *[]map[string]string
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, _ ...bot.Option) (string, error) {
+	if e.sent != nil {
+		*e.sent = messages
+	}
+
+	return e.out, e.err
+}
+
+func TestClassify(t *testing.T) {
+	var sent []map[string]string
+
+	out := "```json\n" + `{"fallacies": [
+	  {"fallacy": "Ad_Hominem", "quote": "only lazy people", "explanation": "Attacks remote workers, not the case.", "suggestion": "Argue about output.", "confidence": 0.9},
+	  {"fallacy": "ad_hominem", "quote": "lazy", "explanation": "Repeat.", "confidence": 0.9},
+	  {"fallacy": "slippery_slope", "quote": "never see each other again", "explanation": "Unsupported chain.", "confidence": 0.3},
+	  {"fallacy": "made_up", "quote": "x", "explanation": "Not in the catalog.", "confidence": 1},
+	  {"fallacy": "false_dilemma", "quote": "not something I wrote", "explanation": "Office or nothing."}]}` + "\n```"
+
+	c := NewLLMClassifier(cannedEngine{out: out, sent: &sent})
+	message := "Only lazy people want remote work, and soon we will never see each other again."
+
+	notes, err := c.Classify(context.Background(), "Remote work is better", "Remote work saves time.", message)
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+
+	if len(notes) != 2 {
+		t.Fatalf("expected 2 notes, got %+v", notes)
+	}
+
+	if n := notes[0]; n.Fallacy != "ad_hominem" || n.Name != "Ad hominem" || n.Quote != "only lazy people" || n.Suggestion == "" {
+		t.Fatalf("unexpected first note %+v", n)
+	}
+
+	if n := notes[1]; n.Fallacy != "false_dilemma" || n.Quote != "" {
+		t.Fatalf("expected a quote that isn't in the message to be dropped, got %+v", n)
+	}
+
+	if !strings.Contains(sent[1]["content"], "Opponent's argument:\nRemote work saves time.") {
+		t.Fatalf("expected the opponent's argument in the prompt:\n%s", sent[1]["content"])
+	}
+
+	if !strings.Contains(sent[0]["content"], "strawman (Strawman)") {
+		t.Fatalf("expected the catalog in the system prompt:\n%s", sent[0]["content"])
+	}
+}
+
+func TestClassifyErrors(t *testing.T) {
+	if _, err := NewLLMClassifier(cannedEngine{out: "no json here"}).Classify(context.Background(), "t", "", "m"); err == nil {
+		t.Fatal("expected invalid output to be an error")
+	}
+
+	if _, err := NewLLMClassifier(cannedEngine{err: errors.New("down")}).Classify(context.Background(), "t", "", "m"); err == nil {
+		t.Fatal("expected engine errors to be returned")
+	}
+
+	notes, err := NewLLMClassifier(cannedEngine{out: `{"fallacies": []}`}).Classify(context.Background(), "t", "", "m")
+	if err != nil || len(notes) != 0 {
+		t.Fatalf("expected no notes for a clean message, got %+v, %v", notes, err)
+	}
+}
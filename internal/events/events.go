@@ -0,0 +1,73 @@
+// Package events fans out live updates of conversations to spectators.
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// Event types pushed to subscribers.
+const (
+	TypeReady   = "ready"   // the subscription is live
+	TypeMessage = "message" // a message was added
+	TypePhase   = "phase"   // a structured debate moved to its next phase or finished
+	TypeVerdict = "verdict" // the debate was judged
+	TypeRewind  = "rewind"  // messages after MessageID were removed, e.g. to regenerate or edit a turn
+)
+
+// Event is one update of a conversation.
+type Event struct {
+	Type           string              `json:"type"`
+	ConversationID string              `json:"conversation_id"`
+	Message        *models.Message     `json:"message,omitempty"`
+	Phase          *models.PhaseStatus `json:"phase,omitempty"`
+	Judgement      *models.Judgement   `json:"judgement,omitempty"`
+	MessageID      string              `json:"message_id,omitempty"` // the last message kept by a rewind; empty when none was
+	TS             int64               `json:"ts"`                   // unix ms
+}
+
+// Broker delivers events to the subscribers of a conversation.
+type Broker interface {
+	Publish(ctx context.Context, e Event) error
+	// Subscribe streams the conversation's events until ctx is done, then closes the channel.
+	// Subscribers that fall behind miss events rather than slowing the debate down.
+	Subscribe(ctx context.Context, conversationID string) (<-chan Event, error)
+}
+
+// subscriberBuffer is how many events a subscriber may lag behind before it misses some.
+const subscriberBuffer = 32
+
+// NewMessage returns a message event, with flagged content redacted.
+func NewMessage(conversationID string, m models.Message) Event {
+	redacted := models.RedactFlagged([]models.Message{m})[0]
+
+	return Event{Type: TypeMessage, ConversationID: conversationID, Message: &redacted, TS: time.Now().UnixMilli()}
+}
+
+// NewPhase returns a phase event.
+func NewPhase(conversationID string, status *models.PhaseStatus) Event {
+	return Event{Type: TypePhase, ConversationID: conversationID, Phase: status, TS: time.Now().UnixMilli()}
+}
+
+// NewVerdict returns a verdict event.
+func NewVerdict(conversationID string, judgement *models.Judgement) Event {
+	return Event{Type: TypeVerdict, ConversationID: conversationID, Judgement: judgement, TS: time.Now().UnixMilli()}
+}
+
+// NewRewind returns a rewind event. messageID is the last message kept, or empty when the
+// history was cleared.
+func NewRewind(conversationID, messageID string) Event {
+	return Event{Type: TypeRewind, ConversationID: conversationID, MessageID: messageID, TS: time.Now().UnixMilli()}
+}
+
+// Notify publishes events and logs failures; spectators never hold up a debate.
+func Notify(ctx context.Context, b Broker, events ...Event) {
+	for _, e := range events {
+		if err := b.Publish(ctx, e); err != nil {
+			log.Printf("events: failed to publish %s for %s: %v", e.Type, e.ConversationID, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+	"github.com/nikoremi97/debate/internal/storage"
+)
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case e := <-ch:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+		return Event{}
+	}
+}
+
+func TestMemoryBroker(t *testing.T) {
+	b := NewMemoryBroker()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	watched, err := b.Subscribe(ctx, "c1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	other, _ := b.Subscribe(ctx, "c2")
+
+	Notify(context.Background(), b, NewMessage("c1", models.Message{Role: "user", Message: "Hello"}))
+
+	if e := receive(t, watched); e.Type != TypeMessage || e.Message.Message != "Hello" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	select {
+	case e := <-other:
+		t.Fatalf("events should only reach the conversation's subscribers, got %+v", e)
+	default:
+	}
+
+	cancel()
+
+	select {
+	case _, open := <-watched:
+		if open {
+			t.Fatal("the channel should be closed once the subscriber leaves")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the channel was not closed")
+	}
+}
+
+func TestNewMessageRedactsFlaggedContent(t *testing.T) {
+	e := NewMessage("c1", models.Message{Role: "user", Message: "insult", Moderation: &moderation.Verdict{Allowed: false, Category: "harassment"}})
+
+	if e.Message.Message == "insult" {
+		t.Fatal("flagged content must not reach spectators")
+	}
+}
+
+func TestRedisBrokerIntegration(t *testing.T) {
+	client, err := storage.NewRedisClient("localhost:6379", "")
+	if err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewRedisBroker(client)
+
+	ch, err := b.Subscribe(ctx, "test-events-1")
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	if err := b.Publish(ctx, NewVerdict("test-events-1", &models.Judgement{Winner: "tie"})); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	if e := receive(t, ch); e.Type != TypeVerdict || e.Judgement.Winner != "tie" {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
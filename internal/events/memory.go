@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroker fans out events within this process. Use RedisBroker when several API
+// replicas serve the same debates.
+type MemoryBroker struct {
+	mu   sync.Mutex
+	subs map[string]map[chan Event]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subs: map[string]map[chan Event]struct{}{}}
+}
+
+func (b *MemoryBroker) Publish(_ context.Context, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subs[e.ConversationID] {
+		select {
+		case ch <- e:
+		default: // the subscriber is behind; drop rather than block the publisher
+		}
+	}
+
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context, conversationID string) (<-chan Event, error) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[conversationID] == nil {
+		b.subs[conversationID] = map[chan Event]struct{}{}
+	}
+
+	b.subs[conversationID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		delete(b.subs[conversationID], ch)
+
+		if len(b.subs[conversationID]) == 0 {
+			delete(b.subs, conversationID)
+		}
+
+		close(ch)
+	}()
+
+	return ch, nil
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroker fans out events through Redis pub/sub, so spectators connected to any replica
+// see updates made on the others.
+type RedisBroker struct {
+	c *redis.Client
+}
+
+func NewRedisBroker(client *redis.Client) *RedisBroker {
+	return &RedisBroker{c: client}
+}
+
+func channel(conversationID string) string { return "events:" + conversationID }
+
+func (b *RedisBroker) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return b.c.Publish(ctx, channel(e.ConversationID), payload).Err()
+}
+
+func (b *RedisBroker) Subscribe(ctx context.Context, conversationID string) (<-chan Event, error) {
+	pubsub := b.c.Subscribe(ctx, channel(conversationID))
+
+	// wait for the confirmation so no event published after Subscribe returns is lost
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("subscribe to %s: %w", conversationID, err)
+	}
+
+	out := make(chan Event, subscriberBuffer)
+
+	go func() {
+		defer close(out)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+
+				var e Event
+				if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+					log.Printf("events: dropping malformed event on %s: %v", msg.Channel, err)
+					continue
+				}
+
+				select {
+				case out <- e:
+				default: // the subscriber is behind
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
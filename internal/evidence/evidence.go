@@ -0,0 +1,87 @@
+// Package evidence retrieves passages from a local document corpus so the bot can cite real
+// material instead of inventing it.
+package evidence
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+// Passage is a retrievable piece of a document.
+type Passage struct {
+	ID     string  `json:"id"`              // source#n
+	Topic  string  `json:"topic,omitempty"` // topic slug of the document, empty for general material
+	Source string  `json:"source"`          // document path relative to the corpus
+	Title  string  `json:"title"`           // first heading or file name of the document
+	Text   string  `json:"text"`
+	Score  float64 `json:"score,omitempty"` // relevance to the query that retrieved it
+}
+
+// Retriever finds the passages most relevant to a message in a debate on topic. The BM25
+// Index is the default; an embedding index can stand in for it.
+type Retriever interface {
+	Retrieve(ctx context.Context, topic, query string, k int) ([]Passage, error)
+}
+
+// Prompt numbers passages for the debate prompt, starting at [1].
+func Prompt(passages []Passage) []prompts.Passage {
+	out := make([]prompts.Passage, len(passages))
+	for i, p := range passages {
+		out[i] = prompts.Passage{Marker: i + 1, Source: p.Source, Text: p.Text}
+	}
+
+	return out
+}
+
+var markerPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// Cited returns the passages a reply cites by marker, in marker order.
+func Cited(reply string, passages []Passage) []models.Citation {
+	cited := make([]bool, len(passages))
+
+	for _, m := range markerPattern.FindAllStringSubmatch(reply, -1) {
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(passages) {
+			cited[n-1] = true
+		}
+	}
+
+	var out []models.Citation
+
+	for i, ok := range cited {
+		if ok {
+			p := passages[i]
+			out = append(out, models.Citation{Marker: i + 1, Source: p.Source, Title: p.Title, Excerpt: p.Text})
+		}
+	}
+
+	return out
+}
+
+// Slug maps a topic to the directory name its documents live under, e.g.
+// "Remote work is superior to office work" to "remote-work-is-superior-to-office-work".
+func Slug(topic string) string {
+	var b strings.Builder
+
+	dash := false
+
+	for _, r := range strings.ToLower(topic) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+
+	return b.String()
+}
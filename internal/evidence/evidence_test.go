@@ -0,0 +1,98 @@
+package evidence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDoc(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadAndRetrieve(t *testing.T) {
+	dir := t.TempDir()
+	writeDoc(t, dir, "remote-work/commute.md", "# Commute Survey\n\nThe average commute takes 27 minutes each way.\n\nOffice rents keep rising in big cities.")
+	writeDoc(t, dir, "remote-work/notes.txt", "Remote teams report fewer interruptions and more focus time.")
+	writeDoc(t, dir, "pineapple-pizza/history.md", "The commute of pineapple from Hawaii to Canada created the Hawaiian pizza.")
+	writeDoc(t, dir, "general.md", "Productivity studies rarely measure commute time.")
+	writeDoc(t, dir, "ignored.json", `{"commute": true}`)
+
+	idx, err := Load(dir)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if idx.Len() != 4 {
+		t.Fatalf("expected 4 passages, got %d", idx.Len())
+	}
+
+	hits, err := idx.Retrieve(context.Background(), "Remote Work", "How long is the average commute?", 5)
+	if err != nil {
+		t.Fatalf("retrieve: %v", err)
+	}
+
+	if len(hits) != 2 || hits[0].Source != "remote-work/commute.md" || hits[1].Source != "general.md" {
+		t.Fatalf("expected the topic's commute survey and then the general notes, got %+v", hits)
+	}
+
+	if hits[0].Title != "Commute Survey" || hits[0].ID != "remote-work/commute.md#1" || !strings.Contains(hits[0].Text, "27 minutes") {
+		t.Fatalf("unexpected passage %+v", hits[0])
+	}
+
+	if hits, _ := idx.Retrieve(context.Background(), "Remote work", "commute", 1); len(hits) != 1 {
+		t.Fatalf("expected k to cap the results, got %d", len(hits))
+	}
+
+	if hits, _ := idx.Retrieve(context.Background(), "Remote work", "basketball", 3); len(hits) != 0 {
+		t.Fatalf("expected no passages without a shared term, got %+v", hits)
+	}
+}
+
+func TestChunkLongDocument(t *testing.T) {
+	idx := NewIndex()
+	para := strings.Repeat("word ", 50)
+	idx.Add("", "long.md", para+"\n\n## Section\n\n"+para+"\n\n"+para)
+
+	if idx.Len() != 2 {
+		t.Fatalf("expected the document to be split into 2 passages, got %d", idx.Len())
+	}
+
+	if idx.passages[0].Title != "long" || strings.Contains(idx.passages[0].Text, "#") {
+		t.Fatalf("expected the file name as title and headings without markers, got %+v", idx.passages[0])
+	}
+}
+
+func TestCited(t *testing.T) {
+	passages := []Passage{{Source: "a.md", Text: "A"}, {Source: "b.md", Text: "B"}, {Source: "c.md", Text: "C"}}
+
+	cited := Cited("Commutes are long [3], and focus improves [1][3]. See [7].", passages)
+	if len(cited) != 2 || cited[0].Marker != 1 || cited[1].Source != "c.md" || cited[1].Excerpt != "C" {
+		t.Fatalf("expected passages 1 and 3 once each, got %+v", cited)
+	}
+
+	if cited := Cited("No sources here.", passages); cited != nil {
+		t.Fatalf("expected no citations, got %+v", cited)
+	}
+
+	if prompt := Prompt(passages); prompt[2].Marker != 3 || prompt[2].Source != "c.md" {
+		t.Fatalf("expected markers to follow the passage order, got %+v", prompt)
+	}
+}
+
+func TestSlug(t *testing.T) {
+	if got := Slug("  Remote work is superior to office work!"); got != "remote-work-is-superior-to-office-work" {
+		t.Fatalf("unexpected slug %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package evidence
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BM25 parameters: term-frequency saturation and document-length normalization.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// passageWords is the size passages are cut to; paragraphs are kept whole up to it.
+const passageWords = 80
+
+// Index is an in-memory BM25 index over passages.
+type Index struct {
+	passages []Passage
+	terms    []map[string]int // term frequencies per passage
+	lengths  []int
+	docFreq  map[string]int // passages containing each term
+	totalLen int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{docFreq: map[string]int{}}
+}
+
+// Load indexes the Markdown and text files under dir. Files in a subdirectory belong to the
+// topic the directory is named after (see Slug); files at the top level apply to every topic.
+func Load(dir string) (*Index, error) {
+	idx := NewIndex()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".md", ".markdown", ".txt":
+		default:
+			return nil
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		var topic string
+		if i := strings.IndexByte(rel, '/'); i >= 0 {
+			topic = rel[:i]
+		}
+
+		idx.Add(topic, rel, string(b))
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load evidence: %w", err)
+	}
+
+	return idx, nil
+}
+
+// Add splits a document into passages and indexes them under topic.
+func (idx *Index) Add(topic, source, text string) {
+	title := strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+
+	n := 0
+
+	for _, chunk := range chunk(text) {
+		if chunk.heading != "" {
+			title = strings.TrimSpace(chunk.heading[2:])
+			continue
+		}
+
+		n++
+		p := Passage{ID: fmt.Sprintf("%s#%d", source, n), Topic: topic, Source: source, Title: title, Text: chunk.text}
+
+		tf := map[string]int{}
+		tokens := tokenize(chunk.text)
+
+		for _, t := range tokens {
+			if tf[t] == 0 {
+				idx.docFreq[t]++
+			}
+
+			tf[t]++
+		}
+
+		idx.passages = append(idx.passages, p)
+		idx.terms = append(idx.terms, tf)
+		idx.lengths = append(idx.lengths, len(tokens))
+		idx.totalLen += len(tokens)
+	}
+}
+
+// Len returns the number of indexed passages.
+func (idx *Index) Len() int { return len(idx.passages) }
+
+// Retrieve returns the k passages that best match query among the topic's documents and the
+// general ones. Passages sharing no term with the query are left out.
+func (idx *Index) Retrieve(_ context.Context, topic, query string, k int) ([]Passage, error) {
+	if len(idx.passages) == 0 || k <= 0 {
+		return nil, nil
+	}
+
+	slug := Slug(topic)
+	terms := tokenize(query)
+	avgLen := float64(idx.totalLen) / float64(len(idx.passages))
+
+	var hits []Passage
+
+	for i, p := range idx.passages {
+		if p.Topic != "" && p.Topic != slug {
+			continue
+		}
+
+		score := idx.score(i, terms, avgLen)
+		if score > 0 {
+			p.Score = score
+			hits = append(hits, p)
+		}
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].Score > hits[b].Score })
+
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+
+	return hits, nil
+}
+
+func (idx *Index) score(i int, query []string, avgLen float64) float64 {
+	n := float64(len(idx.passages))
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(idx.lengths[i])/avgLen)
+
+	var score float64
+
+	for _, t := range query {
+		tf := float64(idx.terms[i][t])
+		if tf == 0 {
+			continue
+		}
+
+		df := float64(idx.docFreq[t])
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		score += idf * tf * (bm25K1 + 1) / (tf + norm)
+	}
+
+	return score
+}
+
+type passageChunk struct {
+	heading string // the document's title heading; such chunks carry no text
+	text    string
+}
+
+// chunk groups a document's paragraphs into passages of about passageWords words. Headings
+// are kept with the paragraph that follows them.
+func chunk(text string) []passageChunk {
+	var (
+		chunks  []passageChunk
+		current []string
+		words   int
+	)
+
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, passageChunk{text: strings.Join(current, " ")})
+			current, words = nil, 0
+		}
+	}
+
+	for i, para := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		para = strings.Join(strings.Fields(para), " ")
+		if para == "" {
+			continue
+		}
+
+		if i == 0 && strings.HasPrefix(para, "# ") {
+			chunks = append(chunks, passageChunk{heading: para})
+			continue
+		}
+
+		para = strings.TrimLeft(para, "#> ")
+
+		current = append(current, para)
+		words += len(strings.Fields(para))
+
+		if words >= passageWords {
+			flush()
+		}
+	}
+
+	flush()
+
+	return chunks
+}
+
+// stopwords carry no topical meaning and are left out of the index.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "that": true, "the": true, "their": true, "this": true,
+	"to": true, "was": true, "were": true, "will": true, "with": true, "you": true, "your": true, "we": true,
+	"i": true, "they": true, "not": true, "do": true, "does": true, "than": true, "so": true, "if": true,
+}
+
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := fields[:0]
+
+	for _, f := range fields {
+		if !stopwords[f] {
+			tokens = append(tokens, stem(f))
+		}
+	}
+
+	return tokens
+}
+
+// stem folds plurals so "commutes" matches "commute". Queries and passages go through the
+// same folding, so words it mangles ("focus" to "focu") still match each other.
+func stem(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		return word[:len(word)-1]
+	}
+
+	return word
+}
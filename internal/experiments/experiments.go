@@ -0,0 +1,182 @@
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+// Variant is one arm of an experiment. Empty fields keep the engine defaults.
+type Variant struct {
+	Name        string   `json:"name"`
+	Weight      int      `json:"weight"`
+	Template    string   `json:"template,omitempty"`
+	Model       string   `json:"model,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// Options turns the variant into engine options for Generate.
+func (v Variant) Options() []bot.Option {
+	var opts []bot.Option
+
+	if v.Template != "" {
+		opts = append(opts, bot.WithTemplate(v.Template))
+	}
+
+	if v.Model != "" {
+		opts = append(opts, bot.WithModel(v.Model))
+	}
+
+	if v.Temperature != nil {
+		opts = append(opts, bot.WithTemperature(*v.Temperature))
+	}
+
+	return opts
+}
+
+// Experiment compares weighted variants.
+type Experiment struct {
+	Name     string    `json:"name"`
+	Active   bool      `json:"active"`
+	Variants []Variant `json:"variants"`
+}
+
+// Config is the set of experiments loaded from EXPERIMENTS_PATH.
+type Config struct {
+	Experiments []Experiment `json:"experiments"`
+}
+
+// Load reads a JSON experiments config from disk.
+func Load(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiments config: %w", err)
+	}
+
+	return Parse(b)
+}
+
+// Parse decodes and validates a JSON experiments config.
+func Parse(b []byte) (*Config, error) {
+	var c Config
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse experiments config: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	for _, e := range c.Experiments {
+		if e.Name == "" || seen[e.Name] {
+			return nil, fmt.Errorf("experiment names must be unique and non-empty (got %q)", e.Name)
+		}
+
+		seen[e.Name] = true
+
+		if len(e.Variants) == 0 {
+			return nil, fmt.Errorf("experiment %q has no variants", e.Name)
+		}
+
+		variants := map[string]bool{}
+
+		for _, v := range e.Variants {
+			if v.Name == "" || variants[v.Name] {
+				return nil, fmt.Errorf("experiment %q: variant names must be unique and non-empty (got %q)", e.Name, v.Name)
+			}
+
+			variants[v.Name] = true
+
+			if v.Weight <= 0 {
+				return nil, fmt.Errorf("experiment %q: variant %q needs a positive weight", e.Name, v.Name)
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+// ValidateTemplates checks that every variant template exists in the registry and renders as
+// a debate system prompt, so a broken variant can't fail the turns of the users assigned to it.
+func (c *Config) ValidateTemplates(reg *prompts.Registry) error {
+	for _, e := range c.Experiments {
+		for _, v := range e.Variants {
+			if v.Template == "" {
+				continue
+			}
+
+			if _, ok := reg.Get(v.Template); !ok {
+				return fmt.Errorf("experiment %q: variant %q uses unknown template %q", e.Name, v.Name, v.Template)
+			}
+
+			if err := reg.ValidateAs(v.Template, prompts.DebateSystem); err != nil {
+				return fmt.Errorf("experiment %q: variant %q: %w", e.Name, v.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Assign places a conversation in the first active experiment. Only one experiment runs
+// per conversation so variants never stack their overrides.
+func (c *Config) Assign(conversationID string) (Experiment, Variant, bool) {
+	if c == nil {
+		return Experiment{}, Variant{}, false
+	}
+
+	for _, e := range c.Experiments {
+		if e.Active {
+			return e, e.Pick(conversationID), true
+		}
+	}
+
+	return Experiment{}, Variant{}, false
+}
+
+// Lookup finds a variant by experiment and variant name.
+func (c *Config) Lookup(experiment, variant string) (Variant, bool) {
+	if c == nil {
+		return Variant{}, false
+	}
+
+	for _, e := range c.Experiments {
+		if e.Name != experiment {
+			continue
+		}
+
+		for _, v := range e.Variants {
+			if v.Name == variant {
+				return v, true
+			}
+		}
+	}
+
+	return Variant{}, false
+}
+
+// Pick deterministically maps a conversation ID to a variant by hashing it with the
+// experiment name, so the same conversation always lands in the same bucket.
+func (e Experiment) Pick(conversationID string) Variant {
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	sum := sha256.Sum256([]byte(e.Name + "/" + conversationID))
+	bucket := int(binary.BigEndian.Uint64(sum[:8]) % uint64(total))
+
+	for _, v := range e.Variants {
+		if bucket < v.Weight {
+			return v
+		}
+
+		bucket -= v.Weight
+	}
+
+	return e.Variants[len(e.Variants)-1]
+}
@@ -0,0 +1,124 @@
+package experiments
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+const sample = `{
+  "experiments": [
+    {"name": "persona-v2", "active": true, "variants": [
+      {"name": "control", "weight": 1},
+      {"name": "treatment", "weight": 3, "template": "debate_system", "model": "gpt-4o", "temperature": 0.2}
+    ]}
+  ]
+}`
+
+func TestPickIsSticky(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("01HZCONV%018d", i)
+
+		_, first, _ := cfg.Assign(id)
+		_, second, _ := cfg.Assign(id)
+
+		if first.Name != second.Name {
+			t.Fatalf("assignment for %s is not sticky: %s vs %s", id, first.Name, second.Name)
+		}
+	}
+}
+
+func TestPickFollowsWeights(t *testing.T) {
+	cfg, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	counts := map[string]int{}
+
+	for i := 0; i < 4000; i++ {
+		counts[cfg.Experiments[0].Pick(fmt.Sprintf("conv-%d", i)).Name]++
+	}
+
+	// expect roughly 25% / 75%
+	if counts["control"] < 800 || counts["control"] > 1200 {
+		t.Fatalf("unexpected distribution: %v", counts)
+	}
+}
+
+func TestParseRejectsInvalidConfig(t *testing.T) {
+	cases := map[string]string{
+		"no variants":      `{"experiments":[{"name":"a","variants":[]}]}`,
+		"zero weight":      `{"experiments":[{"name":"a","variants":[{"name":"x","weight":0}]}]}`,
+		"duplicate exp":    `{"experiments":[{"name":"a","variants":[{"name":"x","weight":1}]},{"name":"a","variants":[{"name":"x","weight":1}]}]}`,
+		"duplicate arm":    `{"experiments":[{"name":"a","variants":[{"name":"x","weight":1},{"name":"x","weight":1}]}]}`,
+		"malformed json":   `{`,
+		"missing exp name": `{"experiments":[{"variants":[{"name":"x","weight":1}]}]}`,
+	}
+
+	for name, raw := range cases {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestInactiveAndNilConfigsDoNotAssign(t *testing.T) {
+	var nilCfg *Config
+	if _, _, ok := nilCfg.Assign("x"); ok {
+		t.Fatal("nil config should not assign")
+	}
+
+	cfg, _ := Parse([]byte(`{"experiments":[{"name":"a","active":false,"variants":[{"name":"x","weight":1}]}]}`))
+	if _, _, ok := cfg.Assign("x"); ok {
+		t.Fatal("inactive experiment should not assign")
+	}
+}
+
+func TestVariantOptionsAndTemplates(t *testing.T) {
+	cfg, _ := Parse([]byte(sample))
+
+	v, ok := cfg.Lookup("persona-v2", "treatment")
+	if !ok {
+		t.Fatal("expected to find treatment variant")
+	}
+
+	o := bot.ApplyOptions(v.Options()...)
+	if o.Model != "gpt-4o" || o.Template != "debate_system" || o.Temperature == nil || *o.Temperature != 0.2 {
+		t.Fatalf("unexpected options: %+v", o)
+	}
+
+	if err := cfg.ValidateTemplates(prompts.Default()); err != nil {
+		t.Fatalf("expected templates to validate: %v", err)
+	}
+
+	cfg.Experiments[0].Variants[1].Template = "missing"
+	if err := cfg.ValidateTemplates(prompts.Default()); err == nil {
+		t.Fatal("expected unknown template to be rejected")
+	}
+
+	// a template whose name doesn't announce its role is only checked when a variant uses it
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "persona_test.tmpl"), []byte("Argue {{.Persona}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reg, err := prompts.NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	cfg.Experiments[0].Variants[1].Template = "persona_test"
+	if err := cfg.ValidateTemplates(reg); err == nil {
+		t.Fatal("expected a template that can't render a debate prompt to be rejected")
+	}
+}
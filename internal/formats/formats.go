@@ -0,0 +1,230 @@
+package formats
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+//go:embed formats.json
+var defaultFormatsJSON []byte
+
+// Phase is one stage of a structured debate.
+type Phase struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Instructions string `json:"instructions"` // tells the bot how to behave in this phase
+	Turns        int    `json:"turns"`        // exchanges (user message + bot reply) in the phase
+	WordLimit    int    `json:"word_limit"`   // per message, for both sides; 0 = unlimited
+}
+
+// Format is an ordered list of phases.
+type Format struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Phases      []Phase `json:"phases"`
+}
+
+// Catalog lists the debate formats users can pick from.
+type Catalog struct {
+	Formats []Format `json:"formats"`
+}
+
+// ErrDebateFinished is returned for turns sent after the last phase.
+var ErrDebateFinished = errors.New("the debate is over")
+
+// PhaseError is returned when a message targets another phase than the current one.
+type PhaseError struct {
+	Current   string
+	Requested string
+}
+
+func (e *PhaseError) Error() string {
+	return fmt.Sprintf("out of phase: the debate is in %q, not %q", e.Current, e.Requested)
+}
+
+// WordLimitError is returned when a message is longer than the phase allows.
+type WordLimitError struct {
+	Phase string
+	Limit int
+	Words int
+}
+
+func (e *WordLimitError) Error() string {
+	return fmt.Sprintf("message has %d words but the %s phase allows at most %d", e.Words, e.Phase, e.Limit)
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog *Catalog
+)
+
+// Default returns the embedded formats.
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		c, err := Parse(defaultFormatsJSON)
+		if err != nil {
+			panic("formats: invalid embedded formats: " + err.Error())
+		}
+
+		defaultCatalog = c
+	})
+
+	return defaultCatalog
+}
+
+// Load reads a JSON formats file from disk.
+func Load(path string) (*Catalog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read debate formats: %w", err)
+	}
+
+	return Parse(b)
+}
+
+// Parse decodes and validates a JSON formats file.
+func Parse(b []byte) (*Catalog, error) {
+	var c Catalog
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse debate formats: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	for _, f := range c.Formats {
+		if f.ID == "" || seen[f.ID] {
+			return nil, fmt.Errorf("format ids must be unique and non-empty (got %q)", f.ID)
+		}
+
+		seen[f.ID] = true
+
+		if len(f.Phases) == 0 {
+			return nil, fmt.Errorf("format %q has no phases", f.ID)
+		}
+
+		for _, p := range f.Phases {
+			if p.ID == "" || p.Turns <= 0 || p.WordLimit < 0 {
+				return nil, fmt.Errorf("format %q: phase %q needs an id, positive turns and a non-negative word limit", f.ID, p.ID)
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+// Format looks up a format by ID.
+func (c *Catalog) Format(id string) (Format, bool) {
+	for _, f := range c.Formats {
+		if f.ID == id {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// Start returns the state of a debate at the beginning of its first phase.
+func (f Format) Start() *models.DebateState {
+	return &models.DebateState{Format: f.ID}
+}
+
+// Current returns the phase the debate is in; after the last phase it stays on the last one.
+func (f Format) Current(s *models.DebateState) Phase {
+	return f.Phases[min(s.Phase, len(f.Phases)-1)]
+}
+
+// CheckTurn validates a user message against the current phase. phase may be empty when the
+// client doesn't say which phase it is speaking in.
+func (f Format) CheckTurn(s *models.DebateState, phase, message string) error {
+	if s.Finished {
+		return ErrDebateFinished
+	}
+
+	current := f.Current(s)
+
+	if phase != "" && phase != current.ID {
+		return &PhaseError{Current: current.ID, Requested: phase}
+	}
+
+	if words := len(strings.Fields(message)); current.WordLimit > 0 && words > current.WordLimit {
+		return &WordLimitError{Phase: current.ID, Limit: current.WordLimit, Words: words}
+	}
+
+	return nil
+}
+
+// CompleteTurn counts a finished exchange and moves to the next phase when the current one
+// is used up. It returns true when the phase changed or the debate ended.
+func (f Format) CompleteTurn(s *models.DebateState) bool {
+	s.Turns++
+
+	if s.Turns < f.Current(s).Turns {
+		return false
+	}
+
+	s.Turns = 0
+
+	if s.Phase == len(f.Phases)-1 {
+		s.Finished = true
+	} else {
+		s.Phase++
+	}
+
+	return true
+}
+
+// Status describes the debate's progress for API responses.
+func (f Format) Status(s *models.DebateState) *models.PhaseStatus {
+	current := f.Current(s)
+
+	status := &models.PhaseStatus{
+		Format:         f.ID,
+		Phase:          current.ID,
+		PhaseName:      current.Name,
+		Index:          s.Phase,
+		Total:          len(f.Phases),
+		TurnsRemaining: current.Turns - s.Turns,
+		WordLimit:      current.WordLimit,
+		Finished:       s.Finished,
+	}
+
+	if s.Finished {
+		status.TurnsRemaining = 0
+	}
+
+	return status
+}
+
+// Announcement describes the debate's new phase, for the history after a phase change.
+func (f Format) Announcement(s *models.DebateState) string {
+	if s.Finished {
+		return "The debate is over: all phases of the " + f.Name + " format are complete."
+	}
+
+	p := f.Current(s)
+	text := fmt.Sprintf("Phase changed: %s (%d turn(s)", p.Name, p.Turns)
+
+	if p.WordLimit > 0 {
+		text += fmt.Sprintf(", at most %d words per message", p.WordLimit)
+	}
+
+	return text + ")."
+}
+
+// Options returns the engine options that make the bot follow the current phase.
+func (f Format) Options(s *models.DebateState) []bot.Option {
+	p := f.Current(s)
+
+	return []bot.Option{bot.WithPhase(prompts.Phase{Name: p.Name, Instructions: p.Instructions, WordLimit: p.WordLimit})}
+}
@@ -0,0 +1,103 @@
+package formats
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+)
+
+const twoPhases = `{"formats":[{"id":"mini","name":"Mini","phases":[
+  {"id":"opening","name":"Opening","instructions":"Open.","turns":1,"word_limit":5},
+  {"id":"closing","name":"Closing","instructions":"Close.","turns":2}
+]}]}`
+
+func TestPhaseTransitions(t *testing.T) {
+	c, err := Parse([]byte(twoPhases))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	f, _ := c.Format("mini")
+	s := f.Start()
+
+	if f.Current(s).ID != "opening" {
+		t.Fatalf("expected to start in the opening, got %s", f.Current(s).ID)
+	}
+
+	if !f.CompleteTurn(s) || f.Current(s).ID != "closing" {
+		t.Fatalf("expected to move to the closing after one turn, got %+v", s)
+	}
+
+	if f.CompleteTurn(s) {
+		t.Fatal("the closing has two turns")
+	}
+
+	if st := f.Status(s); st.TurnsRemaining != 1 || st.Index != 1 || st.Total != 2 {
+		t.Fatalf("unexpected status %+v", st)
+	}
+
+	if !f.CompleteTurn(s) || !s.Finished {
+		t.Fatalf("expected the debate to finish, got %+v", s)
+	}
+
+	if err := f.CheckTurn(s, "", "More"); !errors.Is(err, ErrDebateFinished) {
+		t.Fatalf("expected ErrDebateFinished, got %v", err)
+	}
+
+	if !strings.Contains(f.Announcement(s), "over") {
+		t.Fatalf("unexpected announcement %q", f.Announcement(s))
+	}
+}
+
+func TestCheckTurn(t *testing.T) {
+	c, _ := Parse([]byte(twoPhases))
+	f, _ := c.Format("mini")
+	s := f.Start()
+
+	var phaseErr *PhaseError
+	if err := f.CheckTurn(s, "closing", "Hi"); !errors.As(err, &phaseErr) || phaseErr.Current != "opening" {
+		t.Fatalf("expected a phase error, got %v", err)
+	}
+
+	var limitErr *WordLimitError
+	if err := f.CheckTurn(s, "opening", "one two three four five six"); !errors.As(err, &limitErr) || limitErr.Limit != 5 {
+		t.Fatalf("expected a word limit error, got %v", err)
+	}
+
+	if err := f.CheckTurn(s, "opening", "one two three"); err != nil {
+		t.Fatalf("expected the turn to fit, got %v", err)
+	}
+}
+
+func TestOptionsCarryPhase(t *testing.T) {
+	f, ok := Default().Format("oxford")
+	if !ok {
+		t.Fatal("expected the oxford format")
+	}
+
+	o := bot.ApplyOptions(f.Options(f.Start())...)
+	if o.Phase.Name != "Opening statements" || o.Phase.WordLimit != 300 || o.Phase.Instructions == "" {
+		t.Fatalf("unexpected phase options %+v", o.Phase)
+	}
+
+	if _, ok := Default().Format("lincoln_douglas"); !ok {
+		t.Fatal("expected the lincoln_douglas format")
+	}
+}
+
+func TestParseRejectsInvalidFormats(t *testing.T) {
+	cases := map[string]string{
+		"malformed":  `{`,
+		"no phases":  `{"formats":[{"id":"a","phases":[]}]}`,
+		"zero turns": `{"formats":[{"id":"a","phases":[{"id":"p","turns":0}]}]}`,
+		"duplicate":  `{"formats":[{"id":"a","phases":[{"id":"p","turns":1}]},{"id":"a","phases":[{"id":"p","turns":1}]}]}`,
+	}
+
+	for name, raw := range cases {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package judge
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+//go:embed rubric.json
+var defaultRubricJSON []byte
+
+// Winners a judgement can declare.
+const (
+	WinnerUser = "user"
+	WinnerBot  = "bot"
+	WinnerTie  = "tie"
+)
+
+// sides names the two parties of a debate as they appear in the transcript and the verdict:
+// the user and the bot, or the PRO and CON debaters of a human debate.
+func sides(conv *models.Conversation) (string, string) {
+	if conv.Mode == models.ModeHuman {
+		return models.RolePro, models.RoleCon
+	}
+
+	return WinnerUser, WinnerBot
+}
+
+// ErrNothingToJudge is returned for conversations without a single exchange.
+var ErrNothingToJudge = errors.New("the debate has no turns to judge yet")
+
+// Criterion is one scored dimension of the rubric.
+type Criterion struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MaxScore    int    `json:"max_score"`
+}
+
+// Rubric configures what the judge scores. Fallacies, the winner and per-turn feedback are
+// always part of the verdict.
+type Rubric struct {
+	Criteria []Criterion `json:"criteria"`
+	Guidance string      `json:"guidance"` // extra instructions for the judge
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultRubric *Rubric
+)
+
+// DefaultRubric returns the embedded rubric.
+func DefaultRubric() *Rubric {
+	defaultOnce.Do(func() {
+		r, err := ParseRubric(defaultRubricJSON)
+		if err != nil {
+			panic("judge: invalid embedded rubric: " + err.Error())
+		}
+
+		defaultRubric = r
+	})
+
+	return defaultRubric
+}
+
+// LoadRubric reads a JSON rubric from disk.
+func LoadRubric(path string) (*Rubric, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read judge rubric: %w", err)
+	}
+
+	return ParseRubric(b)
+}
+
+// ParseRubric decodes and validates a JSON rubric.
+func ParseRubric(b []byte) (*Rubric, error) {
+	var r Rubric
+
+	if err := json.Unmarshal(b, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse judge rubric: %w", err)
+	}
+
+	if len(r.Criteria) == 0 {
+		return nil, errors.New("judge rubric needs at least one criterion")
+	}
+
+	seen := map[string]bool{}
+
+	for _, c := range r.Criteria {
+		if c.ID == "" || seen[c.ID] {
+			return nil, fmt.Errorf("criterion ids must be unique and non-empty (got %q)", c.ID)
+		}
+
+		seen[c.ID] = true
+
+		if c.MaxScore <= 0 {
+			return nil, fmt.Errorf("criterion %q needs a positive max_score", c.ID)
+		}
+	}
+
+	return &r, nil
+}
+
+// Judge scores finished debates with a separate judging prompt.
+type Judge struct {
+	engine bot.Engine
+	rubric *Rubric
+	opts   []bot.Option
+}
+
+// New returns a judge using rubric (the embedded one when nil). opts tune the judging call,
+// e.g. a stronger model.
+func New(engine bot.Engine, rubric *Rubric, opts ...bot.Option) *Judge {
+	if rubric == nil {
+		rubric = DefaultRubric()
+	}
+
+	return &Judge{engine: engine, rubric: rubric, opts: opts}
+}
+
+// Rubric returns the rubric the judge scores against.
+func (j *Judge) Rubric() *Rubric { return j.rubric }
+
+// Judge scores the conversation and declares a winner.
+func (j *Judge) Judge(ctx context.Context, conv *models.Conversation) (*models.Judgement, error) {
+	transcript, turns := buildTranscript(conv)
+	if turns == 0 {
+		return nil, ErrNothingToJudge
+	}
+
+	var info bot.ReplyInfo
+
+	opts := append([]bot.Option{bot.WithJSON(), bot.WithTemperature(0), bot.WithMaxTokens(1500)}, j.opts...)
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	out, err := j.engine.Complete(ctx, []map[string]string{
+		{"role": "system", "content": j.systemPrompt(conv)},
+		{"role": "user", "content": transcript},
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("judge: %w", err)
+	}
+
+	judgement, err := j.parse(out, turns, conv)
+	if err != nil {
+		return nil, err
+	}
+
+	judgement.Model = info.Model
+	judgement.JudgedAt = time.Now().UnixMilli()
+
+	return judgement, nil
+}
+
+func (j *Judge) systemPrompt(conv *models.Conversation) string {
+	var b strings.Builder
+
+	first, second := sides(conv)
+
+	b.WriteString("You are an impartial debate judge. Topic: " + conv.Topic + ".\n")
+
+	if conv.Mode == models.ModeHuman {
+		b.WriteString("The transcript lists numbered turns by the PRO and CON debaters.\n\n")
+	} else {
+		b.WriteString("The transcript lists numbered turns by USER and BOT. The user argued " + conv.UserStance +
+			" and the bot argued " + conv.Stance + ", unless a system note says the sides were switched.\n\n")
+	}
+
+	// the JSON field names follow the sides, e.g. {"user": 7, "bot": 5} or {"pro": 7, "con": 5}
+	pair := fmt.Sprintf(`"%s" | "%s"`, first, second)
+
+	b.WriteString("Score each side from 0 to the maximum on these criteria:\n")
+
+	for _, c := range j.rubric.Criteria {
+		fmt.Fprintf(&b, "- %s (max %d): %s\n", c.ID, c.MaxScore, c.Description)
+	}
+
+	if j.rubric.Guidance != "" {
+		b.WriteString("\n" + j.rubric.Guidance + "\n")
+	}
+
+	b.WriteString(`
+Also list the logical fallacies either side committed, and give one line of feedback per turn.
+Reply with a JSON object only:
+{"winner": ` + pair + ` | "tie", "summary": string,
+ "scores": [{"criterion": id, "` + first + `": number, "` + second + `": number, "comment": string}],
+ "fallacies": [{"turn": number, "side": ` + pair + `, "fallacy": string, "explanation": string}],
+ "turn_feedback": [{"turn": number, "side": ` + pair + `, "feedback": string}]}`)
+
+	return b.String()
+}
+
+// buildTranscript numbers the debaters' turns the judge sees. Flagged turns, moderation notices
+// and the moderator's reviews are left out; side switches and phase changes stay in as context.
+func buildTranscript(conv *models.Conversation) (string, int) {
+	var b strings.Builder
+
+	turns := 0
+
+	for _, m := range conv.Messages {
+		if m.Flagged() || m.Event == models.EventModeration || m.Role == models.RoleModerator {
+			continue
+		}
+
+		if m.Role == "system" {
+			b.WriteString("(system note: " + m.Message + ")\n")
+			continue
+		}
+
+		turns++
+		b.WriteString("[" + strconv.Itoa(turns) + "] " + strings.ToUpper(m.Role) + ": " + m.Message + "\n")
+	}
+
+	return b.String(), turns
+}
+
+// parse validates the judge's JSON against the rubric. Scores are clamped to each criterion's
+// range and notes about turns that don't exist are dropped.
+func (j *Judge) parse(out string, turns int, conv *models.Conversation) (*models.Judgement, error) {
+	var raw struct {
+		models.Judgement
+		Scores []map[string]any `json:"scores"` // {"criterion": id, "<side>": score, "comment": string}
+	}
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &raw); err != nil {
+		return nil, fmt.Errorf("invalid judgement: %w", err)
+	}
+
+	judgement := raw.Judgement
+	first, second := sides(conv)
+
+	judgement.Winner = strings.ToLower(strings.TrimSpace(judgement.Winner))
+	switch judgement.Winner {
+	case first, second, WinnerTie:
+	default:
+		return nil, fmt.Errorf("invalid winner %q in judgement", judgement.Winner)
+	}
+
+	scores := make([]models.CriterionScore, 0, len(j.rubric.Criteria))
+
+	for _, c := range j.rubric.Criteria {
+		for _, s := range raw.Scores {
+			if s["criterion"] != c.ID {
+				continue
+			}
+
+			score := models.CriterionScore{Criterion: c.ID, Max: c.MaxScore, Scores: map[string]float64{}}
+			score.Comment, _ = s["comment"].(string)
+
+			for _, side := range []string{first, second} {
+				value, _ := s[side].(float64)
+				score.Scores[side] = clamp(value, c.MaxScore)
+			}
+
+			scores = append(scores, score)
+
+			break
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil, errors.New("invalid judgement: no rubric criteria were scored")
+	}
+
+	judgement.Scores = scores
+
+	fallacies := judgement.Fallacies[:0]
+
+	for _, f := range judgement.Fallacies {
+		if f.Turn >= 1 && f.Turn <= turns {
+			fallacies = append(fallacies, f)
+		}
+	}
+
+	judgement.Fallacies = fallacies
+
+	feedback := judgement.TurnFeedback[:0]
+
+	for _, f := range judgement.TurnFeedback {
+		if f.Turn >= 1 && f.Turn <= turns {
+			feedback = append(feedback, f)
+		}
+	}
+
+	judgement.TurnFeedback = feedback
+
+	return &judgement, nil
+}
+
+func clamp(score float64, maxScore int) float64 {
+	return min(max(score, 0), float64(maxScore))
+}
@@ -0,0 +1,150 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+)
+
+// cannedEngine returns out from Complete and records the messages it was sent.
+type cannedEngine struct {
+	out  string
+	sent *[]map[string]string
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, _ ...bot.Option) (string, error) {
+	if e.sent != nil {
+		*e.sent = messages
+	}
+
+	return e.out, nil
+}
+
+func debate() *models.Conversation {
+	conv := models.NewConversation("c1")
+	conv.Topic = "Tabs are better than spaces"
+	conv.SetSides(models.StancePro)
+	conv.Append(models.Message{Role: "user", Message: "Spaces render the same everywhere."})
+	conv.Append(models.Message{Role: "bot", Message: "Tabs let every reader pick their width."})
+	conv.Append(models.Message{Role: "user", Message: "secret insult", Moderation: &moderation.Verdict{Allowed: false}})
+	conv.Append(models.Message{Role: "user", Message: "Everyone who uses tabs is lazy."})
+	conv.Append(models.Message{Role: "bot", Message: "That attacks people, not the argument."})
+
+	return conv
+}
+
+func TestJudge(t *testing.T) {
+	var sent []map[string]string
+
+	out := "```json\n" + `{"winner": "BOT", "summary": "The bot engaged the arguments.",
+	 "scores": [{"criterion": "argument_strength", "user": 4, "bot": 12},
+	            {"criterion": "made_up", "user": 1, "bot": 1}],
+	 "fallacies": [{"turn": 3, "side": "user", "fallacy": "ad hominem", "explanation": "Attacks tab users."},
+	               {"turn": 9, "side": "bot", "fallacy": "strawman", "explanation": "No such turn."}],
+	 "turn_feedback": [{"turn": 1, "side": "user", "feedback": "Good start."}]}` + "\n```"
+
+	j := New(cannedEngine{out: out, sent: &sent}, nil)
+
+	judgement, err := j.Judge(context.Background(), debate())
+	if err != nil {
+		t.Fatalf("judge: %v", err)
+	}
+
+	if judgement.Winner != WinnerBot || judgement.JudgedAt == 0 {
+		t.Fatalf("unexpected judgement %+v", judgement)
+	}
+
+	if len(judgement.Scores) != 1 || judgement.Scores[0].Scores["bot"] != 10 || judgement.Scores[0].Max != 10 {
+		t.Fatalf("expected scores limited to the rubric and clamped, got %+v", judgement.Scores)
+	}
+
+	if len(judgement.Fallacies) != 1 || judgement.Fallacies[0].Fallacy != "ad hominem" {
+		t.Fatalf("expected fallacies on unknown turns to be dropped, got %+v", judgement.Fallacies)
+	}
+
+	transcript := sent[1]["content"]
+	if strings.Contains(transcript, "secret insult") || !strings.Contains(transcript, "[4] BOT") {
+		t.Fatalf("flagged turns should be left out of the numbered transcript:\n%s", transcript)
+	}
+
+	if !strings.Contains(sent[0]["content"], "evidence_use (max 10)") {
+		t.Fatalf("the rubric should be part of the judging prompt:\n%s", sent[0]["content"])
+	}
+}
+
+func TestJudgeErrors(t *testing.T) {
+	if _, err := New(cannedEngine{out: "{}"}, nil).Judge(context.Background(), models.NewConversation("empty")); !errors.Is(err, ErrNothingToJudge) {
+		t.Fatalf("expected ErrNothingToJudge, got %v", err)
+	}
+
+	bad := map[string]string{
+		"not json":       "I think the bot won.",
+		"unknown winner": `{"winner": "audience", "scores": [{"criterion": "evidence_use", "user": 1, "bot": 2}]}`,
+		"no scores":      `{"winner": "tie", "scores": []}`,
+	}
+
+	for name, out := range bad {
+		if _, err := New(cannedEngine{out: out}, nil).Judge(context.Background(), debate()); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestParseRubric(t *testing.T) {
+	if len(DefaultRubric().Criteria) != 3 {
+		t.Fatalf("expected three default criteria, got %+v", DefaultRubric().Criteria)
+	}
+
+	cases := map[string]string{
+		"malformed":   `{`,
+		"no criteria": `{"criteria": []}`,
+		"zero max":    `{"criteria": [{"id": "a", "max_score": 0}]}`,
+		"duplicate":   `{"criteria": [{"id": "a", "max_score": 5}, {"id": "a", "max_score": 5}]}`,
+	}
+
+	for name, raw := range cases {
+		if _, err := ParseRubric([]byte(raw)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestJudgeHumanDebate(t *testing.T) {
+	conv := models.NewConversation("c2")
+	conv.Topic = "Tabs are better than spaces"
+	conv.Mode = models.ModeHuman
+	conv.Append(models.Message{Role: models.RolePro, Message: "Tabs are accessible."})
+	conv.Append(models.Message{Role: models.RoleCon, Message: "Spaces never break alignment."})
+	conv.Append(models.Message{Role: models.RoleModerator, Message: "Round 1 complete."})
+
+	var sent []map[string]string
+
+	out := `{"winner": "con", "summary": "CON was more concrete.",
+	 "scores": [{"criterion": "argument_strength", "pro": 5, "con": 7}]}`
+
+	judgement, err := New(cannedEngine{out: out, sent: &sent}, nil).Judge(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("judge: %v", err)
+	}
+
+	if judgement.Winner != models.RoleCon || judgement.Scores[0].Scores["con"] != 7 || judgement.Scores[0].Scores["pro"] != 5 {
+		t.Fatalf("expected scores keyed by pro/con, got %+v", judgement)
+	}
+
+	if transcript := sent[1]["content"]; strings.Contains(transcript, "Round 1 complete") || !strings.Contains(transcript, "[2] CON") {
+		t.Fatalf("the moderator's reviews should be left out of the transcript:\n%s", transcript)
+	}
+
+	if _, err := New(cannedEngine{out: `{"winner": "bot", "scores": [{"criterion": "evidence_use", "pro": 1, "con": 2}]}`}, nil).Judge(context.Background(), conv); err == nil {
+		t.Fatal("the bot can't win a human debate")
+	}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// ModeHuman marks a debate between two users, moderated by the bot.
+const ModeHuman = "human"
+
+// Roles of messages in human debates.
+const (
+	RolePro       = "pro"       // the PRO debater
+	RoleCon       = "con"       // the CON debater
+	RoleModerator = "moderator" // the bot's round reviews
+)
+
+// EventDebateOver marks the end of a human debate's planned rounds.
+const EventDebateOver = "debate_over"
+
+// Participant is a user taking a side in a human debate.
+type Participant struct {
+	UserID   string `json:"user_id"`
+	Stance   string `json:"stance"`
+	JoinedAt int64  `json:"joined_at"` // unix ms
+}
+
+// RoundReport is the moderator's review of one round.
+type RoundReport struct {
+	Round     int         `json:"round"`
+	Summary   string      `json:"summary"`
+	OffTopic  []RoundNote `json:"off_topic,omitempty"`
+	Fallacies []RoundNote `json:"fallacies,omitempty"`
+}
+
+// RoundNote flags something one side did during a round.
+type RoundNote struct {
+	Side        string `json:"side"` // "pro" | "con"
+	Fallacy     string `json:"fallacy,omitempty"`
+	Explanation string `json:"explanation"`
+}
+
+// RoleForStance returns the message role of the debater arguing stance.
+func RoleForStance(stance string) string {
+	return strings.ToLower(stance)
+}
+
+// Participant returns the user's membership in the debate.
+func (c *Conversation) Participant(userID string) (Participant, bool) {
+	for _, p := range c.Participants {
+		if p.UserID == userID {
+			return p, true
+		}
+	}
+
+	return Participant{}, false
+}
+
+// Join adds a user to the debate on the given side.
+func (c *Conversation) Join(userID, stance string) {
+	c.Participants = append(c.Participants, Participant{UserID: userID, Stance: stance, JoinedAt: time.Now().UnixMilli()})
+}
+
+// NextSpeaker returns the stance whose turn it is: PRO opens and the sides alternate.
+// Flagged messages don't use up a turn.
+func (c *Conversation) NextSpeaker() string {
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		m := c.Messages[i]
+		if m.Flagged() {
+			continue
+		}
+
+		switch m.Role {
+		case RolePro:
+			return StanceCon
+		case RoleCon:
+			return StancePro
+		}
+	}
+
+	return StancePro
+}
+
+// CompletedRounds counts finished rounds of a human debate; a round ends with CON's turn.
+func (c *Conversation) CompletedRounds() int {
+	rounds := 0
+
+	for _, m := range c.Messages {
+		if m.Role == RoleCon && !m.Flagged() {
+			rounds++
+		}
+	}
+
+	return rounds
+}
+
+// CanRead reports whether userID may read the conversation. Debates against the bot are open
+// to any authenticated client; human debates only to their participants and spectators.
+func (c *Conversation) CanRead(userID string) bool {
+	if c.Mode != ModeHuman {
+		return true
+	}
+
+	if _, ok := c.Participant(userID); ok {
+		return true
+	}
+
+	return userID != "" && slices.Contains(c.Spectators, userID)
+}
+
+// HumanDebateOver reports whether a human debate has played all its planned rounds.
+func (c *Conversation) HumanDebateOver() bool {
+	return c.Rounds > 0 && c.CompletedRounds() >= c.Rounds
+}
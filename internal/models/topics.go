@@ -0,0 +1,57 @@
+package models
+
+// Topic is an entry of the debate topic catalog.
+type Topic struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Category    string `json:"category,omitempty"` // e.g. "technology", "politics"
+	Active      bool   `json:"active"`             // inactive topics are kept but never offered
+}
+
+// TopicSuggestion is the catalog topic a free-text topic was matched to.
+type TopicSuggestion struct {
+	TopicID    string  `json:"topic_id"`
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"` // 0-1
+}
+
+// TopicCategory is a category of the topic catalog with how many topics it holds.
+type TopicCategory struct {
+	Name   string `json:"name"`
+	Topics int    `json:"topics"` // active topics
+}
+
+// Brief is a prep case file for a topic: the strongest arguments on both sides, the
+// rebuttals to expect, key definitions and evidence to read.
+type Brief struct {
+	TopicID       string          `json:"topic_id"`
+	Topic         string          `json:"topic"`
+	Pro           []BriefArgument `json:"pro"`
+	Con           []BriefArgument `json:"con"`
+	Definitions   []Definition    `json:"definitions"`
+	Evidence      []Citation      `json:"evidence"`       // corpus passages the arguments cite by marker
+	PromptVersion string          `json:"prompt_version"` // name@hash of the brief template
+	Model         string          `json:"model,omitempty"`
+	GeneratedAt   int64           `json:"generated_at"` // unix ms
+}
+
+// BriefArgument is one argument of a side with the rebuttals the other side is likely to raise.
+type BriefArgument struct {
+	Claim     string     `json:"claim"`
+	Reasoning string     `json:"reasoning"`
+	Evidence  []int      `json:"evidence,omitempty"` // markers of the supporting passages
+	Rebuttals []Rebuttal `json:"rebuttals"`
+}
+
+// Rebuttal is an expected objection to an argument and how to answer it.
+type Rebuttal struct {
+	Objection string `json:"objection"`
+	Response  string `json:"response"`
+}
+
+// Definition pins down a term the debate hinges on.
+type Definition struct {
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+}
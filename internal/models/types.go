@@ -1,12 +1,50 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/moderation"
+
+	"github.com/oklog/ulid/v2"
+)
+
+// Stances a side can take in a debate.
+const (
+	StancePro = "PRO"
+	StanceCon = "CON"
+)
+
+// System message events.
+const (
+	EventSideSwitch  = "side_switch"  // the sides were swapped
+	EventModeration  = "moderation"   // a turn was withheld by moderation
+	EventPhaseChange = "phase_change" // a structured debate moved to its next phase
+)
+
+// OppositeStance returns the other side of the debate.
+func OppositeStance(stance string) string {
+	if stance == StancePro {
+		return StanceCon
+	}
+
+	return StancePro
+}
 
 // ChatRequest is the incoming API payload.
 type ChatRequest struct {
-	ConversationID *string `json:"conversation_id"`
-	Message        string  `json:"message"` // text
-	Topic          *string `json:"topic"`   // optional user-provided topic
+	ConversationID   *string `json:"conversation_id"`
+	Message          string  `json:"message"`            // text
+	Topic            *string `json:"topic"`              // optional user-provided topic
+	TopicID          *string `json:"topic_id"`           // optional catalog topic for a new debate, see GET /topics
+	Category         *string `json:"category"`           // optional; pick a random catalog topic of this category
+	UserStance       *string `json:"user_stance"`        // optional PRO | CON | RANDOM
+	SwitchSidesEvery *int    `json:"switch_sides_every"` // optional; swap sides after N rounds (0 disables)
+	Persona          *string `json:"persona"`            // optional persona ID, see GET /personas
+	Difficulty       *string `json:"difficulty"`         // optional difficulty ID, see GET /personas
+	Format           *string `json:"format"`             // optional debate format ID for a new debate, see GET /formats
+	Phase            *string `json:"phase"`              // optional; the phase this message is meant for
+	Coach            *bool   `json:"coach"`              // optional; turns coach mode on or off for the debate
 }
 
 // ChatResponse is the outgoing API payload.
@@ -15,21 +53,293 @@ type ChatResponse struct {
 	Messages       []Message `json:"message"`
 	Topic          string    `json:"topic,omitempty"`
 	Stance         string    `json:"stance,omitempty"`
+	UserStance     string    `json:"user_stance,omitempty"`
+	Persona        string    `json:"persona,omitempty"`
+	Difficulty     string    `json:"difficulty,omitempty"`
+
+	// Phase reports the progress of a structured debate.
+	Phase *PhaseStatus `json:"phase,omitempty"`
+
+	// Participants and NextSpeaker describe a human-vs-human debate.
+	Participants []Participant `json:"participants,omitempty"`
+	NextSpeaker  string        `json:"next_speaker,omitempty"` // stance whose turn it is
+
+	// Moderation is set when this turn was withheld by moderation; the flagged
+	// content is replaced by a policy message in Messages.
+	Moderation *moderation.Verdict `json:"moderation,omitempty"`
+
+	// Citations are the evidence passages the new bot reply cites.
+	Citations []Citation `json:"citations,omitempty"`
+
+	// Fallacies is the coaching feedback on the user message the bot replied to, in coach mode.
+	Fallacies []FallacyAnnotation `json:"fallacies,omitempty"`
+
+	// StanceConfirmation is set instead of a reply when the user's side couldn't be
+	// determined confidently; the client should resend with user_stance.
+	StanceConfirmation *StanceConfirmation `json:"stance_confirmation,omitempty"`
+
+	// SuggestedTopic is the catalog topic the free-text topic of a new debate was matched to.
+	// The debate is linked to it and counts toward it in the topic stats.
+	SuggestedTopic *TopicSuggestion `json:"suggested_topic,omitempty"`
+}
+
+// PhaseStatus is the progress of a structured debate.
+type PhaseStatus struct {
+	Format         string `json:"format"`
+	Phase          string `json:"phase"`
+	PhaseName      string `json:"phase_name"`
+	Index          int    `json:"index"` // zero-based position of the phase
+	Total          int    `json:"total"` // number of phases in the format
+	TurnsRemaining int    `json:"turns_remaining"`
+	WordLimit      int    `json:"word_limit,omitempty"`
+	Finished       bool   `json:"finished"`
+}
+
+// StanceConfirmation asks the user to confirm which side they are arguing.
+type StanceConfirmation struct {
+	SuggestedStance string  `json:"suggested_stance,omitempty"` // best guess, empty if unclear
+	Confidence      float64 `json:"confidence"`
+	Prompt          string  `json:"prompt"`
 }
 
 // Message is a single turn.
 type Message struct {
-	Role    string `json:"role"` // "user" | "bot"
+	ID      string `json:"id,omitempty"` // stable ULID, assigned by Append
+	Role    string `json:"role"`         // "user" | "bot" | "system", or "pro" | "con" | "moderator" in human debates
 	Message string `json:"message"`
-	TS      int64  `json:"ts"` // unix ms (for ordering if needed)
+	Author  string `json:"author,omitempty"` // user ID of a human debater
+	Event   string `json:"event,omitempty"`  // set on system messages, e.g. "side_switch"
+	TS      int64  `json:"ts"`               // unix ms (for ordering if needed)
+
+	// PromptVersion is the name@hash of the system prompt template behind a bot reply.
+	PromptVersion string `json:"prompt_version,omitempty"`
+
+	// Citations are the evidence passages a bot reply cites by marker.
+	Citations []Citation `json:"citations,omitempty"`
+
+	// ToolCalls are the tools the bot called on the way to this reply, kept for auditing.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Fallacies are the coach's notes on a user message in coach mode.
+	Fallacies []FallacyAnnotation `json:"fallacies,omitempty"`
+
+	// Report is the moderator's review of a finished round in a human debate.
+	Report *RoundReport `json:"report,omitempty"`
+
+	// Moderation holds the verdict of a flagged turn. The original content is kept for
+	// auditing but never sent to the model or back to clients.
+	Moderation *moderation.Verdict `json:"moderation,omitempty"`
+}
+
+// Citation is a source a bot reply cites, e.g. "[1]".
+type Citation struct {
+	Marker  int    `json:"marker"`
+	Source  string `json:"source"` // document path in the evidence corpus
+	Title   string `json:"title,omitempty"`
+	Excerpt string `json:"excerpt"` // the passage the bot was given
+}
+
+// ToolCall records a tool the bot called and what it got back.
+type ToolCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON, as the model sent them
+	Result    string `json:"result,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Flagged reports whether moderation withheld this message.
+func (m Message) Flagged() bool {
+	return m.Moderation != nil && !m.Moderation.Allowed
+}
+
+// RedactFlagged returns a copy of msgs with flagged content replaced by a policy notice.
+func RedactFlagged(msgs []Message) []Message {
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		if m.Flagged() {
+			m.Message = "[removed by moderation: " + m.Moderation.Category + "]"
+		}
+
+		out[i] = m
+	}
+
+	return out
 }
 
 // Conversation state stored in the DB.
 type Conversation struct {
-	ID       string    `json:"id"`
-	Topic    string    `json:"topic"`
-	Stance   string    `json:"stance"` // e.g., PRO/CON
-	Messages []Message `json:"messages"`
+	ID          string    `json:"id"`
+	Topic       string    `json:"topic"`
+	TopicID     string    `json:"topic_id,omitempty"`     // catalog topic, empty for free-text topics
+	Stance      string    `json:"stance"`                 // bot side, e.g., PRO/CON
+	UserStance  string    `json:"user_stance,omitempty"`  // user side, always opposite of Stance
+	SwitchEvery int       `json:"switch_every,omitempty"` // rounds between side swaps (0 = never)
+	Persona     string    `json:"persona,omitempty"`      // persona ID from the catalog
+	Difficulty  string    `json:"difficulty,omitempty"`   // difficulty ID from the catalog
+	Coach       bool      `json:"coach,omitempty"`        // annotate the user's fallacies
+	Title       string    `json:"title,omitempty"`        // sidebar title, generated once the debate has a summary
+	Messages    []Message `json:"messages"`
+
+	// Summary is the rolling summary of the debate; see Summary.UpTo for how much of the
+	// history it covers.
+	Summary *Summary `json:"summary,omitempty"`
+
+	// Debate is the phase state of a structured debate; nil for free-form debates.
+	Debate *DebateState `json:"debate,omitempty"`
+
+	// Judgement is the judge's verdict. A judged debate is over.
+	Judgement *Judgement `json:"judgement,omitempty"`
+
+	// ArgumentMap is the last map of the debate's claims; see ArgumentMap.UpTo for how much of
+	// the history it covers.
+	ArgumentMap *ArgumentMap `json:"argument_map,omitempty"`
+
+	// Autoplay is set on bot-vs-bot debates, whose PRO side is stored as the "user".
+	Autoplay *Autoplay `json:"autoplay,omitempty"`
+
+	// Mode is ModeHuman for human-vs-human debates moderated by the bot, empty otherwise.
+	Mode         string        `json:"mode,omitempty"`
+	Participants []Participant `json:"participants,omitempty"`
+	Spectators   []string      `json:"spectators,omitempty"` // user IDs allowed to watch a human debate
+	Rounds       int           `json:"rounds,omitempty"`     // planned rounds of a human debate (0 = open-ended)
+	AutoJudge    bool          `json:"auto_judge,omitempty"` // judge the human debate when its rounds are done
+
+	// Experiment is the sticky prompt-experiment assignment, if any.
+	Experiment *ExperimentAssignment `json:"experiment,omitempty"`
+	Rating     int                   `json:"rating,omitempty"`      // user rating 1-5, 0 when unrated
+	ErrorCount int                   `json:"error_count,omitempty"` // failed bot generations
+
+	// ParentID and ForkedFrom are set on branches: the conversation this one was forked from
+	// and the ID of the last message copied from it.
+	ParentID   string `json:"parent_id,omitempty"`
+	ForkedFrom string `json:"forked_from,omitempty"`
+
+	// appended counts Append calls on this copy; see Mark.
+	appended int
+}
+
+// DebateState tracks where a structured debate is in its format.
+type DebateState struct {
+	Format   string `json:"format"`
+	Phase    int    `json:"phase"` // index into the format's phases
+	Turns    int    `json:"turns"` // exchanges completed in the current phase
+	Finished bool   `json:"finished,omitempty"`
+}
+
+// Judgement is the judge's structured verdict on a debate.
+type Judgement struct {
+	Winner       string           `json:"winner"` // "user" | "bot" | "tie"
+	Summary      string           `json:"summary"`
+	Scores       []CriterionScore `json:"scores"`
+	Fallacies    []FallacyNote    `json:"fallacies"`
+	TurnFeedback []TurnFeedback   `json:"turn_feedback"`
+	Model        string           `json:"model,omitempty"`
+	JudgedAt     int64            `json:"judged_at"` // unix ms
+}
+
+// CriterionScore scores both sides on one rubric criterion. Scores are keyed by side:
+// "user" and "bot", or "pro" and "con" in human debates.
+type CriterionScore struct {
+	Criterion string             `json:"criterion"`
+	Scores    map[string]float64 `json:"scores"`
+	Max       int                `json:"max"`
+	Comment   string             `json:"comment,omitempty"`
+}
+
+// FallacyNote points out a logical fallacy in a turn. Turns are numbered from 1 over the
+// user and bot messages the judge saw.
+type FallacyNote struct {
+	Turn        int    `json:"turn"`
+	Side        string `json:"side"`
+	Fallacy     string `json:"fallacy"`
+	Explanation string `json:"explanation"`
+}
+
+// TurnFeedback is the judge's comment on a single turn.
+type TurnFeedback struct {
+	Turn     int    `json:"turn"`
+	Side     string `json:"side"`
+	Feedback string `json:"feedback"`
+}
+
+// FallacyAnnotation is coaching feedback on a fallacy the user committed.
+type FallacyAnnotation struct {
+	Fallacy     string `json:"fallacy"`              // catalog ID, e.g. "strawman"
+	Name        string `json:"name"`                 // display name, e.g. "Strawman"
+	Quote       string `json:"quote,omitempty"`      // the words of the message that commit it
+	Explanation string `json:"explanation"`          // why the argument is fallacious
+	Suggestion  string `json:"suggestion,omitempty"` // how to make the point soundly
+}
+
+// Summary is a short rolling summary of a debate, refreshed every few turns.
+type Summary struct {
+	Text        string `json:"text"`
+	UpTo        string `json:"up_to"` // ID of the last message the summary covers
+	Turns       int    `json:"turns"` // bot turns covered
+	Model       string `json:"model,omitempty"`
+	GeneratedAt int64  `json:"generated_at"` // unix ms
+}
+
+// Relations between claims in an argument map.
+const (
+	RelationSupport = "support"
+	RelationAttack  = "attack"
+)
+
+// ArgumentMap is the graph of claims made in a debate and how they support or attack each
+// other.
+type ArgumentMap struct {
+	Claims    []Claim    `json:"claims"`
+	Relations []Relation `json:"relations"`
+	UpTo      string     `json:"up_to"` // ID of the last message the map covers
+	Model     string     `json:"model,omitempty"`
+	MappedAt  int64      `json:"mapped_at"` // unix ms
+}
+
+// Claim is one assertion made in a message. Turns are numbered like in a Judgement.
+type Claim struct {
+	ID        string `json:"id"` // c1, c2, ... in the order the claims were made
+	MessageID string `json:"message_id"`
+	Turn      int    `json:"turn"`
+	Side      string `json:"side"` // the role of the message, e.g. "user" or "pro"
+	Text      string `json:"text"`
+}
+
+// Relation is a directed edge between claims: From supports or attacks To.
+type Relation struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"` // RelationSupport | RelationAttack
+}
+
+// Autoplay configures a bot-vs-bot debate.
+type Autoplay struct {
+	Rounds int          `json:"rounds"` // a round is one PRO and one CON turn
+	Pro    AutoplaySide `json:"pro"`
+	Con    AutoplaySide `json:"con"`
+}
+
+// AutoplaySide is the engine and persona setup of one side. Empty fields use the defaults.
+type AutoplaySide struct {
+	Model       string   `json:"model,omitempty"`
+	Persona     string   `json:"persona,omitempty"`
+	Difficulty  string   `json:"difficulty,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+}
+
+// Side returns the setup of the side arguing stance.
+func (a *Autoplay) Side(stance string) AutoplaySide {
+	if stance == StancePro {
+		return a.Pro
+	}
+
+	return a.Con
+}
+
+// ExperimentAssignment records which experiment variant a conversation runs.
+type ExperimentAssignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
 }
 
 func NewConversation(id string) *Conversation {
@@ -37,8 +347,13 @@ func NewConversation(id string) *Conversation {
 }
 
 func (c *Conversation) Append(m Message) {
+	if m.ID == "" {
+		m.ID = ulid.Make().String()
+	}
+
 	m.TS = time.Now().UnixMilli()
 	c.Messages = append(c.Messages, m)
+	c.appended++
 
 	if len(c.Messages) > 200 { // cap growth defensively
 		c.Messages = c.Messages[len(c.Messages)-200:]
@@ -47,6 +362,70 @@ func (c *Conversation) Append(m Message) {
 
 func (c *Conversation) History() []Message { return c.Messages }
 
+// MessageIndex returns the position of the message with the given ID, or -1.
+func (c *Conversation) MessageIndex(id string) int {
+	for i, m := range c.Messages {
+		if m.ID == id && id != "" {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Truncate drops the messages from position i on and undoes the side switches among them.
+func (c *Conversation) Truncate(i int) {
+	for _, m := range c.Messages[i:] {
+		if m.Event == EventSideSwitch {
+			c.SetSides(OppositeStance(c.Stance))
+		}
+	}
+
+	// cap the slice so later appends don't overwrite copies that share the array
+	c.Messages = c.Messages[:i:i]
+}
+
+// Fork returns a branch with the given ID and the history up to and including message i.
+// Copied messages keep their IDs; the judgement, argument map, title, summary, rating and
+// error count start over.
+func (c *Conversation) Fork(id string, i int) *Conversation {
+	branch := *c
+	branch.ID = id
+	branch.ParentID = c.ID
+	branch.ForkedFrom = c.Messages[i].ID
+	branch.Messages = slices.Clone(c.Messages)
+	branch.Judgement = nil
+	branch.ArgumentMap = nil
+	branch.Title = ""
+	branch.Summary = nil
+	branch.Rating = 0
+	branch.ErrorCount = 0
+	branch.appended = 0
+
+	if c.Debate != nil {
+		debate := *c.Debate
+		branch.Debate = &debate
+	}
+
+	branch.Truncate(i + 1)
+
+	return &branch
+}
+
+// Mark returns a position in the history to pass to Since later.
+func (c *Conversation) Mark() int { return c.appended }
+
+// Since returns the messages appended after mark, newest last. Messages already dropped by
+// the growth cap are left out.
+func (c *Conversation) Since(mark int) []Message {
+	n := min(c.appended-mark, len(c.Messages))
+	if n <= 0 {
+		return nil
+	}
+
+	return append([]Message(nil), c.Messages[len(c.Messages)-n:]...)
+}
+
 // LastN returns at most n most-recent messages (user/bot mixed), newest last.
 func (c *Conversation) LastN(n int) []Message {
 	if n <= 0 || len(c.Messages) <= n {
@@ -55,3 +434,55 @@ func (c *Conversation) LastN(n int) []Message {
 
 	return append([]Message(nil), c.Messages[len(c.Messages)-n:]...)
 }
+
+// SetSides assigns the bot stance and derives the user's side from it.
+func (c *Conversation) SetSides(botStance string) {
+	c.Stance = botStance
+	c.UserStance = OppositeStance(botStance)
+}
+
+// SwapSides flips the bot and user stances and records the change in the history
+// so both the transcript and the prompt reflect it.
+func (c *Conversation) SwapSides() {
+	c.SetSides(OppositeStance(c.Stance))
+	c.Append(Message{
+		Role:    "system",
+		Event:   EventSideSwitch,
+		Message: "Sides switched: the user now argues " + c.UserStance + " and the bot argues " + c.Stance + ".",
+	})
+}
+
+// RoundsSinceSwitch counts bot replies since the last side swap (or the start of the debate).
+func (c *Conversation) RoundsSinceSwitch() int {
+	rounds := 0
+
+	for i := len(c.Messages) - 1; i >= 0; i-- {
+		if c.Messages[i].Event == EventSideSwitch {
+			return rounds
+		}
+
+		if c.Messages[i].Role == "bot" && !c.Messages[i].Flagged() {
+			rounds++
+		}
+	}
+
+	return rounds
+}
+
+// Turns counts the bot replies that reached the user.
+func (c *Conversation) Turns() int {
+	turns := 0
+
+	for _, m := range c.Messages {
+		if m.Role == "bot" && !m.Flagged() {
+			turns++
+		}
+	}
+
+	return turns
+}
+
+// ShouldSwitchSides reports whether the switch-sides interval has been reached.
+func (c *Conversation) ShouldSwitchSides() bool {
+	return c.SwitchEvery > 0 && c.RoundsSinceSwitch() >= c.SwitchEvery
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/nikoremi97/debate/internal/moderation"
 )
 
 func TestNewConversation(t *testing.T) {
@@ -135,3 +137,179 @@ func TestLastN(t *testing.T) {
 		t.Fatalf("expected 5 messages, got %d", len(last10))
 	}
 }
+
+func TestSwapSides(t *testing.T) {
+	conv := NewConversation("test-123")
+	conv.SetSides(StancePro)
+
+	if conv.UserStance != StanceCon {
+		t.Fatalf("expected user stance CON, got %s", conv.UserStance)
+	}
+
+	conv.SwapSides()
+
+	if conv.Stance != StanceCon || conv.UserStance != StancePro {
+		t.Fatalf("expected sides to be swapped, got bot=%s user=%s", conv.Stance, conv.UserStance)
+	}
+
+	if len(conv.Messages) != 1 || conv.Messages[0].Event != EventSideSwitch {
+		t.Fatalf("expected side switch to be recorded in history, got %+v", conv.Messages)
+	}
+}
+
+func TestShouldSwitchSides(t *testing.T) {
+	conv := NewConversation("test-123")
+	conv.SetSides(StancePro)
+	conv.SwitchEvery = 2
+
+	conv.Append(Message{Role: "user", Message: "One"})
+	conv.Append(Message{Role: "bot", Message: "Reply one"})
+
+	if conv.ShouldSwitchSides() {
+		t.Fatal("should not switch after one round")
+	}
+
+	conv.Append(Message{Role: "user", Message: "Two"})
+	conv.Append(Message{Role: "bot", Message: "Reply two"})
+
+	if !conv.ShouldSwitchSides() {
+		t.Fatal("should switch after two rounds")
+	}
+
+	conv.SwapSides()
+
+	if conv.RoundsSinceSwitch() != 0 {
+		t.Fatalf("expected round counter to reset after a switch, got %d", conv.RoundsSinceSwitch())
+	}
+}
+
+func TestHumanDebateTurns(t *testing.T) {
+	conv := NewConversation("test-123")
+	conv.Mode = ModeHuman
+	conv.Rounds = 2
+	conv.Join("alice", StancePro)
+	conv.Join("bob", StanceCon)
+
+	if p, ok := conv.Participant("bob"); !ok || p.Stance != StanceCon {
+		t.Fatalf("expected bob to argue CON, got %+v", p)
+	}
+
+	if conv.NextSpeaker() != StancePro {
+		t.Fatalf("PRO should open, got %s", conv.NextSpeaker())
+	}
+
+	conv.Append(Message{Role: RolePro, Author: "alice", Message: "Opening"})
+
+	if conv.NextSpeaker() != StanceCon {
+		t.Fatalf("expected CON to answer, got %s", conv.NextSpeaker())
+	}
+
+	// a flagged message doesn't use up the turn
+	conv.Append(Message{Role: RoleCon, Author: "bob", Message: "insult", Moderation: &moderation.Verdict{Allowed: false}})
+
+	if conv.NextSpeaker() != StanceCon || conv.CompletedRounds() != 0 {
+		t.Fatalf("flagged turn should not count, next=%s rounds=%d", conv.NextSpeaker(), conv.CompletedRounds())
+	}
+
+	conv.Append(Message{Role: RoleCon, Author: "bob", Message: "Rebuttal"})
+	conv.Append(Message{Role: RoleModerator, Message: "Round 1 complete."})
+
+	if conv.NextSpeaker() != StancePro || conv.CompletedRounds() != 1 || conv.HumanDebateOver() {
+		t.Fatalf("expected round 1 to be complete with PRO next, got next=%s rounds=%d", conv.NextSpeaker(), conv.CompletedRounds())
+	}
+
+	conv.Append(Message{Role: RolePro, Message: "Second"})
+	conv.Append(Message{Role: RoleCon, Message: "Second rebuttal"})
+
+	if !conv.HumanDebateOver() {
+		t.Fatal("expected the debate to be over after two rounds")
+	}
+}
+
+func TestSince(t *testing.T) {
+	conv := NewConversation("test-123")
+	conv.Append(Message{Role: "user", Message: "One"})
+
+	mark := conv.Mark()
+	if got := conv.Since(mark); len(got) != 0 {
+		t.Fatalf("expected nothing new, got %+v", got)
+	}
+
+	conv.Append(Message{Role: "bot", Message: "Two"})
+	conv.Append(Message{Role: "user", Message: "Three"})
+
+	if got := conv.Since(mark); len(got) != 2 || got[0].Message != "Two" {
+		t.Fatalf("expected the two new messages, got %+v", got)
+	}
+
+	for i := 0; i < 250; i++ {
+		conv.Append(Message{Role: "user", Message: fmt.Sprint(i)})
+	}
+
+	if got := conv.Since(mark); len(got) != 200 || got[199].Message != "249" {
+		t.Fatalf("expected the capped history, got %d messages", len(got))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	conv := NewConversation("test-123")
+	conv.SetSides(StancePro)
+	conv.Append(Message{Role: "user", Message: "One"})
+	conv.Append(Message{Role: "bot", Message: "Two"})
+	conv.SwapSides()
+	conv.Append(Message{Role: "user", Message: "Three"})
+
+	if conv.Messages[0].ID == "" || conv.Messages[0].ID == conv.Messages[1].ID {
+		t.Fatalf("expected unique message IDs, got %+v", conv.Messages)
+	}
+
+	i := conv.MessageIndex(conv.Messages[1].ID)
+	if i != 1 || conv.MessageIndex("missing") != -1 {
+		t.Fatalf("expected the bot reply at index 1, got %d", i)
+	}
+
+	copied := conv.Messages
+	conv.Truncate(i + 1)
+
+	if len(conv.Messages) != 2 || conv.Stance != StancePro || conv.UserStance != StanceCon {
+		t.Fatalf("expected the side switch to be undone, got %d messages, bot=%s", len(conv.Messages), conv.Stance)
+	}
+
+	conv.Append(Message{Role: "user", Message: "Four"})
+
+	if copied[2].Event != EventSideSwitch {
+		t.Fatalf("expected the truncated history to leave earlier copies alone, got %+v", copied[2])
+	}
+}
+
+func TestFork(t *testing.T) {
+	conv := NewConversation("parent")
+	conv.SetSides(StancePro)
+	conv.Debate = &DebateState{Format: "oxford"}
+	conv.Rating = 4
+	conv.ArgumentMap = &ArgumentMap{UpTo: "anything"}
+	conv.Title = "Pizza toppings showdown"
+	conv.Summary = &Summary{UpTo: "anything"}
+	conv.Append(Message{Role: "user", Message: "One"})
+	conv.Append(Message{Role: "bot", Message: "Two"})
+	conv.SwapSides()
+
+	branch := conv.Fork("branch", 1)
+
+	if branch.ID != "branch" || branch.ParentID != "parent" || branch.ForkedFrom != conv.Messages[1].ID {
+		t.Fatalf("expected a branch of the parent at its second message, got %+v", branch)
+	}
+
+	if len(branch.Messages) != 2 || branch.Messages[0].ID != conv.Messages[0].ID {
+		t.Fatalf("expected the first two messages with their IDs, got %+v", branch.Messages)
+	}
+
+	if branch.Stance != StancePro || branch.Rating != 0 || branch.ArgumentMap != nil || branch.Summary != nil || branch.Title != "" {
+		t.Fatalf("expected the side switch, rating, argument map and summary to be dropped, got bot=%s rating=%d", branch.Stance, branch.Rating)
+	}
+
+	branch.Debate.Turns = 3
+	if conv.Debate.Turns != 0 || conv.Stance != StanceCon {
+		t.Fatalf("expected the parent to be left alone, got %+v bot=%s", conv.Debate, conv.Stance)
+	}
+}
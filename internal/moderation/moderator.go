@@ -0,0 +1,164 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Moderator checks a single piece of chat content (a user message or a bot reply).
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (Verdict, error)
+}
+
+// PolicyModerator applies the policy's regex rules to messages. Debate messages quote
+// words like "harm" or "attack" all the time, so it blocks at its own, usually higher, threshold.
+type PolicyModerator struct {
+	policy  *Policy
+	blockAt Severity
+}
+
+func NewPolicyModerator(policy *Policy, blockAt Severity) *PolicyModerator {
+	if blockAt.rank() == 0 {
+		blockAt = policy.BlockSeverity
+	}
+
+	return &PolicyModerator{policy: policy, blockAt: blockAt}
+}
+
+func (m *PolicyModerator) Moderate(_ context.Context, text string) (Verdict, error) {
+	return m.policy.check(text, m.blockAt), nil
+}
+
+// chain runs moderators in order and stops at the first block.
+type chain []Moderator
+
+// Chain combines moderators; the first one to block decides the verdict.
+func Chain(moderators ...Moderator) Moderator {
+	return chain(moderators)
+}
+
+func (c chain) Moderate(ctx context.Context, text string) (Verdict, error) {
+	verdict := Verdict{Allowed: true, Reason: "ok"}
+
+	var errs []error
+
+	for _, m := range c {
+		v, err := m.Moderate(ctx, text)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if !v.Allowed {
+			return v, nil
+		}
+	}
+
+	return verdict, errors.Join(errs...)
+}
+
+// openAIModerationResponse is the response of the OpenAI moderation endpoint.
+type openAIModerationResponse struct {
+	Results []openAIModerationResult `json:"results"`
+}
+
+type openAIModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// OpenAIModerator calls an OpenAI-compatible /v1/moderations endpoint.
+type OpenAIModerator struct {
+	apiKey string
+	model  string
+	url    string
+	client *http.Client
+}
+
+func NewOpenAIModerator(apiKey string) *OpenAIModerator {
+	return NewOpenAIModeratorWithURL(apiKey, "https://api.openai.com/v1/moderations")
+}
+
+// NewOpenAIModeratorWithURL points the moderator at a compatible endpoint (a proxy or a local stub).
+func NewOpenAIModeratorWithURL(apiKey, url string) *OpenAIModerator {
+	return &OpenAIModerator{
+		apiKey: apiKey,
+		model:  "omni-moderation-latest",
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (m *OpenAIModerator) Moderate(ctx context.Context, text string) (Verdict, error) {
+	b, _ := json.Marshal(map[string]any{"model": m.model, "input": text})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(b))
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return Verdict{}, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return Verdict{}, fmt.Errorf("moderation http %d: %s", resp.StatusCode, string(body))
+	}
+
+	var out openAIModerationResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Verdict{}, err
+	}
+
+	if len(out.Results) == 0 {
+		return Verdict{}, errors.New("no moderation results returned")
+	}
+
+	return verdictFromResult(out.Results[0]), nil
+}
+
+func verdictFromResult(r openAIModerationResult) Verdict {
+	if !r.Flagged {
+		return Verdict{Allowed: true, Reason: "ok"}
+	}
+
+	// report the flagged category with the highest score
+	var flagged []string
+
+	for name, on := range r.Categories {
+		if on {
+			flagged = append(flagged, name)
+		}
+	}
+
+	sort.Slice(flagged, func(i, j int) bool {
+		return r.CategoryScores[flagged[i]] > r.CategoryScores[flagged[j]]
+	})
+
+	category := "flagged"
+	if len(flagged) > 0 {
+		category = flagged[0]
+	}
+
+	return Verdict{
+		Reason:   "flagged by the moderation service as " + category,
+		Category: category,
+		Severity: SeverityHigh,
+	}
+}
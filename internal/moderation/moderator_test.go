@@ -0,0 +1,112 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPolicyModeratorThreshold(t *testing.T) {
+	m := NewPolicyModerator(Default(), SeverityCritical)
+
+	v, err := m.Moderate(context.Background(), "Remote work can harm team culture")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.Allowed || v.Category != "violence" {
+		t.Fatalf("high severity words should be flagged but allowed in messages, got %+v", v)
+	}
+
+	v, _ = m.Moderate(context.Background(), "send me porn")
+	if v.Allowed || v.Category != "sexual_explicit" {
+		t.Fatalf("critical content should be blocked, got %+v", v)
+	}
+}
+
+// moderationStub is a local stand-in for the OpenAI moderation endpoint.
+func moderationStub(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req struct {
+			Input string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		result := openAIModerationResult{Categories: map[string]bool{}, CategoryScores: map[string]float64{}}
+		if strings.Contains(req.Input, "threat") {
+			result.Flagged = true
+			result.Categories["harassment"] = true
+			result.Categories["violence"] = true
+			result.CategoryScores["harassment"] = 0.4
+			result.CategoryScores["violence"] = 0.9
+		}
+
+		_ = json.NewEncoder(w).Encode(openAIModerationResponse{Results: []openAIModerationResult{result}})
+	}))
+}
+
+func TestOpenAIModerator(t *testing.T) {
+	srv := moderationStub(t)
+	defer srv.Close()
+
+	m := NewOpenAIModeratorWithURL("test-key", srv.URL)
+
+	v, err := m.Moderate(context.Background(), "Cats are better than dogs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !v.Allowed {
+		t.Fatalf("expected clean text to pass, got %+v", v)
+	}
+
+	v, err = m.Moderate(context.Background(), "this is a threat")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if v.Allowed || v.Category != "violence" {
+		t.Fatalf("expected the highest scoring category to be reported, got %+v", v)
+	}
+
+	if _, err := NewOpenAIModeratorWithURL("wrong-key", srv.URL).Moderate(context.Background(), "hi"); err == nil {
+		t.Fatal("expected an error for a non-2xx response")
+	}
+}
+
+type errModerator struct{}
+
+func (errModerator) Moderate(context.Context, string) (Verdict, error) {
+	return Verdict{}, errors.New("unavailable")
+}
+
+func TestChain(t *testing.T) {
+	srv := moderationStub(t)
+	defer srv.Close()
+
+	m := Chain(errModerator{}, NewPolicyModerator(Default(), SeverityCritical), NewOpenAIModeratorWithURL("test-key", srv.URL))
+
+	v, err := m.Moderate(context.Background(), "a threat")
+	if err != nil || v.Allowed {
+		t.Fatalf("expected a later moderator to block, got %+v, %v", v, err)
+	}
+
+	v, err = m.Moderate(context.Background(), "hello")
+	if !v.Allowed || err == nil {
+		t.Fatalf("expected allowed verdict with the failing moderator's error, got %+v, %v", v, err)
+	}
+}
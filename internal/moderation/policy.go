@@ -0,0 +1,310 @@
+package moderation
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//go:embed policy.json
+var defaultPolicyJSON []byte
+
+// Severity ranks how harmful a category is.
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityLow:
+		return 1
+	case SeverityMedium:
+		return 2
+	case SeverityHigh:
+		return 3
+	case SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+// Category groups terms that share a severity.
+type Category struct {
+	Name     string   `json:"name"`
+	Severity Severity `json:"severity"`
+	Terms    []string `json:"terms"`
+
+	pattern *regexp.Regexp
+}
+
+// ContextException lets a flagged term through when one of the patterns matches a phrase
+// containing it, e.g. educational or policy phrasing ("sexual health awareness", "marijuana
+// legalization"). A pattern elsewhere in the text excuses nothing.
+type ContextException struct {
+	Name        string   `json:"name"`
+	Patterns    []string `json:"patterns"`
+	Categories  []string `json:"categories"`
+	MaxSeverity Severity `json:"max_severity"`
+
+	compiled []*regexp.Regexp
+}
+
+// Policy is the moderation configuration.
+type Policy struct {
+	MaxLength         int                `json:"max_length"`
+	BlockSeverity     Severity           `json:"block_severity"` // matches at or above this severity are blocked
+	Allow             []string           `json:"allow"`          // phrases that are always allowed
+	Deny              []string           `json:"deny"`           // phrases that are always blocked
+	Categories        []Category         `json:"categories"`
+	ContextExceptions []ContextException `json:"context_exceptions"`
+
+	allow *regexp.Regexp
+	deny  *regexp.Regexp
+}
+
+// Verdict is the structured moderation decision returned to clients.
+type Verdict struct {
+	Allowed  bool     `json:"allowed"`
+	Reason   string   `json:"reason"`
+	Category string   `json:"category,omitempty"`
+	Severity Severity `json:"severity,omitempty"`
+	Match    string   `json:"match,omitempty"`
+}
+
+// Categories reported for checks that aren't driven by the term lists.
+const (
+	CategoryEmpty  = "empty"
+	CategoryLength = "length"
+	CategoryDenied = "denied"
+)
+
+var (
+	defaultOnce   sync.Once
+	defaultPolicy *Policy
+)
+
+// Default returns the embedded policy.
+func Default() *Policy {
+	defaultOnce.Do(func() {
+		p, err := ParsePolicy(defaultPolicyJSON)
+		if err != nil {
+			panic("moderation: invalid embedded policy: " + err.Error())
+		}
+
+		defaultPolicy = p
+	})
+
+	return defaultPolicy
+}
+
+// LoadPolicy reads a JSON policy from disk.
+func LoadPolicy(path string) (*Policy, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation policy: %w", err)
+	}
+
+	return ParsePolicy(b)
+}
+
+// ParsePolicy decodes and compiles a JSON policy.
+func ParsePolicy(b []byte) (*Policy, error) {
+	var p Policy
+
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation policy: %w", err)
+	}
+
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (p *Policy) compile() error {
+	if p.BlockSeverity == "" {
+		p.BlockSeverity = SeverityHigh
+	}
+
+	if p.BlockSeverity.rank() == 0 {
+		return fmt.Errorf("unknown block_severity %q", p.BlockSeverity)
+	}
+
+	p.allow = termsPattern(p.Allow)
+	p.deny = termsPattern(p.Deny)
+
+	for i := range p.Categories {
+		c := &p.Categories[i]
+		if c.Severity.rank() == 0 {
+			return fmt.Errorf("category %q has unknown severity %q", c.Name, c.Severity)
+		}
+
+		c.pattern = termsPattern(c.Terms)
+	}
+
+	for i := range p.ContextExceptions {
+		e := &p.ContextExceptions[i]
+		if e.MaxSeverity == "" {
+			e.MaxSeverity = SeverityHigh
+		}
+
+		for _, pattern := range e.Patterns {
+			re, err := regexp.Compile("(?i)" + pattern)
+			if err != nil {
+				return fmt.Errorf("exception %q: invalid pattern %q: %w", e.Name, pattern, err)
+			}
+
+			e.compiled = append(e.compiled, re)
+		}
+	}
+
+	return nil
+}
+
+// termsPattern builds a case-insensitive whole-word matcher for a list of terms.
+func termsPattern(terms []string) *regexp.Regexp {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	quoted := make([]string, len(terms))
+	for i, t := range terms {
+		quoted[i] = regexp.QuoteMeta(strings.ToLower(t))
+	}
+
+	return regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// CheckTopic evaluates a debate topic against the policy.
+func (p *Policy) CheckTopic(topic string) Verdict {
+	trimmed := strings.TrimSpace(topic)
+	if trimmed == "" {
+		return Verdict{Reason: "topic is empty", Category: CategoryEmpty}
+	}
+
+	if p.MaxLength > 0 && len(topic) > p.MaxLength {
+		return Verdict{Reason: fmt.Sprintf("topic is longer than %d characters", p.MaxLength), Category: CategoryLength}
+	}
+
+	return p.Check(trimmed)
+}
+
+// Check evaluates arbitrary text against the allow/deny lists and categories.
+func (p *Policy) Check(text string) Verdict {
+	return p.check(text, p.BlockSeverity)
+}
+
+// check is Check with an explicit blocking threshold, so the same rules can be stricter for
+// topics than for individual debate messages. The deny list and every category always run: an
+// allow-listed phrase or a context exception only excuses the category terms it overlaps, so
+// "Sex education" can't carry "pornography" elsewhere in the text.
+func (p *Policy) check(text string, blockAt Severity) Verdict {
+	if p.deny != nil {
+		if m := p.deny.FindString(text); m != "" {
+			return Verdict{Reason: "contains a denied phrase", Category: CategoryDenied, Severity: SeverityCritical, Match: m}
+		}
+	}
+
+	allowed := spans(p.allow, text)
+
+	var flagged *Verdict
+
+	for _, c := range p.Categories {
+		if c.pattern == nil {
+			continue
+		}
+
+		for _, loc := range c.pattern.FindAllStringIndex(text, -1) {
+			if overlaps(allowed, loc) {
+				continue
+			}
+
+			m := text[loc[0]:loc[1]]
+
+			if exception := p.exceptionFor(c, text, loc); exception != "" {
+				if flagged == nil {
+					flagged = &Verdict{Allowed: true, Reason: "allowed by " + exception + " context", Category: c.Name, Severity: c.Severity, Match: m}
+				}
+
+				continue
+			}
+
+			if c.Severity.rank() >= blockAt.rank() {
+				return Verdict{Reason: "topic touches the " + c.Name + " category; please rephrase it as a debatable question", Category: c.Name, Severity: c.Severity, Match: m}
+			}
+
+			if flagged == nil || c.Severity.rank() > flagged.Severity.rank() {
+				flagged = &Verdict{Allowed: true, Reason: c.Name + " content below the blocking threshold", Category: c.Name, Severity: c.Severity, Match: m}
+			}
+		}
+	}
+
+	if flagged != nil {
+		return *flagged
+	}
+
+	if len(allowed) > 0 {
+		return Verdict{Allowed: true, Reason: "matches allow-listed phrase", Match: text[allowed[0][0]:allowed[0][1]]}
+	}
+
+	return Verdict{Allowed: true, Reason: "ok"}
+}
+
+// exceptionFor returns the name of the first context exception whose phrase contains the
+// category match at loc, or "".
+func (p *Policy) exceptionFor(c Category, text string, loc []int) string {
+	for _, e := range p.ContextExceptions {
+		if c.Severity.rank() > e.MaxSeverity.rank() || !contains(e.Categories, c.Name) {
+			continue
+		}
+
+		for _, re := range e.compiled {
+			if overlaps(re.FindAllStringIndex(text, -1), loc) {
+				return e.Name
+			}
+		}
+	}
+
+	return ""
+}
+
+// spans returns the byte ranges re matches in text; none when re is nil.
+func spans(re *regexp.Regexp, text string) [][]int {
+	if re == nil {
+		return nil
+	}
+
+	return re.FindAllStringIndex(text, -1)
+}
+
+// overlaps reports whether any of the ranges overlaps loc.
+func overlaps(ranges [][]int, loc []int) bool {
+	for _, r := range ranges {
+		if r[0] < loc[1] && loc[0] < r[1] {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
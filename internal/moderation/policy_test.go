@@ -0,0 +1,107 @@
+package moderation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefaultPolicyCheckTopic(t *testing.T) {
+	tests := []struct {
+		name     string
+		topic    string
+		allowed  bool
+		category string
+	}{
+		{"Plain topic", "Remote work is better than office work", true, ""},
+		{"Empty topic", "   ", false, CategoryEmpty},
+		{"Allow-listed phrase", "Sex education in schools", true, ""},
+		{"Educational context", "Sexual health awareness for teenagers", true, "sexual"},
+		{"Hate speech laws", "Should hate speech laws exist", true, ""},
+		{"Drug legalization", "Marijuana legalization is overdue", true, "drugs"},
+		{"Violence", "Violence is good", false, "violence"},
+		{"Racism", "Racism is acceptable", false, "hate"},
+		{"Theft", "Theft is justified", false, "crime"},
+		{"Explicit content is never exempt", "Pornography education", false, "sexual_explicit"},
+		{"Medium severity is allowed but flagged", "Offensive jokes are funny", true, "offensive"},
+		{"Policy phrasing around the term", "Should governments ban violent video games", true, "violence"},
+		{"History of", "The history of violence in sports", true, "violence"},
+		{"Allow-listed phrase excuses only itself", "Sex education should show pornography and rape", false, "sexual_explicit"},
+		{"Allow-listed phrase doesn't exempt critical terms", "Suicide is a good drug policy", false, "self_harm"},
+		{"Policy word elsewhere in the text", "Violence is good policy", false, "violence"},
+		{"Law word elsewhere in the text", "Murder is fine under the law", false, "violence"},
+		{"Research word elsewhere in the text", "Theft is fine, research shows", false, "crime"},
+	}
+
+	policy := Default()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := policy.CheckTopic(tt.topic)
+			if v.Allowed != tt.allowed {
+				t.Fatalf("CheckTopic(%q) allowed = %v, expected %v (%+v)", tt.topic, v.Allowed, tt.allowed, v)
+			}
+
+			if v.Category != tt.category {
+				t.Fatalf("CheckTopic(%q) category = %q, expected %q", tt.topic, v.Category, tt.category)
+			}
+
+			if v.Reason == "" {
+				t.Fatalf("CheckTopic(%q) should explain its verdict", tt.topic)
+			}
+		})
+	}
+}
+
+func TestCheckTopicTooLong(t *testing.T) {
+	topic := make([]byte, 201)
+	for i := range topic {
+		topic[i] = 'a'
+	}
+
+	v := Default().CheckTopic(string(topic))
+	if v.Allowed || v.Category != CategoryLength {
+		t.Fatalf("expected length rejection, got %+v", v)
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	custom := `{
+		"block_severity": "medium",
+		"deny": ["flat earth"],
+		"categories": [{"name": "food", "severity": "medium", "terms": ["pineapple"]}]
+	}`
+
+	if err := os.WriteFile(path, []byte(custom), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("load policy: %v", err)
+	}
+
+	if v := policy.CheckTopic("Pineapple belongs on pizza"); v.Allowed || v.Category != "food" {
+		t.Fatalf("expected custom category to block, got %+v", v)
+	}
+
+	if v := policy.CheckTopic("The flat earth society is right"); v.Allowed || v.Category != CategoryDenied {
+		t.Fatalf("expected deny list to block, got %+v", v)
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	invalid := []string{
+		`{"block_severity": "extreme"}`,
+		`{"categories": [{"name": "x", "severity": "bad", "terms": ["x"]}]}`,
+		`{"context_exceptions": [{"name": "x", "patterns": ["("]}]}`,
+		`not json`,
+	}
+
+	for _, b := range invalid {
+		if _, err := ParsePolicy([]byte(b)); err == nil {
+			t.Errorf("ParsePolicy(%s) should fail", b)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package personas
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/prompts"
+)
+
+//go:embed catalog.json
+var defaultCatalogJSON []byte
+
+// Persona is a debating character: how the bot argues and how adventurous its wording is.
+type Persona struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Prompt      string   `json:"prompt"`
+	Techniques  []string `json:"techniques"`
+	Temperature float64  `json:"temperature"`
+}
+
+// Difficulty sets how hard the bot pushes: the guidance, reply length and a temperature
+// shift applied on top of the persona's.
+type Difficulty struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description"`
+	Prompt           string   `json:"prompt"`
+	Techniques       []string `json:"techniques"`
+	Length           string   `json:"length"` // e.g. "3-6 sentences"
+	MaxTokens        int      `json:"max_tokens"`
+	TemperatureShift float64  `json:"temperature_shift"`
+}
+
+// Catalog lists the personas and difficulty levels users can pick from.
+type Catalog struct {
+	DefaultPersona    string       `json:"default_persona"`
+	DefaultDifficulty string       `json:"default_difficulty"`
+	Personas          []Persona    `json:"personas"`
+	Difficulties      []Difficulty `json:"difficulties"`
+}
+
+var (
+	defaultOnce    sync.Once
+	defaultCatalog *Catalog
+)
+
+// Default returns the embedded catalog.
+func Default() *Catalog {
+	defaultOnce.Do(func() {
+		c, err := Parse(defaultCatalogJSON)
+		if err != nil {
+			panic("personas: invalid embedded catalog: " + err.Error())
+		}
+
+		defaultCatalog = c
+	})
+
+	return defaultCatalog
+}
+
+// Load reads a JSON catalog from disk.
+func Load(path string) (*Catalog, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read persona catalog: %w", err)
+	}
+
+	return Parse(b)
+}
+
+// Parse decodes and validates a JSON catalog.
+func Parse(b []byte) (*Catalog, error) {
+	var c Catalog
+
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse persona catalog: %w", err)
+	}
+
+	seen := map[string]bool{}
+
+	for _, p := range c.Personas {
+		if p.ID == "" || seen[p.ID] {
+			return nil, fmt.Errorf("persona ids must be unique and non-empty (got %q)", p.ID)
+		}
+
+		seen[p.ID] = true
+
+		if p.Temperature < 0 || p.Temperature > 2 {
+			return nil, fmt.Errorf("persona %q: temperature must be between 0 and 2", p.ID)
+		}
+	}
+
+	seen = map[string]bool{}
+
+	for _, d := range c.Difficulties {
+		if d.ID == "" || seen[d.ID] {
+			return nil, fmt.Errorf("difficulty ids must be unique and non-empty (got %q)", d.ID)
+		}
+
+		seen[d.ID] = true
+
+		if d.MaxTokens < 0 {
+			return nil, fmt.Errorf("difficulty %q: max_tokens must not be negative", d.ID)
+		}
+	}
+
+	if _, ok := c.Persona(c.DefaultPersona); !ok {
+		return nil, fmt.Errorf("default persona %q is not in the catalog", c.DefaultPersona)
+	}
+
+	if _, ok := c.Difficulty(c.DefaultDifficulty); !ok {
+		return nil, fmt.Errorf("default difficulty %q is not in the catalog", c.DefaultDifficulty)
+	}
+
+	return &c, nil
+}
+
+// Persona looks up a persona by ID.
+func (c *Catalog) Persona(id string) (Persona, bool) {
+	for _, p := range c.Personas {
+		if p.ID == id {
+			return p, true
+		}
+	}
+
+	return Persona{}, false
+}
+
+// Difficulty looks up a difficulty level by ID.
+func (c *Catalog) Difficulty(id string) (Difficulty, bool) {
+	for _, d := range c.Difficulties {
+		if d.ID == id {
+			return d, true
+		}
+	}
+
+	return Difficulty{}, false
+}
+
+// Options returns the engine options for a persona and difficulty. Empty or unknown IDs
+// (e.g. removed from the catalog since the conversation started) use the defaults.
+func (c *Catalog) Options(personaID, difficultyID string) []bot.Option {
+	persona, ok := c.Persona(personaID)
+	if !ok {
+		persona, _ = c.Persona(c.DefaultPersona)
+	}
+
+	difficulty, ok := c.Difficulty(difficultyID)
+	if !ok {
+		difficulty, _ = c.Difficulty(c.DefaultDifficulty)
+	}
+
+	style := prompts.Style{
+		Persona:    persona.Prompt,
+		Difficulty: difficulty.Prompt,
+		Techniques: append(append([]string(nil), persona.Techniques...), difficulty.Techniques...),
+		Length:     difficulty.Length,
+	}
+
+	temperature := min(max(persona.Temperature+difficulty.TemperatureShift, 0), 2)
+
+	opts := []bot.Option{bot.WithStyle(style), bot.WithTemperature(temperature)}
+
+	if difficulty.MaxTokens > 0 {
+		opts = append(opts, bot.WithMaxTokens(difficulty.MaxTokens))
+	}
+
+	return opts
+}
@@ -0,0 +1,63 @@
+package personas
+
+import (
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	c := Default()
+
+	for _, id := range []string{"socratic", "lawyer", "scientist", "devils_advocate"} {
+		if _, ok := c.Persona(id); !ok {
+			t.Fatalf("expected persona %s in the default catalog", id)
+		}
+	}
+
+	for _, id := range []string{"beginner", "intermediate", "advanced", "expert"} {
+		if _, ok := c.Difficulty(id); !ok {
+			t.Fatalf("expected difficulty %s in the default catalog", id)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	o := bot.ApplyOptions(Default().Options("scientist", "expert")...)
+
+	if o.Temperature == nil || *o.Temperature < 0.19 || *o.Temperature > 0.21 {
+		t.Fatalf("expected the difficulty shift on the persona temperature, got %v", o.Temperature)
+	}
+
+	if o.MaxTokens != 600 || o.Style.Length != "5-8 sentences" {
+		t.Fatalf("expected the expert length, got %+v", o)
+	}
+
+	if o.Style.Persona == "" || o.Style.Difficulty == "" || len(o.Style.Techniques) != 4 {
+		t.Fatalf("expected persona and difficulty prompts and techniques, got %+v", o.Style)
+	}
+}
+
+func TestOptionsFallBackToDefaults(t *testing.T) {
+	o := bot.ApplyOptions(Default().Options("retired", "")...)
+
+	if o.Style.Persona != "Be persuasive, calm, and structured." || o.Style.Length != "3-6 sentences" {
+		t.Fatalf("expected the default persona and difficulty, got %+v", o.Style)
+	}
+}
+
+func TestParseRejectsInvalidCatalog(t *testing.T) {
+	cases := map[string]string{
+		"malformed":         `{`,
+		"duplicate persona": `{"default_persona":"a","default_difficulty":"x","personas":[{"id":"a"},{"id":"a"}],"difficulties":[{"id":"x"}]}`,
+		"bad temperature":   `{"default_persona":"a","default_difficulty":"x","personas":[{"id":"a","temperature":3}],"difficulties":[{"id":"x"}]}`,
+		"missing default":   `{"default_persona":"b","default_difficulty":"x","personas":[{"id":"a"}],"difficulties":[{"id":"x"}]}`,
+		"negative tokens":   `{"default_persona":"a","default_difficulty":"x","personas":[{"id":"a"}],"difficulties":[{"id":"x","max_tokens":-1}]}`,
+	}
+
+	for name, raw := range cases {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Fatalf("%s: expected an error", name)
+		}
+	}
+}
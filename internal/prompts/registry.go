@@ -0,0 +1,381 @@
+package prompts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// Template names known to the application.
+const (
+	DebateSystem = "debate_system"
+	TopicBrief   = "topic_brief"
+)
+
+// DebateData is the data passed to debate system prompts.
+type DebateData struct {
+	Topic    string
+	Stance   string
+	Style    Style
+	Phase    Phase
+	Evidence []Passage
+}
+
+// BriefData is the data passed to topic brief prompts.
+type BriefData struct {
+	Topic       string
+	Description string
+	Evidence    []Passage
+}
+
+// Style shapes the bot's voice. Empty fields let the template fall back to its defaults.
+type Style struct {
+	Persona    string   // how the bot argues
+	Difficulty string   // guidance for the opponent's level
+	Techniques []string // rhetorical techniques to lean on
+	Length     string   // reply length, e.g. "3-6 sentences"
+}
+
+// Phase is the current stage of a structured debate. A zero Phase means a free-form debate.
+type Phase struct {
+	Name         string
+	Instructions string
+	WordLimit    int
+}
+
+// Passage is a piece of retrieved evidence the bot may cite by its marker.
+type Passage struct {
+	Marker int    // cited as [Marker]
+	Source string // document the passage comes from
+	Text   string
+}
+
+// samples holds the data each template role is validated against at load time, so a
+// template referencing a missing field is rejected before it can reach a live request.
+var samples = map[string]any{
+	DebateSystem: DebateData{
+		Topic:    "Sample topic",
+		Stance:   "PRO",
+		Style:    Style{Persona: "Sample persona", Difficulty: "Sample level", Techniques: []string{"analogy"}, Length: "2-3 sentences"},
+		Phase:    Phase{Name: "Sample phase", Instructions: "Sample instructions.", WordLimit: 100},
+		Evidence: []Passage{{Marker: 1, Source: "sample.md", Text: "Sample passage."}},
+	},
+	TopicBrief: BriefData{
+		Topic:       "Sample topic",
+		Description: "Sample description?",
+		Evidence:    []Passage{{Marker: 1, Source: "sample.md", Text: "Sample passage."}},
+	},
+}
+
+// Template is a parsed, versioned prompt template.
+type Template struct {
+	Name   string
+	Hash   string // first 12 hex chars of the source's SHA-256
+	Source string // "embedded" or the file path it was loaded from
+	tmpl   *template.Template
+}
+
+// Version identifies the template as name@hash.
+func (t *Template) Version() string { return t.Name + "@" + t.Hash }
+
+// Registry serves prompt templates: the embedded defaults, overridden by any
+// same-named *.tmpl files in an optional directory.
+type Registry struct {
+	dir string
+
+	mu         sync.RWMutex
+	templates  map[string]*Template
+	dirHash    string // fingerprint of the loaded override directory
+	failedHash string // fingerprint of the last directory state that failed validation
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRegistry *Registry
+)
+
+// Default returns a registry with only the embedded templates.
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		r, err := NewRegistry("")
+		if err != nil {
+			panic("prompts: invalid embedded templates: " + err.Error())
+		}
+
+		defaultRegistry = r
+	})
+
+	return defaultRegistry
+}
+
+// NewRegistry loads and validates the templates. dir may be empty.
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{dir: dir}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload re-reads every template. The new set replaces the current one only if all of
+// them parse and validate; otherwise the registry keeps serving the previous set.
+func (r *Registry) Reload() error {
+	templates := map[string]*Template{}
+
+	err := fs.WalkDir(embedded, "templates", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		b, err := embedded.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		t, err := parseTemplate(templateName(path), "embedded", b)
+		if err != nil {
+			return err
+		}
+
+		templates[t.Name] = t
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	dirHash := ""
+
+	if r.dir != "" {
+		overrides, hash, err := loadDir(r.dir)
+		if err != nil {
+			return err
+		}
+
+		for name, t := range overrides {
+			templates[name] = t
+		}
+
+		dirHash = hash
+	}
+
+	r.mu.Lock()
+	r.templates = templates
+	r.dirHash = dirHash
+	r.mu.Unlock()
+
+	return nil
+}
+
+func loadDir(dir string) (map[string]*Template, string, error) {
+	hash, err := fingerprint(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read prompt templates: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, "", err
+	}
+
+	templates := map[string]*Template{}
+
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read prompt template: %w", err)
+		}
+
+		t, err := parseTemplate(templateName(path), path, b)
+		if err != nil {
+			return nil, "", err
+		}
+
+		templates[t.Name] = t
+	}
+
+	return templates, hash, nil
+}
+
+func templateName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), ".tmpl")
+}
+
+func parseTemplate(name, source string, content []byte) (*Template, error) {
+	// an empty file is usually a half-written edit caught mid-save
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, fmt.Errorf("prompt template %s (%s) is empty", name, source)
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("prompt template %s (%s): %w", name, source, err)
+	}
+
+	if sample, ok := sampleFor(name); ok {
+		if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("prompt template %s (%s) failed validation: %w", name, source, err)
+		}
+	}
+
+	sum := sha256.Sum256(content)
+
+	return &Template{
+		Name:   name,
+		Hash:   hex.EncodeToString(sum[:])[:12],
+		Source: source,
+		tmpl:   tmpl,
+	}, nil
+}
+
+// sampleFor returns the sample data of the role a template name announces: the role itself,
+// or a variant of it such as debate_system_v2.
+func sampleFor(name string) (any, bool) {
+	for role, sample := range samples {
+		if name == role || strings.HasPrefix(name, role+"_") {
+			return sample, true
+		}
+	}
+
+	return nil, false
+}
+
+// ValidateAs renders the named template against the sample data of role, for templates used
+// in a role their name doesn't announce, such as experiment variants.
+func (r *Registry) ValidateAs(name, role string) error {
+	sample, ok := samples[role]
+	if !ok {
+		return fmt.Errorf("unknown prompt template role %q", role)
+	}
+
+	_, _, err := r.Render(name, sample)
+
+	return err
+}
+
+// Get returns the current template with the given name.
+func (r *Registry) Get(name string) (*Template, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.templates[name]
+
+	return t, ok
+}
+
+// Versions lists the current version of every template, keyed by name.
+func (r *Registry) Versions() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make(map[string]string, len(r.templates))
+	for name, t := range r.templates {
+		out[name] = t.Version()
+	}
+
+	return out
+}
+
+// Render executes the named template and returns the text with the version that produced it.
+func (r *Registry) Render(name string, data any) (string, string, error) {
+	t, ok := r.Get(name)
+	if !ok {
+		return "", "", fmt.Errorf("prompt template %q not found", name)
+	}
+
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", "", fmt.Errorf("render prompt %s: %w", t.Version(), err)
+	}
+
+	return strings.TrimSpace(buf.String()), t.Version(), nil
+}
+
+// Watch polls the override directory and reloads when its contents change, until ctx is done.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	if r.dir == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reloadIfChanged()
+		}
+	}
+}
+
+func (r *Registry) reloadIfChanged() {
+	hash, err := fingerprint(r.dir)
+	if err != nil {
+		log.Printf("prompt templates not reloaded: %v", err)
+		return
+	}
+
+	r.mu.RLock()
+	seen := hash == r.dirHash || hash == r.failedHash
+	r.mu.RUnlock()
+
+	if seen {
+		return
+	}
+
+	if err := r.Reload(); err != nil {
+		// remember the broken state so it is reported once, not on every tick
+		r.mu.Lock()
+		r.failedHash = hash
+		r.mu.Unlock()
+
+		log.Printf("prompt templates not reloaded, keeping the previous set: %v", err)
+
+		return
+	}
+
+	log.Printf("prompt templates reloaded from %s", r.dir)
+}
+
+// fingerprint hashes the names and contents of the *.tmpl files in dir.
+func fingerprint(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(paths)
+
+	h := sha256.New()
+
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+
+		h.Write([]byte(path))
+		h.Write(b)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,187 @@
+package prompts
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTemplate(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name+".tmpl"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultRegistryRender(t *testing.T) {
+	text, version, err := Default().Render(DebateSystem, DebateData{Topic: "Cats are better than dogs", Stance: "CON"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if !strings.Contains(text, "Topic: Cats are better than dogs") || !strings.Contains(text, "Your stance: CON") {
+		t.Fatalf("unexpected prompt:\n%s", text)
+	}
+
+	if strings.Contains(text, `\n`) {
+		t.Fatal("prompt should contain real newlines, not escape sequences")
+	}
+
+	tmpl, _ := Default().Get(DebateSystem)
+	if version != tmpl.Version() || len(tmpl.Hash) != 12 || tmpl.Source != "embedded" {
+		t.Fatalf("unexpected version %q for %+v", version, tmpl)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, _, err := Default().Render("nope", nil); err == nil {
+		t.Fatal("expected an error for an unknown template")
+	}
+}
+
+func TestDirectoryOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, DebateSystem, "Argue {{.Stance}} on {{.Topic}}.")
+	writeTemplate(t, dir, "extra", "Extra prompt")
+
+	reg, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	text, version, err := reg.Render(DebateSystem, DebateData{Topic: "Tabs", Stance: "PRO"})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if text != "Argue PRO on Tabs." {
+		t.Fatalf("expected the override to win, got %q", text)
+	}
+
+	if version == Default().Versions()[DebateSystem] {
+		t.Fatal("the override should have its own version")
+	}
+
+	if _, ok := reg.Get("extra"); !ok {
+		t.Fatal("extra templates in the directory should be loaded")
+	}
+}
+
+func TestValidationRejectsBrokenTemplates(t *testing.T) {
+	broken := map[string]string{
+		"syntax":        "Argue {{.Stance",
+		"missing field": "Argue {{.Persona}}",
+		"empty":         "  \n",
+	}
+
+	for name, content := range broken {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTemplate(t, dir, DebateSystem, content)
+
+			if _, err := NewRegistry(dir); err == nil {
+				t.Fatal("expected validation to fail")
+			}
+		})
+	}
+}
+
+func TestValidationCoversRoleVariants(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "debate_system_v2", "Argue {{.Persona}}")
+
+	if _, err := NewRegistry(dir); err == nil {
+		t.Fatal("expected a debate system variant to be validated as one")
+	}
+
+	dir = t.TempDir()
+	writeTemplate(t, dir, "debate_system_v2", "Argue {{.Stance}} in the {{.Phase.Name}} phase")
+
+	if _, err := NewRegistry(dir); err != nil {
+		t.Fatalf("expected a valid variant to load: %v", err)
+	}
+}
+
+func TestHotReload(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, DebateSystem, "v1 {{.Topic}}")
+
+	reg, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go reg.Watch(ctx, 10*time.Millisecond)
+
+	v1 := reg.Versions()[DebateSystem]
+
+	// a broken edit is ignored and the previous template keeps serving
+	writeTemplate(t, dir, DebateSystem, "v2 {{.Missing}}")
+	time.Sleep(50 * time.Millisecond)
+
+	if got := reg.Versions()[DebateSystem]; got != v1 {
+		t.Fatalf("invalid template should not be loaded, version changed to %s", got)
+	}
+
+	writeTemplate(t, dir, DebateSystem, "v3 {{.Topic}}")
+
+	var text string
+
+	deadline := time.Now().Add(2 * time.Second)
+	for text != "v3 Tabs" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		text, _, _ = reg.Render(DebateSystem, DebateData{Topic: "Tabs"})
+	}
+
+	if text != "v3 Tabs" {
+		t.Fatalf("expected the reloaded template, got %q", text)
+	}
+}
+
+func TestRenderStyle(t *testing.T) {
+	text, _, err := Default().Render(DebateSystem, DebateData{
+		Topic:  "Tabs",
+		Stance: "PRO",
+		Style:  Style{Persona: "Argue like a lawyer.", Techniques: []string{"cross-examination"}, Length: "2-3 sentences"},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	for _, want := range []string{"Argue like a lawyer.", "cross-examination", "(2-3 sentences)"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in the prompt: %s", want, text)
+		}
+	}
+
+	if strings.Contains(text, "Be persuasive, calm, and structured.") {
+		t.Fatal("the persona should replace the default voice")
+	}
+}
+
+func TestRenderEvidence(t *testing.T) {
+	text, _, err := Default().Render(DebateSystem, DebateData{
+		Topic:    "Remote work",
+		Stance:   "PRO",
+		Evidence: []Passage{{Marker: 1, Source: "remote-work/survey.md", Text: "Commutes average 27 minutes."}},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	if !strings.Contains(text, "[1] (remote-work/survey.md) Commutes average 27 minutes.") || !strings.Contains(text, "cite them with their markers") {
+		t.Fatalf("expected the evidence with its marker in the prompt: %s", text)
+	}
+
+	text, _, _ = Default().Render(DebateSystem, DebateData{Topic: "Remote work", Stance: "PRO"})
+	if strings.Contains(text, "Evidence:") {
+		t.Fatalf("expected no evidence section without passages: %s", text)
+	}
+}
@@ -0,0 +1,116 @@
+package referee
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// Referee is the bot moderating a human-vs-human debate: after every round it summarizes the
+// exchange and flags off-topic drift and logical fallacies.
+type Referee struct {
+	engine bot.Engine
+}
+
+// New returns a referee that reviews rounds with engine.
+func New(engine bot.Engine) *Referee {
+	return &Referee{engine: engine}
+}
+
+// Review asks the engine for a structured review of the latest round.
+func (r *Referee) Review(ctx context.Context, conv *models.Conversation, round int) (*models.RoundReport, error) {
+	sys := `You moderate a debate between two people. Topic: ` + conv.Topic + `.
+Review the latest round below. Summarize it neutrally in one or two sentences, point out any
+argument that drifts away from the topic, and name any logical fallacy (e.g. strawman, ad hominem,
+slippery slope, false dilemma). Only flag clear cases; do not take sides.
+
+Reply with a JSON object only:
+{"summary": string,
+ "off_topic": [{"side": "pro" | "con", "explanation": string}],
+ "fallacies": [{"side": "pro" | "con", "fallacy": string, "explanation": string}]}`
+
+	out, err := r.engine.Complete(ctx, []map[string]string{
+		{"role": "system", "content": sys},
+		{"role": "user", "content": lastRound(conv)},
+	}, bot.WithJSON(), bot.WithTemperature(0), bot.WithMaxTokens(400))
+	if err != nil {
+		return nil, fmt.Errorf("referee: %w", err)
+	}
+
+	var report models.RoundReport
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &report); err != nil {
+		return nil, fmt.Errorf("invalid round report: %w", err)
+	}
+
+	report.Round = round
+	report.OffTopic = validSides(report.OffTopic)
+	report.Fallacies = validSides(report.Fallacies)
+
+	return &report, nil
+}
+
+// Message renders a report as the moderator's turn. A nil report (the review failed) still
+// marks the end of the round.
+func Message(round int, report *models.RoundReport) models.Message {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Round %d complete.", round)
+
+	if report != nil {
+		if report.Summary != "" {
+			b.WriteString(" " + report.Summary)
+		}
+
+		for _, n := range report.OffTopic {
+			fmt.Fprintf(&b, "\nOff-topic (%s): %s", strings.ToUpper(n.Side), n.Explanation)
+		}
+
+		for _, n := range report.Fallacies {
+			fmt.Fprintf(&b, "\nFallacy (%s, %s): %s", strings.ToUpper(n.Side), n.Fallacy, n.Explanation)
+		}
+	}
+
+	return models.Message{Role: models.RoleModerator, Message: b.String(), Report: report}
+}
+
+// lastRound returns the debaters' turns since the previous moderator review.
+func lastRound(conv *models.Conversation) string {
+	start := 0
+
+	for i := len(conv.Messages) - 1; i >= 0; i-- {
+		if conv.Messages[i].Role == models.RoleModerator {
+			start = i + 1
+			break
+		}
+	}
+
+	var b strings.Builder
+
+	for _, m := range conv.Messages[start:] {
+		if m.Flagged() || (m.Role != models.RolePro && m.Role != models.RoleCon) {
+			continue
+		}
+
+		b.WriteString(strings.ToUpper(m.Role) + ": " + m.Message + "\n")
+	}
+
+	return b.String()
+}
+
+func validSides(notes []models.RoundNote) []models.RoundNote {
+	out := notes[:0]
+
+	for _, n := range notes {
+		n.Side = strings.ToLower(strings.TrimSpace(n.Side))
+		if n.Side == models.RolePro || n.Side == models.RoleCon {
+			out = append(out, n)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,75 @@
+package referee
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// cannedEngine returns out from Complete and records the messages it was sent.
+type cannedEngine struct {
+	out  string
+	sent *[]map[string]string
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, _ ...bot.Option) (string, error) {
+	if e.sent != nil {
+		*e.sent = messages
+	}
+
+	return e.out, nil
+}
+
+func TestReview(t *testing.T) {
+	conv := models.NewConversation("c1")
+	conv.Topic = "Tabs are better than spaces"
+	conv.Mode = models.ModeHuman
+	conv.Append(models.Message{Role: models.RolePro, Message: "Old point"})
+	conv.Append(models.Message{Role: models.RoleCon, Message: "Old answer"})
+	conv.Append(models.Message{Role: models.RoleModerator, Message: "Round 1 complete."})
+	conv.Append(models.Message{Role: models.RolePro, Message: "Tabs are accessible."})
+	conv.Append(models.Message{Role: models.RoleCon, Message: "Tab users are lazy."})
+
+	var sent []map[string]string
+
+	out := `{"summary": "PRO argued accessibility; CON attacked tab users.",
+	 "off_topic": [],
+	 "fallacies": [{"side": "CON", "fallacy": "ad hominem", "explanation": "Attacks people."},
+	               {"side": "audience", "fallacy": "strawman", "explanation": "Not a debater."}]}`
+
+	report, err := New(cannedEngine{out: out, sent: &sent}).Review(context.Background(), conv, 2)
+	if err != nil {
+		t.Fatalf("review: %v", err)
+	}
+
+	if report.Round != 2 || len(report.Fallacies) != 1 || report.Fallacies[0].Side != models.RoleCon {
+		t.Fatalf("expected one CON fallacy in round 2, got %+v", report)
+	}
+
+	if round := sent[1]["content"]; strings.Contains(round, "Old point") || !strings.Contains(round, "CON: Tab users are lazy.") {
+		t.Fatalf("only the latest round should be reviewed:\n%s", round)
+	}
+
+	msg := Message(2, report)
+	if msg.Role != models.RoleModerator || !strings.HasPrefix(msg.Message, "Round 2 complete. PRO argued") || !strings.Contains(msg.Message, "Fallacy (CON, ad hominem)") {
+		t.Fatalf("unexpected moderator message %+v", msg)
+	}
+}
+
+func TestReviewErrors(t *testing.T) {
+	if _, err := New(cannedEngine{out: "Both did fine."}).Review(context.Background(), models.NewConversation("c1"), 1); err == nil {
+		t.Fatal("expected an error for a non-JSON review")
+	}
+
+	if msg := Message(1, nil); msg.Message != "Round 1 complete." || msg.Report != nil {
+		t.Fatalf("a failed review should still close the round, got %+v", msg)
+	}
+}
@@ -3,6 +3,8 @@ package storage
 import (
 	"context"
 	"errors"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,11 +14,21 @@ import (
 )
 
 type memoryStore struct {
-	mu   sync.RWMutex
-	data map[string]*models.Conversation
+	mu      sync.RWMutex
+	data    map[string]*models.Conversation
+	created map[string]time.Time // when each conversation was first saved
+	briefs  map[string]*models.Brief
+	topics  map[string]models.Topic
 }
 
-func NewMemoryStore() Store { return &memoryStore{data: map[string]*models.Conversation{}} }
+func NewMemoryStore() Store {
+	return &memoryStore{
+		data:    map[string]*models.Conversation{},
+		created: map[string]time.Time{},
+		briefs:  map[string]*models.Brief{},
+		topics:  seedCatalog(),
+	}
+}
 
 func (m *memoryStore) GetConversation(_ context.Context, id string) (*models.Conversation, error) {
 	m.mu.RLock()
@@ -27,8 +39,10 @@ func (m *memoryStore) GetConversation(_ context.Context, id string) (*models.Con
 		return nil, errors.New("not found")
 	}
 
-	// return a shallow copy to avoid external mutation of map pointer
+	// return a copy to avoid external mutation of map pointer; the history is copied too so
+	// edits and truncation only take effect once saved
 	copy := *c
+	copy.Messages = slices.Clone(c.Messages)
 
 	return &copy, nil
 }
@@ -39,8 +53,231 @@ func (m *memoryStore) SaveConversation(_ context.Context, conv *models.Conversat
 
 	// store a copy
 	copy := *conv
+	copy.Messages = slices.Clone(conv.Messages)
+
+	if stored, ok := m.data[conv.ID]; ok {
+		keepStored(&copy, stored)
+	}
+
 	m.data[conv.ID] = &copy
 
+	if _, ok := m.created[conv.ID]; !ok {
+		m.created[conv.ID] = time.Now()
+	}
+
+	return nil
+}
+
+// update applies fn to the stored conversation under the lock (memory implementation)
+func (m *memoryStore) update(id string, fn func(*models.Conversation)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.data[id]
+	if !ok {
+		return errors.New("not found")
+	}
+
+	fn(c)
+
+	return nil
+}
+
+// SaveSummary sets the title and summary of the stored conversation (memory implementation)
+func (m *memoryStore) SaveSummary(_ context.Context, id, title string, summary *models.Summary) error {
+	return m.update(id, func(c *models.Conversation) {
+		c.Title = title
+		c.Summary = summary
+	})
+}
+
+// SaveArgumentMap sets the argument map of the stored conversation (memory implementation)
+func (m *memoryStore) SaveArgumentMap(_ context.Context, id string, am *models.ArgumentMap) error {
+	return m.update(id, func(c *models.Conversation) { c.ArgumentMap = am })
+}
+
+// SaveJudgement sets the verdict of the stored conversation (memory implementation)
+func (m *memoryStore) SaveJudgement(_ context.Context, id string, j *models.Judgement) error {
+	return m.update(id, func(c *models.Conversation) { c.Judgement = j })
+}
+
+// SaveRating sets the rating of the stored conversation (memory implementation)
+func (m *memoryStore) SaveRating(_ context.Context, id string, rating int) error {
+	return m.update(id, func(c *models.Conversation) { c.Rating = rating })
+}
+
+// ForkConversation saves the branch (memory implementation)
+func (m *memoryStore) ForkConversation(ctx context.Context, branch *models.Conversation) error {
+	return m.SaveConversation(ctx, branch)
+}
+
+// ConversationTree finds the root through the parents, then collects its descendants (memory
+// implementation)
+func (m *memoryStore) ConversationTree(_ context.Context, id string) ([]Branch, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conv, ok := m.data[id]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	for conv.ParentID != "" {
+		parent, ok := m.data[conv.ParentID]
+		if !ok {
+			break
+		}
+
+		conv = parent
+	}
+
+	children := map[string][]*models.Conversation{}
+	for _, c := range m.data {
+		if c.ParentID != "" {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		}
+	}
+
+	tree := []Branch{newBranch(conv)}
+
+	for i := 0; i < len(tree); i++ {
+		branches := children[tree[i].ID]
+		slices.SortFunc(branches, func(a, b *models.Conversation) int { return strings.Compare(a.ID, b.ID) })
+
+		for _, c := range branches {
+			tree = append(tree, newBranch(c))
+		}
+	}
+
+	return tree, nil
+}
+
+// TopicDescription looks up a topic's description by name (memory implementation)
+func (m *memoryStore) TopicDescription(_ context.Context, name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := topicByName(m.catalog(), name)
+	if !ok {
+		return "", ErrTopicNotFound
+	}
+
+	return t.Description, nil
+}
+
+// GetTopic looks up a topic by ID (memory implementation)
+func (m *memoryStore) GetTopic(_ context.Context, id string) (*models.Topic, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.topics[id]
+	if !ok {
+		return nil, ErrTopicNotFound
+	}
+
+	return &t, nil
+}
+
+// ListTopics lists the catalog (memory implementation)
+func (m *memoryStore) ListTopics(_ context.Context, filter TopicFilter) ([]models.Topic, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return filterTopics(m.catalog(), filter), nil
+}
+
+// TopicCategories counts the active topics per category (memory implementation)
+func (m *memoryStore) TopicCategories(_ context.Context) ([]models.TopicCategory, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return topicCategories(m.catalog()), nil
+}
+
+// CreateTopic adds a topic to the catalog (memory implementation)
+func (m *memoryStore) CreateTopic(_ context.Context, t *models.Topic) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t.ID = ulid.Make().String()
+	if err := checkTopic(m.catalog(), t); err != nil {
+		return err
+	}
+
+	m.topics[t.ID] = *t
+
+	return nil
+}
+
+// UpdateTopic replaces a topic of the catalog (memory implementation)
+func (m *memoryStore) UpdateTopic(_ context.Context, t *models.Topic) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.topics[t.ID]; !ok {
+		return ErrTopicNotFound
+	}
+
+	if err := checkTopic(m.catalog(), t); err != nil {
+		return err
+	}
+
+	m.topics[t.ID] = *t
+
+	return nil
+}
+
+// DeleteTopic removes a topic and its briefs (memory implementation). Conversations keep
+// their topic name.
+func (m *memoryStore) DeleteTopic(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.topics[id]; !ok {
+		return ErrTopicNotFound
+	}
+
+	delete(m.topics, id)
+
+	for key := range m.briefs {
+		if strings.HasPrefix(key, briefKey(id, "")) {
+			delete(m.briefs, key)
+		}
+	}
+
+	return nil
+}
+
+// catalog returns the topics in no particular order; callers hold the lock.
+func (m *memoryStore) catalog() []models.Topic {
+	topics := make([]models.Topic, 0, len(m.topics))
+	for _, t := range m.topics {
+		topics = append(topics, t)
+	}
+
+	return topics
+}
+
+// GetBrief returns a cached brief (memory implementation)
+func (m *memoryStore) GetBrief(_ context.Context, topicID, promptVersion string) (*models.Brief, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b, ok := m.briefs[briefKey(topicID, promptVersion)]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+
+	return b, nil
+}
+
+// SaveBrief caches a brief (memory implementation)
+func (m *memoryStore) SaveBrief(_ context.Context, b *models.Brief) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.briefs[briefKey(b.TopicID, b.PromptVersion)] = b
+
 	return nil
 }
 
@@ -52,10 +289,11 @@ func (m *memoryStore) CreateConversation(_ context.Context, topicName, botStance
 	id := ulid.Make().String()
 
 	conv := &models.Conversation{
-		ID:       id,
-		Topic:    topicName,
-		Stance:   botStance,
-		Messages: make([]models.Message, 0),
+		ID:         id,
+		Topic:      topicName,
+		Stance:     botStance,
+		UserStance: models.OppositeStance(botStance),
+		Messages:   make([]models.Message, 0),
 	}
 
 	// Save the conversation
@@ -67,7 +305,7 @@ func (m *memoryStore) CreateConversation(_ context.Context, topicName, botStance
 }
 
 // ListConversations lists conversations (memory implementation)
-func (m *memoryStore) ListConversations(_ context.Context, limit, offset int) ([]ConversationSummary, error) {
+func (m *memoryStore) ListConversations(_ context.Context, viewer string, limit, offset int) ([]ConversationSummary, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
@@ -75,6 +313,10 @@ func (m *memoryStore) ListConversations(_ context.Context, limit, offset int) ([
 	count := 0
 
 	for _, conv := range m.data {
+		if !conv.CanRead(viewer) {
+			continue
+		}
+
 		if count < offset {
 			count++
 			continue
@@ -88,9 +330,10 @@ func (m *memoryStore) ListConversations(_ context.Context, limit, offset int) ([
 			ID:           conv.ID,
 			TopicName:    conv.Topic,
 			BotStance:    conv.Stance,
-			Title:        "Debate: " + conv.Topic + " (" + conv.Stance + ")",
+			Title:        conversationTitle(conv),
+			Summary:      summaryText(conv),
 			MessageCount: len(conv.Messages),
-			CreatedAt:    time.Now(), // Memory store doesn't track creation time
+			CreatedAt:    m.created[conv.ID],
 			UpdatedAt:    time.Now(),
 		})
 		count++
@@ -99,24 +342,35 @@ func (m *memoryStore) ListConversations(_ context.Context, limit, offset int) ([
 	return conversations, nil
 }
 
-// GetPopularTopics gets popular topics (memory implementation)
-func (m *memoryStore) GetPopularTopics(_ context.Context, limit int) ([]string, error) {
+// GetPopularTopics counts the debates started in the window (memory implementation)
+func (m *memoryStore) GetPopularTopics(_ context.Context, q PopularTopicsQuery) ([]TopicCount, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	topicCount := make(map[string]int)
+	now := time.Now()
+
+	var counts []topicCount
+
 	for _, conv := range m.data {
-		topicCount[conv.Topic]++
+		count, previous := q.tally(m.created[conv.ID], now)
+		counts = append(counts, topicCount{id: conv.TopicID, name: conv.Topic, count: count, previous: previous})
 	}
 
-	// Simple implementation - just return the first N topics
-	var topics []string
-	for topic := range topicCount {
-		topics = append(topics, topic)
-		if len(topics) >= limit {
-			break
+	return rankTopics(counts, m.catalog(), q), nil
+}
+
+// ExperimentStats aggregates outcomes per variant (memory implementation)
+func (m *memoryStore) ExperimentStats(_ context.Context, experiment string) ([]VariantStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := map[string]*VariantStats{}
+
+	for _, conv := range m.data {
+		if conv.Experiment != nil && conv.Experiment.Experiment == experiment {
+			addConversation(stats, conv)
 		}
 	}
 
-	return topics, nil
+	return sortedStats(stats), nil
 }
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/nikoremi97/debate/internal/models"
 )
@@ -77,3 +80,293 @@ func TestMemoryStoreConcurrency(t *testing.T) {
 		<-done
 	}
 }
+
+func TestMemoryStoreConversationTree(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	root := models.NewConversation("root")
+	root.Append(models.Message{Role: "user", Message: "One"})
+	root.Append(models.Message{Role: "bot", Message: "Two"})
+
+	if err := store.SaveConversation(ctx, root); err != nil {
+		t.Fatalf("save conversation should succeed: %v", err)
+	}
+
+	for _, fork := range []struct{ parent, id string }{{"root", "b"}, {"root", "a"}, {"a", "c"}} {
+		parent, err := store.GetConversation(ctx, fork.parent)
+		if err != nil {
+			t.Fatalf("get conversation should succeed: %v", err)
+		}
+
+		if err := store.ForkConversation(ctx, parent.Fork(fork.id, 0)); err != nil {
+			t.Fatalf("fork should succeed: %v", err)
+		}
+	}
+
+	tree, err := store.ConversationTree(ctx, "c")
+	if err != nil {
+		t.Fatalf("conversation tree should succeed: %v", err)
+	}
+
+	var order []string
+	for _, b := range tree {
+		order = append(order, b.ID)
+	}
+
+	if fmt.Sprint(order) != "[root a b c]" {
+		t.Fatalf("expected the root, its branches and then theirs, got %v", order)
+	}
+
+	if tree[3].ParentID != "a" || tree[3].ForkedFrom != root.Messages[0].ID || tree[3].MessageCount != 1 {
+		t.Fatalf("expected c to branch off a at the first message, got %+v", tree[3])
+	}
+
+	if _, err := store.ConversationTree(ctx, "non-existent"); err == nil {
+		t.Fatal("tree of a non-existent conversation should fail")
+	}
+}
+
+func TestMemoryStoreSummarySave(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	conv, _ := store.CreateConversation(ctx, "Remote work", "PRO")
+
+	// a turn is loaded before the background summary lands and saved after it
+	stale, _ := store.GetConversation(ctx, conv.ID)
+
+	if err := store.SaveSummary(ctx, conv.ID, "Remote work", &models.Summary{Text: "So far", UpTo: "x"}); err != nil {
+		t.Fatalf("save summary: %v", err)
+	}
+
+	stale.Append(models.Message{Role: "user", Message: "A new turn"})
+	_ = store.SaveConversation(ctx, stale)
+
+	got, _ := store.GetConversation(ctx, conv.ID)
+	if len(got.Messages) != 1 || got.Title != "Remote work" || got.Summary == nil || got.Summary.Text != "So far" {
+		t.Fatalf("expected the turn and the summary, got %+v", got)
+	}
+
+	if err := store.SaveSummary(ctx, "missing", "", nil); err == nil {
+		t.Fatal("expected an error for an unknown conversation")
+	}
+}
+
+func TestMemoryStoreListingHidesUnreadableSummaries(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	conv := models.NewConversation("human-1")
+	conv.Topic = "Tabs"
+	conv.Mode = models.ModeHuman
+	conv.Join("alice", "PRO")
+	_ = store.SaveConversation(ctx, conv)
+	_ = store.SaveSummary(ctx, conv.ID, "Alice's private title", &models.Summary{Text: "What Alice said"})
+
+	for viewer, visible := range map[string]bool{"alice": true, "mallory": false, "": false} {
+		list, err := store.ListConversations(ctx, viewer, 10, 0)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+
+		leaked := fmt.Sprintf("%+v", list)
+		if strings.Contains(leaked, "private title") != visible || strings.Contains(leaked, "What Alice said") != visible {
+			t.Fatalf("%q listing: expected the title and summary visible=%v, got %s", viewer, visible, leaked)
+		}
+	}
+}
+
+func TestMemoryStoreArgumentMapSave(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	conv, _ := store.CreateConversation(ctx, "Remote work", "PRO")
+
+	// a turn lands while the map is being built from an older copy
+	stale, _ := store.GetConversation(ctx, conv.ID)
+	conv.Append(models.Message{Role: "user", Message: "A new turn"})
+	_ = store.SaveConversation(ctx, conv)
+
+	if err := store.SaveArgumentMap(ctx, conv.ID, &models.ArgumentMap{UpTo: "x"}); err != nil {
+		t.Fatalf("save argument map: %v", err)
+	}
+
+	got, _ := store.GetConversation(ctx, conv.ID)
+	if len(got.Messages) != 1 || got.ArgumentMap == nil {
+		t.Fatalf("expected the turn and the map, got %+v", got)
+	}
+
+	// and saving the older copy doesn't revert the map
+	_ = store.SaveConversation(ctx, stale)
+
+	if got, _ = store.GetConversation(ctx, conv.ID); got.ArgumentMap == nil {
+		t.Fatal("expected the map to survive a stale save")
+	}
+
+	if err := store.SaveArgumentMap(ctx, "missing", nil); err == nil {
+		t.Fatal("expected an error for an unknown conversation")
+	}
+}
+
+func TestMemoryStoreJudgementSave(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	conv, _ := store.CreateConversation(ctx, "Remote work", "PRO")
+
+	// a turn lands while the judge is thinking about an older copy
+	stale, _ := store.GetConversation(ctx, conv.ID)
+	conv.Append(models.Message{Role: "user", Message: "A last word"})
+	_ = store.SaveConversation(ctx, conv)
+
+	if err := store.SaveJudgement(ctx, conv.ID, &models.Judgement{Winner: "pro"}); err != nil {
+		t.Fatalf("save judgement: %v", err)
+	}
+
+	_ = store.SaveConversation(ctx, stale)
+
+	got, _ := store.GetConversation(ctx, conv.ID)
+	if got.Judgement == nil || got.Judgement.Winner != "pro" {
+		t.Fatalf("expected the verdict to survive a stale save, got %+v", got.Judgement)
+	}
+
+	if err := store.SaveJudgement(ctx, "missing", nil); err == nil {
+		t.Fatal("expected an error for an unknown conversation")
+	}
+}
+
+func TestMemoryStoreRatingSave(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	conv, _ := store.CreateConversation(ctx, "Remote work", "PRO")
+	stale, _ := store.GetConversation(ctx, conv.ID)
+
+	if err := store.SaveRating(ctx, conv.ID, 5); err != nil {
+		t.Fatalf("save rating: %v", err)
+	}
+
+	_ = store.SaveConversation(ctx, stale)
+
+	if got, _ := store.GetConversation(ctx, conv.ID); got.Rating != 5 {
+		t.Fatalf("expected the rating to survive a stale save, got %d", got.Rating)
+	}
+
+	if err := store.SaveRating(ctx, "missing", 1); err == nil {
+		t.Fatal("expected an error for an unknown conversation")
+	}
+}
+
+func TestMemoryStorePopularTopics(t *testing.T) {
+	store := NewMemoryStore().(*memoryStore)
+	ctx := context.Background()
+	day := 24 * time.Hour
+
+	for _, c := range []struct {
+		topic, topicID string
+		age            time.Duration
+	}{
+		{"Should AI be regulated?", "01HZ0000000000000000000001", time.Hour},
+		{"ai regulation", "01HZ0000000000000000000001", 2 * time.Hour},
+		{"Pineapple on pizza", "", time.Hour},
+		{"pineapple on pizza!", "", 30 * time.Hour},
+		{"Pineapple on pizza", "", 40 * time.Hour},
+		{"Pineapple on pizza", "", 45 * time.Hour},
+		{"Space", "", 10 * day},
+	} {
+		conv, err := store.CreateConversation(ctx, c.topic, "PRO")
+		if err != nil {
+			t.Fatalf("create: %v", err)
+		}
+
+		conv.TopicID = c.topicID
+		if err := store.SaveConversation(ctx, conv); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+
+		store.created[conv.ID] = time.Now().Add(-c.age)
+	}
+
+	names := func(topics []TopicCount) string {
+		var out []string
+		for _, t := range topics {
+			out = append(out, fmt.Sprintf("%s=%d", t.Name, t.Count))
+		}
+
+		return strings.Join(out, ", ")
+	}
+
+	for _, tt := range []struct {
+		q    PopularTopicsQuery
+		want string
+	}{
+		{PopularTopicsQuery{Limit: 2}, "Pineapple on pizza=4, Artificial Intelligence Regulation=2"},
+		{PopularTopicsQuery{Window: day}, "Artificial Intelligence Regulation=2, Pineapple on pizza=1"},
+		{PopularTopicsQuery{Window: 7 * day}, "Pineapple on pizza=4, Artificial Intelligence Regulation=2"},
+		{PopularTopicsQuery{Window: day, Trending: true}, "Artificial Intelligence Regulation=2"},
+	} {
+		topics, err := store.GetPopularTopics(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("popular topics: %v", err)
+		}
+
+		if got := names(topics); got != tt.want {
+			t.Errorf("GetPopularTopics(%+v) = %s, expected %s", tt.q, got, tt.want)
+		}
+	}
+
+	topics, _ := store.GetPopularTopics(ctx, PopularTopicsQuery{Window: day, Trending: true})
+	if topics[0].TopicID != "01HZ0000000000000000000001" || topics[0].Previous == nil || *topics[0].Previous != 0 {
+		t.Fatalf("expected the catalog topic with no debates the day before, got %+v", topics[0])
+	}
+}
+
+func TestMemoryStoreTopicCatalog(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	seeded, err := store.ListTopics(ctx, TopicFilter{Category: "Technology"})
+	if err != nil || len(seeded) != 3 || seeded[0].Name != "Artificial Intelligence Regulation" || !seeded[0].Active {
+		t.Fatalf("expected the seeded technology topics by name, got %+v (%v)", seeded, err)
+	}
+
+	topic := &models.Topic{Name: "Four-Day Work Week", Category: "business", Active: true}
+	if err := store.CreateTopic(ctx, topic); err != nil || topic.ID == "" {
+		t.Fatalf("create topic: %v (%+v)", err, topic)
+	}
+
+	if err := store.CreateTopic(ctx, &models.Topic{Name: "four-day work week"}); !errors.Is(err, ErrTopicExists) {
+		t.Fatalf("expected a duplicate name to be rejected, got %v", err)
+	}
+
+	topic.Active = false
+	if err := store.UpdateTopic(ctx, topic); err != nil {
+		t.Fatalf("update topic: %v", err)
+	}
+
+	if business, _ := store.ListTopics(ctx, TopicFilter{Category: "business"}); len(business) != 1 {
+		t.Fatalf("expected the inactive topic to be left out, got %+v", business)
+	}
+
+	if all, _ := store.ListTopics(ctx, TopicFilter{IncludeInactive: true}); len(all) != len(seedTopics)+1 {
+		t.Fatalf("expected the inactive topic on request, got %d topics", len(all))
+	}
+
+	categories, _ := store.TopicCategories(ctx)
+	if len(categories) != 6 || categories[0] != (models.TopicCategory{Name: "business", Topics: 1}) {
+		t.Fatalf("unexpected categories %+v", categories)
+	}
+
+	if err := store.DeleteTopic(ctx, topic.ID); err != nil {
+		t.Fatalf("delete topic: %v", err)
+	}
+
+	if _, err := store.GetTopic(ctx, topic.ID); !errors.Is(err, ErrTopicNotFound) {
+		t.Fatalf("expected the deleted topic to be gone, got %v", err)
+	}
+
+	if err := store.UpdateTopic(ctx, topic); !errors.Is(err, ErrTopicNotFound) {
+		t.Fatalf("expected updating a deleted topic to fail, got %v", err)
+	}
+}
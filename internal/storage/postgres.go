@@ -4,13 +4,15 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"reflect"
 	"time"
 
 	"github.com/nikoremi97/debate/internal/models"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 	"github.com/oklog/ulid/v2"
 )
 
@@ -33,27 +35,64 @@ func NewPostgresStore(connStr string) (*PostgresStore, error) {
 
 func (s *PostgresStore) GetConversation(ctx context.Context, id string) (*models.Conversation, error) {
 	query := `
-		SELECT c.id, c.topic_name, c.bot_stance,
+		SELECT c.id, c.topic_name, COALESCE(c.topic_id, ''), c.bot_stance, COALESCE(c.user_stance, ''), c.switch_every,
+		       COALESCE(c.persona, ''), COALESCE(c.difficulty, ''), c.coach, c.debate_state, c.judgement, c.argument_map, c.autoplay,
+		       COALESCE(c.mode, ''), c.participants, c.spectators, c.rounds, c.auto_judge,
+		       COALESCE(c.experiment_name, ''), COALESCE(c.experiment_variant, ''), COALESCE(c.rating, 0), c.error_count,
+		       COALESCE(c.parent_id, ''), COALESCE(c.forked_from, ''), COALESCE(c.title, ''), c.summary,
 		       COALESCE(json_agg(
 		           json_build_object(
+		               'id', m.id,
 		               'role', m.role,
 		               'message', m.content,
+		               'event', COALESCE(m.event, ''),
+		               'moderation', m.moderation,
+		               'prompt_version', COALESCE(m.prompt_version, ''),
+		               'author', COALESCE(m.author, ''),
+		               'report', m.report,
+		               'citations', m.citations,
+		               'tool_calls', m.tool_calls,
+		               'fallacies', m.fallacies,
 		               'ts', extract(epoch from m.created_at) * 1000
-		           ) ORDER BY m.created_at
+		           ) ORDER BY m.created_at, m.id
 		       ) FILTER (WHERE m.id IS NOT NULL), '[]'::json) as messages
 		FROM conversations c
 		LEFT JOIN messages m ON c.id = m.conversation_id
 		WHERE c.id = $1
-		GROUP BY c.id, c.topic_name, c.bot_stance
+		GROUP BY c.id
 	`
 
 	var conv models.Conversation
-	var messagesJSON string
+	var messagesJSON, experimentName, experimentVariant string
+	var debateJSON, judgementJSON, argumentMapJSON, autoplayJSON, participantsJSON, spectatorsJSON, summaryJSON []byte
 
 	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&conv.ID,
 		&conv.Topic,
+		&conv.TopicID,
 		&conv.Stance,
+		&conv.UserStance,
+		&conv.SwitchEvery,
+		&conv.Persona,
+		&conv.Difficulty,
+		&conv.Coach,
+		&debateJSON,
+		&judgementJSON,
+		&argumentMapJSON,
+		&autoplayJSON,
+		&conv.Mode,
+		&participantsJSON,
+		&spectatorsJSON,
+		&conv.Rounds,
+		&conv.AutoJudge,
+		&experimentName,
+		&experimentVariant,
+		&conv.Rating,
+		&conv.ErrorCount,
+		&conv.ParentID,
+		&conv.ForkedFrom,
+		&conv.Title,
+		&summaryJSON,
 		&messagesJSON,
 	)
 
@@ -65,6 +104,52 @@ func (s *PostgresStore) GetConversation(ctx context.Context, id string) (*models
 		return nil, fmt.Errorf("failed to get conversation: %w", err)
 	}
 
+	if experimentName != "" {
+		conv.Experiment = &models.ExperimentAssignment{Experiment: experimentName, Variant: experimentVariant}
+	}
+
+	if debateJSON != nil {
+		if err := json.Unmarshal(debateJSON, &conv.Debate); err != nil {
+			return nil, fmt.Errorf("failed to parse debate state: %w", err)
+		}
+	}
+
+	if judgementJSON != nil {
+		if err := json.Unmarshal(judgementJSON, &conv.Judgement); err != nil {
+			return nil, fmt.Errorf("failed to parse judgement: %w", err)
+		}
+	}
+
+	if argumentMapJSON != nil {
+		if err := json.Unmarshal(argumentMapJSON, &conv.ArgumentMap); err != nil {
+			return nil, fmt.Errorf("failed to parse argument map: %w", err)
+		}
+	}
+
+	if summaryJSON != nil {
+		if err := json.Unmarshal(summaryJSON, &conv.Summary); err != nil {
+			return nil, fmt.Errorf("failed to parse summary: %w", err)
+		}
+	}
+
+	if autoplayJSON != nil {
+		if err := json.Unmarshal(autoplayJSON, &conv.Autoplay); err != nil {
+			return nil, fmt.Errorf("failed to parse autoplay settings: %w", err)
+		}
+	}
+
+	if participantsJSON != nil {
+		if err := json.Unmarshal(participantsJSON, &conv.Participants); err != nil {
+			return nil, fmt.Errorf("failed to parse participants: %w", err)
+		}
+	}
+
+	if spectatorsJSON != nil {
+		if err := json.Unmarshal(spectatorsJSON, &conv.Spectators); err != nil {
+			return nil, fmt.Errorf("failed to parse spectators: %w", err)
+		}
+	}
+
 	// Parse messages from JSON
 	if err := json.Unmarshal([]byte(messagesJSON), &conv.Messages); err != nil {
 		return nil, fmt.Errorf("failed to parse messages: %w", err)
@@ -101,14 +186,219 @@ func (s *PostgresStore) SaveConversation(ctx context.Context, c *models.Conversa
 	return tx.Commit()
 }
 
+// SaveSummary only touches the title and summary columns; SaveConversation never writes them,
+// so a turn saved while the summary was being written doesn't clobber it.
+func (s *PostgresStore) SaveSummary(ctx context.Context, id, title string, summary *models.Summary) error {
+	summaryJSON, err := nullableJSON(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "UPDATE conversations SET title = $2, summary = $3 WHERE id = $1", id, title, summaryJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save summary: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// SaveArgumentMap only touches the argument_map column, which SaveConversation never writes.
+func (s *PostgresStore) SaveArgumentMap(ctx context.Context, id string, m *models.ArgumentMap) error {
+	argumentMapJSON, err := nullableJSON(m)
+	if err != nil {
+		return fmt.Errorf("failed to encode argument map: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "UPDATE conversations SET argument_map = $2 WHERE id = $1", id, argumentMapJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save argument map: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// SaveJudgement only touches the judgement column. SaveConversation writes it too, for debates
+// judged within a turn, but never clears it.
+func (s *PostgresStore) SaveJudgement(ctx context.Context, id string, j *models.Judgement) error {
+	judgementJSON, err := nullableJSON(j)
+	if err != nil {
+		return fmt.Errorf("failed to encode judgement: %w", err)
+	}
+
+	res, err := s.db.ExecContext(ctx, "UPDATE conversations SET judgement = $2 WHERE id = $1", id, judgementJSON)
+	if err != nil {
+		return fmt.Errorf("failed to save judgement: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// SaveRating only touches the rating column. SaveConversation never clears it.
+func (s *PostgresStore) SaveRating(ctx context.Context, id string, rating int) error {
+	res, err := s.db.ExecContext(ctx, "UPDATE conversations SET rating = $2 WHERE id = $1", id, rating)
+	if err != nil {
+		return fmt.Errorf("failed to save rating: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("conversation not found")
+	}
+
+	return nil
+}
+
+// ForkConversation inserts the branch and copies its history from the parent's rows, so the
+// messages never leave the database.
+func (s *PostgresStore) ForkConversation(ctx context.Context, branch *models.Conversation) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			// Log rollback error but don't return it to avoid masking the original error
+			log.Printf("Failed to rollback transaction: %v", rollbackErr)
+		}
+	}()
+
+	insertConv := `
+		INSERT INTO conversations (id, topic_name, bot_stance, user_stance, title, parent_id, forked_from)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	title := fmt.Sprintf("Debate: %s (%s)", branch.Topic, branch.Stance)
+
+	_, err = tx.ExecContext(ctx, insertConv, branch.ID, branch.Topic, branch.Stance, branch.UserStance, title, branch.ParentID, branch.ForkedFrom)
+	if err != nil {
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	if err := s.updateConversationMetadata(ctx, tx, branch); err != nil {
+		return err
+	}
+
+	copyMessages := `
+		INSERT INTO messages (id, conversation_id, role, content, event, moderation, prompt_version, author, report, citations, tool_calls, fallacies, created_at)
+		SELECT id, $1, role, content, event, moderation, prompt_version, author, report, citations, tool_calls, fallacies, created_at
+		FROM messages
+		WHERE conversation_id = $2
+		ORDER BY created_at, id
+		LIMIT $3
+	`
+
+	res, err := tx.ExecContext(ctx, copyMessages, branch.ID, branch.ParentID, len(branch.Messages))
+	if err != nil {
+		return fmt.Errorf("failed to copy messages: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n != int64(len(branch.Messages)) {
+		return fmt.Errorf("failed to copy messages: expected %d, copied %d", len(branch.Messages), n)
+	}
+
+	return tx.Commit()
+}
+
+// ConversationTree walks up to the root and back down through parent_id.
+func (s *PostgresStore) ConversationTree(ctx context.Context, id string) ([]Branch, error) {
+	query := `
+		WITH RECURSIVE ancestors AS (
+		    SELECT id, parent_id FROM conversations WHERE id = $1
+		    UNION ALL
+		    SELECT c.id, c.parent_id FROM conversations c JOIN ancestors a ON c.id = a.parent_id
+		), tree AS (
+		    SELECT id FROM ancestors WHERE parent_id IS NULL
+		    UNION ALL
+		    SELECT c.id FROM conversations c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT c.id, COALESCE(c.parent_id, ''), COALESCE(c.forked_from, ''), c.topic_name, c.message_count
+		FROM conversations c
+		JOIN tree t ON c.id = t.id
+		ORDER BY c.created_at, c.id
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conversation tree: %w", err)
+	}
+	defer rows.Close()
+
+	var tree []Branch
+
+	for rows.Next() {
+		var b Branch
+
+		if err := rows.Scan(&b.ID, &b.ParentID, &b.ForkedFrom, &b.Topic, &b.MessageCount); err != nil {
+			return nil, fmt.Errorf("failed to scan branch: %w", err)
+		}
+
+		tree = append(tree, b)
+	}
+
+	if len(tree) == 0 {
+		return nil, fmt.Errorf("conversation not found")
+	}
+
+	return tree, rows.Err()
+}
+
 func (s *PostgresStore) updateConversationMetadata(ctx context.Context, tx *sql.Tx, c *models.Conversation) error {
 	updateConv := `
 		UPDATE conversations
-		SET topic_name = $2, bot_stance = $3, message_count = $4, updated_at = NOW()
+		SET topic_name = $2, bot_stance = $3, user_stance = $4, switch_every = $5, message_count = $6,
+		    experiment_name = NULLIF($7, ''), experiment_variant = NULLIF($8, ''), rating = COALESCE(NULLIF($9, 0), rating), error_count = $10,
+		    persona = NULLIF($11, ''), difficulty = NULLIF($12, ''), debate_state = $13, judgement = COALESCE($14, judgement), autoplay = $15,
+		    mode = NULLIF($16, ''), participants = $17, rounds = $18, auto_judge = $19, spectators = $20,
+		    coach = $21, topic_id = NULLIF($22, ''),
+		    updated_at = NOW()
 		WHERE id = $1
 	`
 
-	_, err := tx.ExecContext(ctx, updateConv, c.ID, c.Topic, c.Stance, len(c.Messages))
+	var experimentName, experimentVariant string
+	if c.Experiment != nil {
+		experimentName, experimentVariant = c.Experiment.Experiment, c.Experiment.Variant
+	}
+
+	debateJSON, err := nullableJSON(c.Debate)
+	if err != nil {
+		return fmt.Errorf("failed to encode debate state: %w", err)
+	}
+
+	judgementJSON, err := nullableJSON(c.Judgement)
+	if err != nil {
+		return fmt.Errorf("failed to encode judgement: %w", err)
+	}
+
+	autoplayJSON, err := nullableJSON(c.Autoplay)
+	if err != nil {
+		return fmt.Errorf("failed to encode autoplay settings: %w", err)
+	}
+
+	participantsJSON, err := nullableJSON(c.Participants)
+	if err != nil {
+		return fmt.Errorf("failed to encode participants: %w", err)
+	}
+
+	spectatorsJSON, err := nullableJSON(c.Spectators)
+	if err != nil {
+		return fmt.Errorf("failed to encode spectators: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, updateConv, c.ID, c.Topic, c.Stance, c.UserStance, c.SwitchEvery, len(c.Messages),
+		experimentName, experimentVariant, c.Rating, c.ErrorCount, c.Persona, c.Difficulty, debateJSON, judgementJSON, autoplayJSON,
+		c.Mode, participantsJSON, c.Rounds, c.AutoJudge, spectatorsJSON, c.Coach, c.TopicID)
 	if err != nil {
 		return fmt.Errorf("failed to update conversation: %w", err)
 	}
@@ -131,8 +421,8 @@ func (s *PostgresStore) insertMessages(ctx context.Context, tx *sql.Tx, c *model
 	}
 
 	insertMsg := `
-		INSERT INTO messages (conversation_id, role, content, created_at)
-		VALUES ($1, $2, $3, to_timestamp($4 / 1000.0))
+		INSERT INTO messages (id, conversation_id, role, content, event, moderation, prompt_version, author, report, citations, tool_calls, fallacies, created_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, $12, to_timestamp($13 / 1000.0))
 	`
 
 	stmt, err := tx.PrepareContext(ctx, insertMsg)
@@ -142,7 +432,38 @@ func (s *PostgresStore) insertMessages(ctx context.Context, tx *sql.Tx, c *model
 	defer stmt.Close()
 
 	for _, msg := range c.Messages {
-		_, err = stmt.ExecContext(ctx, c.ID, msg.Role, msg.Message, msg.TS)
+		moderationJSON, err := nullableJSON(msg.Moderation)
+		if err != nil {
+			return fmt.Errorf("failed to encode moderation verdict: %w", err)
+		}
+
+		reportJSON, err := nullableJSON(msg.Report)
+		if err != nil {
+			return fmt.Errorf("failed to encode round report: %w", err)
+		}
+
+		citationsJSON, err := nullableJSON(msg.Citations)
+		if err != nil {
+			return fmt.Errorf("failed to encode citations: %w", err)
+		}
+
+		toolCallsJSON, err := nullableJSON(msg.ToolCalls)
+		if err != nil {
+			return fmt.Errorf("failed to encode tool calls: %w", err)
+		}
+
+		fallaciesJSON, err := nullableJSON(msg.Fallacies)
+		if err != nil {
+			return fmt.Errorf("failed to encode fallacies: %w", err)
+		}
+
+		// messages stored before IDs existed get one now
+		id := msg.ID
+		if id == "" {
+			id = ulid.Make().String()
+		}
+
+		_, err = stmt.ExecContext(ctx, id, c.ID, msg.Role, msg.Message, msg.Event, moderationJSON, msg.PromptVersion, msg.Author, reportJSON, citationsJSON, toolCallsJSON, fallaciesJSON, msg.TS)
 		if err != nil {
 			return fmt.Errorf("failed to insert message: %w", err)
 		}
@@ -151,42 +472,70 @@ func (s *PostgresStore) insertMessages(ctx context.Context, tx *sql.Tx, c *model
 	return nil
 }
 
+// nullableJSON encodes v for a JSONB column, mapping nil to NULL.
+func nullableJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
+}
+
 func (s *PostgresStore) CreateConversation(ctx context.Context, topicName, botStance string) (*models.Conversation, error) {
 	// Generate ULID for the conversation
 	id := ulid.Make().String()
 
 	query := `
-		INSERT INTO conversations (id, topic_name, bot_stance, title)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO conversations (id, topic_name, bot_stance, user_stance, title)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING created_at
 	`
 
 	title := fmt.Sprintf("Debate: %s (%s)", topicName, botStance)
+	userStance := models.OppositeStance(botStance)
 
 	var createdAt time.Time
 
-	err := s.db.QueryRowContext(ctx, query, id, topicName, botStance, title).Scan(&createdAt)
+	err := s.db.QueryRowContext(ctx, query, id, topicName, botStance, userStance, title).Scan(&createdAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create conversation: %w", err)
 	}
 
 	return &models.Conversation{
-		ID:       id,
-		Topic:    topicName,
-		Stance:   botStance,
-		Messages: make([]models.Message, 0),
+		ID:         id,
+		Topic:      topicName,
+		Stance:     botStance,
+		UserStance: userStance,
+		Messages:   make([]models.Message, 0),
 	}, nil
 }
 
-func (s *PostgresStore) ListConversations(ctx context.Context, limit, offset int) ([]ConversationSummary, error) {
+func (s *PostgresStore) ListConversations(ctx context.Context, viewer string, limit, offset int) ([]ConversationSummary, error) {
+	// the WHERE clause is Conversation.CanRead: human debates are listed to their participants
+	// and spectators only
 	query := `
-		SELECT id, topic_name, bot_stance, title, message_count, created_at, updated_at
+		SELECT id, topic_name, bot_stance, title, COALESCE(summary->>'text', ''), message_count, created_at, updated_at
 		FROM conversations
+		WHERE mode IS DISTINCT FROM 'human'
+		   OR participants @> jsonb_build_array(jsonb_build_object('user_id', $3::text))
+		   OR ($3 <> '' AND spectators ? $3)
 		ORDER BY updated_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, limit, offset, viewer)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list conversations: %w", err)
 	}
@@ -202,6 +551,7 @@ func (s *PostgresStore) ListConversations(ctx context.Context, limit, offset int
 			&conv.TopicName,
 			&conv.BotStance,
 			&conv.Title,
+			&conv.Summary,
 			&conv.MessageCount,
 			&conv.CreatedAt,
 			&conv.UpdatedAt,
@@ -216,36 +566,279 @@ func (s *PostgresStore) ListConversations(ctx context.Context, limit, offset int
 	return conversations, nil
 }
 
-func (s *PostgresStore) GetPopularTopics(ctx context.Context, limit int) ([]string, error) {
+func (s *PostgresStore) GetPopularTopics(ctx context.Context, q PopularTopicsQuery) ([]TopicCount, error) {
+	// $1 is the window in seconds (0 for all time) and $2 how many windows back to count
 	query := `
-		SELECT topic_name, COUNT(*) as count
-		FROM conversations
-		GROUP BY topic_name
-		ORDER BY count DESC
-		LIMIT $1
+		SELECT COALESCE(c.topic_id, ''), COALESCE(t.name, c.topic_name),
+		       COUNT(*) FILTER (WHERE $1 = 0 OR c.created_at >= NOW() - make_interval(secs => $1)),
+		       COUNT(*) FILTER (WHERE $1 > 0 AND c.created_at < NOW() - make_interval(secs => $1))
+		FROM conversations c
+		LEFT JOIN topics t ON t.id = c.topic_id
+		WHERE $1 = 0 OR c.created_at >= NOW() - make_interval(secs => $1 * $2)
+		GROUP BY 1, 2
 	`
 
-	rows, err := s.db.QueryContext(ctx, query, limit)
+	windows := 1
+	if q.Trending {
+		windows = 2
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, int64(q.Window.Seconds()), windows)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get popular topics: %w", err)
 	}
 	defer rows.Close()
 
-	var topics []string
+	var counts []topicCount
 
 	for rows.Next() {
-		var topic string
-		var count int
+		var c topicCount
 
-		err := rows.Scan(&topic, &count)
+		err := rows.Scan(&c.id, &c.name, &c.count, &c.previous)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan topic: %w", err)
 		}
 
-		topics = append(topics, topic)
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get popular topics: %w", err)
+	}
+
+	return rankTopics(counts, nil, q), nil
+}
+
+func (s *PostgresStore) ExperimentStats(ctx context.Context, experiment string) ([]VariantStats, error) {
+	query := `
+		SELECT c.experiment_variant, COUNT(*), COALESCE(SUM(t.turns), 0),
+		       COUNT(c.rating), COALESCE(SUM(c.rating), 0), COALESCE(SUM(c.error_count), 0)
+		FROM conversations c
+		LEFT JOIN (
+		    SELECT conversation_id, COUNT(*) AS turns
+		    FROM messages
+		    WHERE role = 'bot' AND moderation IS NULL
+		    GROUP BY conversation_id
+		) t ON t.conversation_id = c.id
+		WHERE c.experiment_name = $1
+		GROUP BY c.experiment_variant
+		ORDER BY c.experiment_variant
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, experiment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiment stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []VariantStats
+
+	for rows.Next() {
+		var v VariantStats
+
+		if err := rows.Scan(&v.Variant, &v.Conversations, &v.Turns, &v.RatingCount, &v.RatingSum, &v.Errors); err != nil {
+			return nil, fmt.Errorf("failed to scan experiment stats: %w", err)
+		}
+
+		stats = append(stats, v)
+	}
+
+	return stats, nil
+}
+
+// TopicDescription looks up a topic's description in the topics table by name.
+func (s *PostgresStore) TopicDescription(ctx context.Context, name string) (string, error) {
+	var description string
+
+	err := s.db.QueryRowContext(ctx, "SELECT COALESCE(description, '') FROM topics WHERE lower(name) = lower($1)", name).Scan(&description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", ErrTopicNotFound
+		}
+
+		return "", fmt.Errorf("failed to get topic: %w", err)
+	}
+
+	return description, nil
+}
+
+// GetTopic looks up a topic in the topics table by ID.
+func (s *PostgresStore) GetTopic(ctx context.Context, id string) (*models.Topic, error) {
+	var t models.Topic
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, COALESCE(description, ''), COALESCE(category, ''), active FROM topics WHERE id = $1", id,
+	).Scan(&t.ID, &t.Name, &t.Description, &t.Category, &t.Active)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTopicNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get topic: %w", err)
+	}
+
+	return &t, nil
+}
+
+// ListTopics lists the topics table, ordered by name.
+func (s *PostgresStore) ListTopics(ctx context.Context, filter TopicFilter) ([]models.Topic, error) {
+	query := `
+		SELECT id, name, COALESCE(description, ''), COALESCE(category, ''), active
+		FROM topics
+		WHERE ($1 = '' OR lower(category) = lower($1)) AND (active OR $2)
+		ORDER BY lower(name)
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, filter.Category, filter.IncludeInactive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+	defer rows.Close()
+
+	topics := []models.Topic{}
+
+	for rows.Next() {
+		var t models.Topic
+		if err := rows.Scan(&t.ID, &t.Name, &t.Description, &t.Category, &t.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan topic: %w", err)
+		}
+
+		topics = append(topics, t)
+	}
+
+	return topics, rows.Err()
+}
+
+// TopicCategories counts the active topics per category.
+func (s *PostgresStore) TopicCategories(ctx context.Context) ([]models.TopicCategory, error) {
+	query := `
+		SELECT category, COUNT(*)
+		FROM topics
+		WHERE active AND COALESCE(category, '') <> ''
+		GROUP BY category
+		ORDER BY category
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topic categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []models.TopicCategory{}
+
+	for rows.Next() {
+		var c models.TopicCategory
+		if err := rows.Scan(&c.Name, &c.Topics); err != nil {
+			return nil, fmt.Errorf("failed to scan topic category: %w", err)
+		}
+
+		categories = append(categories, c)
 	}
 
-	return topics, nil
+	return categories, rows.Err()
+}
+
+// CreateTopic inserts a topic; the unique index on lower(name) rejects duplicate names.
+func (s *PostgresStore) CreateTopic(ctx context.Context, t *models.Topic) error {
+	t.ID = ulid.Make().String()
+
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO topics (id, name, description, category, active) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), $5)",
+		t.ID, t.Name, t.Description, t.Category, t.Active,
+	)
+
+	return topicWriteError(err, "create")
+}
+
+// UpdateTopic rewrites a topic.
+func (s *PostgresStore) UpdateTopic(ctx context.Context, t *models.Topic) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE topics SET name = $2, description = NULLIF($3, ''), category = NULLIF($4, ''), active = $5 WHERE id = $1",
+		t.ID, t.Name, t.Description, t.Category, t.Active,
+	)
+	if err != nil {
+		return topicWriteError(err, "update")
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTopicNotFound
+	}
+
+	return nil
+}
+
+// DeleteTopic deletes a topic. Its briefs go with it, and conversations keep their topic name
+// but lose the link.
+func (s *PostgresStore) DeleteTopic(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM topics WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete topic: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrTopicNotFound
+	}
+
+	return nil
+}
+
+// topicWriteError maps unique violations to ErrTopicExists.
+func topicWriteError(err error, op string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrTopicExists
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to %s topic: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetBrief returns the brief cached for a topic and prompt version.
+func (s *PostgresStore) GetBrief(ctx context.Context, topicID, promptVersion string) (*models.Brief, error) {
+	var b []byte
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT brief FROM topic_briefs WHERE topic_id = $1 AND prompt_version = $2", topicID, promptVersion,
+	).Scan(&b)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("brief not found")
+		}
+
+		return nil, fmt.Errorf("failed to get brief: %w", err)
+	}
+
+	var brief models.Brief
+
+	if err := json.Unmarshal(b, &brief); err != nil {
+		return nil, fmt.Errorf("failed to parse brief: %w", err)
+	}
+
+	return &brief, nil
+}
+
+// SaveBrief caches a brief, replacing the one made with the same prompt version.
+func (s *PostgresStore) SaveBrief(ctx context.Context, brief *models.Brief) error {
+	b, err := json.Marshal(brief)
+	if err != nil {
+		return fmt.Errorf("failed to encode brief: %w", err)
+	}
+
+	query := `
+		INSERT INTO topic_briefs (topic_id, prompt_version, brief)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (topic_id, prompt_version) DO UPDATE SET brief = EXCLUDED.brief, created_at = NOW()
+	`
+
+	if _, err := s.db.ExecContext(ctx, query, brief.TopicID, brief.PromptVersion, string(b)); err != nil {
+		return fmt.Errorf("failed to save brief: %w", err)
+	}
+
+	return nil
 }
 
 func (s *PostgresStore) Ping(ctx context.Context) error {
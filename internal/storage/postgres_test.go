@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/nikoremi97/debate/internal/models"
+	"github.com/oklog/ulid/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -78,6 +79,11 @@ func TestPostgresStore(t *testing.T) {
 	t.Run("GetPopularTopics_Limit", func(t *testing.T) {
 		testGetPopularTopicsLimit(ctx, t, store)
 	})
+
+	// Test ForkConversation and ConversationTree
+	t.Run("ForkConversation", func(t *testing.T) {
+		testForkConversation(ctx, t, store)
+	})
 }
 
 func testCreateConversation(ctx context.Context, t *testing.T, store *PostgresStore) {
@@ -191,7 +197,7 @@ func testListConversations(ctx context.Context, t *testing.T, store *PostgresSto
 	require.NoError(t, err)
 
 	// List conversations
-	conversations, err := store.ListConversations(ctx, 10, 0)
+	conversations, err := store.ListConversations(ctx, "", 10, 0)
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(conversations), 2)
 
@@ -217,6 +223,32 @@ func testListConversations(ctx context.Context, t *testing.T, store *PostgresSto
 	assert.True(t, found1, "Conversation 1 not found in list")
 	assert.True(t, found2, "Conversation 2 not found in list")
 
+	// a human debate, with its title and summary, is only listed to its readers
+	human, err := store.CreateConversation(ctx, "List Test Human", "PRO")
+	require.NoError(t, err)
+
+	human.Mode = models.ModeHuman
+	human.Join("alice", "PRO")
+	require.NoError(t, store.SaveConversation(ctx, human))
+	require.NoError(t, store.SaveSummary(ctx, human.ID, "Private title", &models.Summary{Text: "Private summary"}))
+
+	for viewer, visible := range map[string]bool{"alice": true, "mallory": false} {
+		conversations, err := store.ListConversations(ctx, viewer, 100, 0)
+		require.NoError(t, err)
+
+		found := false
+
+		for _, conv := range conversations {
+			if conv.ID == human.ID {
+				found = true
+			}
+		}
+
+		assert.Equal(t, visible, found, "human debate listed to %q", viewer)
+	}
+
+	cleanupConversation(t, store, human.ID)
+
 	// Clean up
 	cleanupConversation(t, store, conv1.ID)
 	cleanupConversation(t, store, conv2.ID)
@@ -234,11 +266,11 @@ func testListConversationsPagination(ctx context.Context, t *testing.T, store *P
 	}
 
 	// Test pagination
-	conversations, err := store.ListConversations(ctx, 2, 0)
+	conversations, err := store.ListConversations(ctx, "", 2, 0)
 	require.NoError(t, err)
 	assert.Len(t, conversations, 2)
 
-	conversations, err = store.ListConversations(ctx, 2, 2)
+	conversations, err = store.ListConversations(ctx, "", 2, 2)
 	require.NoError(t, err)
 	assert.Len(t, conversations, 2)
 
@@ -261,12 +293,20 @@ func testGetPopularTopics(ctx context.Context, t *testing.T, store *PostgresStor
 	}
 
 	// Get popular topics
-	popularTopics, err := store.GetPopularTopics(ctx, 10)
+	popularTopics, err := store.GetPopularTopics(ctx, PopularTopicsQuery{Limit: 10})
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(popularTopics), 1)
 
 	// "Popular Topic 1" should be the most popular
-	assert.Equal(t, "Popular Topic 1", popularTopics[0])
+	assert.Equal(t, "Popular Topic 1", popularTopics[0].Name)
+	assert.Equal(t, 3, popularTopics[0].Count)
+
+	// they were all started just now, so they are trending too
+	trending, err := store.GetPopularTopics(ctx, PopularTopicsQuery{Window: 24 * time.Hour, Trending: true, Limit: 10})
+	require.NoError(t, err)
+	require.NotEmpty(t, trending)
+	assert.Equal(t, "Popular Topic 1", trending[0].Name)
+	assert.Equal(t, 0, *trending[0].Previous)
 
 	// Clean up
 	for _, id := range convIDs {
@@ -287,7 +327,7 @@ func testGetPopularTopicsLimit(ctx context.Context, t *testing.T, store *Postgre
 	}
 
 	// Get popular topics with limit
-	popularTopics, err := store.GetPopularTopics(ctx, 2)
+	popularTopics, err := store.GetPopularTopics(ctx, PopularTopicsQuery{Limit: 2})
 	require.NoError(t, err)
 	assert.Len(t, popularTopics, 2)
 
@@ -425,6 +465,40 @@ func testInsertMessagesEmpty(ctx context.Context, t *testing.T, store *PostgresS
 
 // Helper functions
 
+func testForkConversation(ctx context.Context, t *testing.T, store *PostgresStore) {
+	conv, err := store.CreateConversation(ctx, "Fork Test Topic", "PRO")
+	require.NoError(t, err)
+
+	conv.Append(models.Message{Role: "user", Message: "First"})
+	conv.Append(models.Message{Role: "bot", Message: "Reply"})
+	conv.Append(models.Message{Role: "user", Message: "Second"})
+
+	err = store.SaveConversation(ctx, conv)
+	require.NoError(t, err)
+
+	branch := conv.Fork(ulid.Make().String(), 1)
+	err = store.ForkConversation(ctx, branch)
+	require.NoError(t, err)
+
+	// Copied messages keep their IDs
+	retrieved, err := store.GetConversation(ctx, branch.ID)
+	require.NoError(t, err)
+	assert.Equal(t, conv.ID, retrieved.ParentID)
+	assert.Equal(t, conv.Messages[1].ID, retrieved.ForkedFrom)
+	assert.Len(t, retrieved.Messages, 2)
+	assert.Equal(t, conv.Messages[1].ID, retrieved.Messages[1].ID)
+
+	tree, err := store.ConversationTree(ctx, branch.ID)
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, conv.ID, tree[0].ID)
+	assert.Equal(t, branch.ID, tree[1].ID)
+
+	// Clean up
+	cleanupConversation(t, store, branch.ID)
+	cleanupConversation(t, store, conv.ID)
+}
+
 func getTestDSN() string {
 	// In a real test environment, you would get this from environment variables
 	// For now, return empty string to skip tests
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/nikoremi97/debate/internal/models"
@@ -17,21 +20,152 @@ type Store interface {
 	GetConversation(ctx context.Context, id string) (*models.Conversation, error)
 	SaveConversation(ctx context.Context, c *models.Conversation) error
 	CreateConversation(ctx context.Context, topicName, botStance string) (*models.Conversation, error)
-	ListConversations(ctx context.Context, limit, offset int) ([]ConversationSummary, error)
-	GetPopularTopics(ctx context.Context, limit int) ([]string, error)
+	// ListConversations lists the conversations viewer may read (see Conversation.CanRead):
+	// human debates only show up for their participants and spectators.
+	ListConversations(ctx context.Context, viewer string, limit, offset int) ([]ConversationSummary, error)
+	GetPopularTopics(ctx context.Context, q PopularTopicsQuery) ([]TopicCount, error)
+	ExperimentStats(ctx context.Context, experiment string) ([]VariantStats, error)
+	TopicDescription(ctx context.Context, name string) (string, error)
+
+	// The topic catalog. Lookups by ID also find inactive topics; CreateTopic assigns the ID
+	// of a new topic. Missing topics yield ErrTopicNotFound and duplicate names ErrTopicExists.
+	GetTopic(ctx context.Context, id string) (*models.Topic, error)
+	ListTopics(ctx context.Context, filter TopicFilter) ([]models.Topic, error)
+	TopicCategories(ctx context.Context) ([]models.TopicCategory, error)
+	CreateTopic(ctx context.Context, t *models.Topic) error
+	UpdateTopic(ctx context.Context, t *models.Topic) error
+	DeleteTopic(ctx context.Context, id string) error
+
+	// GetBrief returns the cached prep brief of a topic made with the given prompt version;
+	// SaveBrief caches one.
+	GetBrief(ctx context.Context, topicID, promptVersion string) (*models.Brief, error)
+	SaveBrief(ctx context.Context, b *models.Brief) error
+
+	// SaveSummary sets the title and rolling summary of a conversation, leaving the rest of it
+	// alone: summaries are written in the background while the debate goes on. SaveConversation
+	// never writes the title or summary, so a turn loaded before one lands doesn't revert it.
+	SaveSummary(ctx context.Context, id, title string, s *models.Summary) error
+	// SaveArgumentMap sets the argument map of a conversation, likewise leaving the rest of it
+	// alone: a map takes a model call to build.
+	SaveArgumentMap(ctx context.Context, id string, m *models.ArgumentMap) error
+	// SaveJudgement sets the judge's verdict on a conversation, likewise leaving the rest of it
+	// alone. A verdict is never cleared by saving a conversation loaded before it.
+	SaveJudgement(ctx context.Context, id string, j *models.Judgement) error
+	// SaveRating sets the user's rating of a conversation, likewise leaving the rest of it alone.
+	// A rating is never cleared by saving a conversation loaded before it.
+	SaveRating(ctx context.Context, id string, rating int) error
+
+	// ForkConversation stores a new branch, whose history is a prefix of its parent's. Stores
+	// may copy the messages from the parent instead of writing them out again.
+	ForkConversation(ctx context.Context, branch *models.Conversation) error
+	// ConversationTree returns every conversation in the fork tree of id, the root first and
+	// each branch after its parent.
+	ConversationTree(ctx context.Context, id string) ([]Branch, error)
+
 	Ping(ctx context.Context) error
 }
 
+// keepStored carries over, from the stored copy of a conversation, the fields that only
+// targeted saves write, so saving a turn that was loaded before one of them never reverts it.
+func keepStored(c, stored *models.Conversation) {
+	c.Title = stored.Title
+	c.Summary = stored.Summary
+	c.ArgumentMap = stored.ArgumentMap
+
+	if c.Judgement == nil {
+		c.Judgement = stored.Judgement
+	}
+
+	if c.Rating == 0 {
+		c.Rating = stored.Rating
+	}
+}
+
+// VariantStats aggregates outcome metrics for one experiment variant.
+type VariantStats struct {
+	Variant       string `json:"variant"`
+	Conversations int    `json:"conversations"`
+	Turns         int    `json:"turns"`
+	RatingCount   int    `json:"rating_count"`
+	RatingSum     int    `json:"rating_sum"`
+	Errors        int    `json:"errors"`
+}
+
+// addConversation folds a conversation into stats keyed by variant.
+func addConversation(stats map[string]*VariantStats, conv *models.Conversation) {
+	v, ok := stats[conv.Experiment.Variant]
+	if !ok {
+		v = &VariantStats{Variant: conv.Experiment.Variant}
+		stats[conv.Experiment.Variant] = v
+	}
+
+	v.Conversations++
+	v.Turns += conv.Turns()
+	v.Errors += conv.ErrorCount
+
+	if conv.Rating > 0 {
+		v.RatingCount++
+		v.RatingSum += conv.Rating
+	}
+}
+
+// sortedStats flattens stats ordered by variant name.
+func sortedStats(stats map[string]*VariantStats) []VariantStats {
+	out := make([]VariantStats, 0, len(stats))
+	for _, v := range stats {
+		out = append(out, *v)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Variant < out[j].Variant })
+
+	return out
+}
+
+// ConversationSummary is a conversation as listed. Its title and summary carry the debate's
+// content, so it is only built for viewers who may read the conversation.
 type ConversationSummary struct {
 	ID           string    `json:"id"`
 	TopicName    string    `json:"topic_name"`
 	BotStance    string    `json:"bot_stance"`
 	Title        string    `json:"title"`
+	Summary      string    `json:"summary,omitempty"` // rolling summary, once there is one
 	MessageCount int       `json:"message_count"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// conversationTitle is the generated title of a conversation, or the default one until it
+// has been summarized.
+func conversationTitle(conv *models.Conversation) string {
+	if conv.Title != "" {
+		return conv.Title
+	}
+
+	return fmt.Sprintf("Debate: %s (%s)", conv.Topic, conv.Stance)
+}
+
+// summaryText is the text of the conversation's rolling summary, if any.
+func summaryText(conv *models.Conversation) string {
+	if conv.Summary == nil {
+		return ""
+	}
+
+	return conv.Summary.Text
+}
+
+// Branch is one conversation in a fork tree.
+type Branch struct {
+	ID           string `json:"id"`
+	ParentID     string `json:"parent_id,omitempty"`
+	ForkedFrom   string `json:"forked_from,omitempty"` // last message copied from the parent
+	Topic        string `json:"topic"`
+	MessageCount int    `json:"message_count"`
+}
+
+func newBranch(conv *models.Conversation) Branch {
+	return Branch{ID: conv.ID, ParentID: conv.ParentID, ForkedFrom: conv.ForkedFrom, Topic: conv.Topic, MessageCount: len(conv.Messages)}
+}
+
 type RedisStore struct {
 	c *redis.Client
 }
@@ -77,12 +211,368 @@ func (s *RedisStore) GetConversation(ctx context.Context, id string) (*models.Co
 }
 
 func (s *RedisStore) SaveConversation(ctx context.Context, c *models.Conversation) error {
-	b, err := json.Marshal(c)
+	key := s.key(c.ID)
+
+	err := s.c.Watch(ctx, func(tx *redis.Tx) error {
+		saved := *c
+
+		stored, err := tx.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+
+		if err == nil {
+			var prev models.Conversation
+			if json.Unmarshal(stored, &prev) == nil {
+				keepStored(&saved, &prev)
+			}
+		}
+
+		b, err := json.Marshal(&saved)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, b, 24*time.Hour).Err()
+		})
+
+		return err
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	return s.countTopic(ctx, c)
+}
+
+// update applies fn to the stored conversation in a transaction, so it never overwrites
+// turns saved in the meantime.
+func (s *RedisStore) update(ctx context.Context, id string, fn func(*models.Conversation)) error {
+	key := s.key(id)
+
+	return s.c.Watch(ctx, func(tx *redis.Tx) error {
+		b, err := tx.Get(ctx, key).Bytes()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				return errors.New("not found")
+			}
+
+			return err
+		}
+
+		var conv models.Conversation
+		if err := json.Unmarshal(b, &conv); err != nil {
+			return err
+		}
+
+		fn(&conv)
+
+		if b, err = json.Marshal(&conv); err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			return pipe.Set(ctx, key, b, 24*time.Hour).Err()
+		})
+
+		return err
+	}, key)
+}
+
+// SaveSummary sets the title and summary of the stored conversation (Redis implementation)
+func (s *RedisStore) SaveSummary(ctx context.Context, id, title string, summary *models.Summary) error {
+	return s.update(ctx, id, func(conv *models.Conversation) {
+		conv.Title = title
+		conv.Summary = summary
+	})
+}
+
+// SaveArgumentMap sets the argument map of the stored conversation (Redis implementation)
+func (s *RedisStore) SaveArgumentMap(ctx context.Context, id string, m *models.ArgumentMap) error {
+	return s.update(ctx, id, func(conv *models.Conversation) { conv.ArgumentMap = m })
+}
+
+// SaveJudgement sets the verdict of the stored conversation (Redis implementation)
+func (s *RedisStore) SaveJudgement(ctx context.Context, id string, j *models.Judgement) error {
+	return s.update(ctx, id, func(conv *models.Conversation) { conv.Judgement = j })
+}
+
+// SaveRating sets the rating of the stored conversation (Redis implementation)
+func (s *RedisStore) SaveRating(ctx context.Context, id string, rating int) error {
+	return s.update(ctx, id, func(conv *models.Conversation) { conv.Rating = rating })
+}
+
+func (s *RedisStore) branchesKey(id string) string {
+	return "branches:" + id
+}
+
+// ForkConversation saves the branch and records it under its parent
+func (s *RedisStore) ForkConversation(ctx context.Context, branch *models.Conversation) error {
+	if err := s.SaveConversation(ctx, branch); err != nil {
+		return err
+	}
+
+	key := s.branchesKey(branch.ParentID)
+
+	_, err := s.c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, branch.ID)
+		pipe.Expire(ctx, key, 24*time.Hour)
+
+		return nil
+	})
+
+	return err
+}
+
+// ConversationTree walks up to the root through the parents, then down through the branch
+// sets. Expired conversations are left out.
+func (s *RedisStore) ConversationTree(ctx context.Context, id string) ([]Branch, error) {
+	conv, err := s.GetConversation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for conv.ParentID != "" {
+		parent, err := s.GetConversation(ctx, conv.ParentID)
+		if err != nil {
+			break
+		}
+
+		conv = parent
+	}
+
+	tree := []Branch{newBranch(conv)}
+
+	for i := 0; i < len(tree); i++ {
+		ids, err := s.c.SMembers(ctx, s.branchesKey(tree[i].ID)).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get branches: %w", err)
+		}
+
+		sort.Strings(ids)
+
+		for _, id := range ids {
+			if branch, err := s.GetConversation(ctx, id); err == nil {
+				tree = append(tree, newBranch(branch))
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+// topicsKey is the hash holding the topic catalog, topic ID to JSON; topicsSeededKey marks
+// that the seed topics were written, so deleting them all doesn't bring them back.
+const (
+	topicsKey       = "topics"
+	topicsSeededKey = "topics:seeded"
+)
+
+// seedTopics writes the seed topics the first time the catalog is used.
+func (s *RedisStore) seedTopics(ctx context.Context) error {
+	first, err := s.c.SetNX(ctx, topicsSeededKey, 1, 0).Result()
+	if err != nil || !first {
+		return err
+	}
+
+	fields := map[string]any{}
+
+	for id, t := range seedCatalog() {
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+
+		fields[id] = b
+	}
+
+	return s.c.HSet(ctx, topicsKey, fields).Err()
+}
+
+// catalog returns every topic, skipping entries that don't decode.
+func (s *RedisStore) catalog(ctx context.Context) ([]models.Topic, error) {
+	if err := s.seedTopics(ctx); err != nil {
+		return nil, err
+	}
+
+	raw, err := s.c.HGetAll(ctx, topicsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	topics := make([]models.Topic, 0, len(raw))
+
+	for _, v := range raw {
+		var t models.Topic
+		if err := json.Unmarshal([]byte(v), &t); err != nil {
+			continue
+		}
+
+		topics = append(topics, t)
+	}
+
+	return topics, nil
+}
+
+func (s *RedisStore) saveTopic(ctx context.Context, t *models.Topic) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	return s.c.HSet(ctx, topicsKey, t.ID, b).Err()
+}
+
+// TopicDescription looks up a topic's description by name
+func (s *RedisStore) TopicDescription(ctx context.Context, name string) (string, error) {
+	topics, err := s.catalog(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	t, ok := topicByName(topics, name)
+	if !ok {
+		return "", ErrTopicNotFound
+	}
+
+	return t.Description, nil
+}
+
+// GetTopic looks up a topic by ID
+func (s *RedisStore) GetTopic(ctx context.Context, id string) (*models.Topic, error) {
+	if err := s.seedTopics(ctx); err != nil {
+		return nil, err
+	}
+
+	b, err := s.c.HGet(ctx, topicsKey, id).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, ErrTopicNotFound
+		}
+
+		return nil, err
+	}
+
+	var t models.Topic
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// ListTopics lists the catalog
+func (s *RedisStore) ListTopics(ctx context.Context, filter TopicFilter) ([]models.Topic, error) {
+	topics, err := s.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return filterTopics(topics, filter), nil
+}
+
+// TopicCategories counts the active topics per category
+func (s *RedisStore) TopicCategories(ctx context.Context) ([]models.TopicCategory, error) {
+	topics, err := s.catalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return topicCategories(topics), nil
+}
+
+// CreateTopic adds a topic to the catalog
+func (s *RedisStore) CreateTopic(ctx context.Context, t *models.Topic) error {
+	topics, err := s.catalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	t.ID = ulid.Make().String()
+	if err := checkTopic(topics, t); err != nil {
+		return err
+	}
+
+	return s.saveTopic(ctx, t)
+}
+
+// UpdateTopic replaces a topic of the catalog
+func (s *RedisStore) UpdateTopic(ctx context.Context, t *models.Topic) error {
+	topics, err := s.catalog(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !slices.ContainsFunc(topics, func(other models.Topic) bool { return other.ID == t.ID }) {
+		return ErrTopicNotFound
+	}
+
+	if err := checkTopic(topics, t); err != nil {
+		return err
+	}
+
+	return s.saveTopic(ctx, t)
+}
+
+// DeleteTopic removes a topic and its cached briefs. Conversations keep their topic name.
+func (s *RedisStore) DeleteTopic(ctx context.Context, id string) error {
+	if err := s.seedTopics(ctx); err != nil {
+		return err
+	}
+
+	n, err := s.c.HDel(ctx, topicsKey, id).Result()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrTopicNotFound
+	}
+
+	briefs, err := s.c.Keys(ctx, s.briefKey(id, "*")).Result()
+	if err != nil || len(briefs) == 0 {
+		return err
+	}
+
+	return s.c.Del(ctx, briefs...).Err()
+}
+
+// briefTTL is how long a cached brief is kept; a new prompt version replaces it sooner.
+const briefTTL = 7 * 24 * time.Hour
+
+func (s *RedisStore) briefKey(topicID, promptVersion string) string {
+	return "brief:" + briefKey(topicID, promptVersion)
+}
+
+// GetBrief returns a cached brief
+func (s *RedisStore) GetBrief(ctx context.Context, topicID, promptVersion string) (*models.Brief, error) {
+	b, err := s.c.Get(ctx, s.briefKey(topicID, promptVersion)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, errors.New("not found")
+		}
+
+		return nil, err
+	}
+
+	var brief models.Brief
+
+	if err := json.Unmarshal(b, &brief); err != nil {
+		return nil, err
+	}
+
+	return &brief, nil
+}
+
+// SaveBrief caches a brief for briefTTL
+func (s *RedisStore) SaveBrief(ctx context.Context, brief *models.Brief) error {
+	b, err := json.Marshal(brief)
 	if err != nil {
 		return err
 	}
 
-	return s.c.Set(ctx, s.key(c.ID), b, 24*time.Hour).Err()
+	return s.c.Set(ctx, s.briefKey(brief.TopicID, brief.PromptVersion), b, briefTTL).Err()
 }
 
 func (s *RedisStore) Ping(ctx context.Context) error {
@@ -95,10 +585,11 @@ func (s *RedisStore) CreateConversation(ctx context.Context, topicName, botStanc
 	id := ulid.Make().String()
 
 	conv := &models.Conversation{
-		ID:       id,
-		Topic:    topicName,
-		Stance:   botStance,
-		Messages: make([]models.Message, 0),
+		ID:         id,
+		Topic:      topicName,
+		Stance:     botStance,
+		UserStance: models.OppositeStance(botStance),
+		Messages:   make([]models.Message, 0),
 	}
 
 	// Save the conversation
@@ -110,7 +601,7 @@ func (s *RedisStore) CreateConversation(ctx context.Context, topicName, botStanc
 }
 
 // ListConversations lists conversations (Redis fallback - limited functionality)
-func (s *RedisStore) ListConversations(ctx context.Context, limit, offset int) ([]ConversationSummary, error) {
+func (s *RedisStore) ListConversations(ctx context.Context, viewer string, limit, offset int) ([]ConversationSummary, error) {
 	// Redis doesn't have great support for complex queries
 	// This is a simplified implementation - in production, use PostgreSQL
 	keys, err := s.c.Keys(ctx, "convo:*").Result()
@@ -119,12 +610,9 @@ func (s *RedisStore) ListConversations(ctx context.Context, limit, offset int) (
 	}
 
 	var conversations []ConversationSummary
+	count := 0
 
-	for i, key := range keys {
-		if i < offset {
-			continue
-		}
-
+	for _, key := range keys {
 		if len(conversations) >= limit {
 			break
 		}
@@ -140,11 +628,21 @@ func (s *RedisStore) ListConversations(ctx context.Context, limit, offset int) (
 			continue // Skip invalid conversations
 		}
 
+		if !conv.CanRead(viewer) {
+			continue
+		}
+
+		if count < offset {
+			count++
+			continue
+		}
+
 		conversations = append(conversations, ConversationSummary{
 			ID:           conv.ID,
 			TopicName:    conv.Topic,
 			BotStance:    conv.Stance,
-			Title:        fmt.Sprintf("Debate: %s (%s)", conv.Topic, conv.Stance),
+			Title:        conversationTitle(&conv),
+			Summary:      summaryText(&conv),
 			MessageCount: len(conv.Messages),
 			CreatedAt:    time.Now(), // Redis doesn't store creation time
 			UpdatedAt:    time.Now(),
@@ -154,16 +652,133 @@ func (s *RedisStore) ListConversations(ctx context.Context, limit, offset int) (
 	return conversations, nil
 }
 
-// GetPopularTopics gets popular topics (Redis fallback - limited functionality)
-func (s *RedisStore) GetPopularTopics(ctx context.Context, limit int) ([]string, error) {
-	// This is a simplified implementation for Redis
-	// In production, use PostgreSQL for better analytics
+// Popular topics are counted as conversations are saved, in hourly sorted sets of topics
+// scored by debates started that hour, and in an all-time set. Members are the topic ID and
+// the topic's wording, joined by popularSep. Each conversation remembers the member it was
+// counted under, so it is counted once and moved when it gets linked to a catalog topic.
+const (
+	popularAllKey = "popular:all"
+	popularSep    = "\x1f"
+
+	// popularTTL keeps the hourly sets long enough to compare the longest window with the
+	// one before it.
+	popularTTL = 61 * 24 * time.Hour
+)
+
+// countTopicScript moves a conversation's count to its current member. KEYS: the marker
+// of the conversation, its hourly set and the all-time set; ARGV: the member and the TTLs of
+// the marker and the hourly set, in seconds.
+var countTopicScript = redis.NewScript(`
+local prev = redis.call('GET', KEYS[1])
+if prev ~= ARGV[1] then
+  for i = 2, 3 do
+    if prev then
+      redis.call('ZINCRBY', KEYS[i], -1, prev)
+      redis.call('ZREMRANGEBYSCORE', KEYS[i], '-inf', 0)
+    end
+    redis.call('ZINCRBY', KEYS[i], 1, ARGV[1])
+  end
+  redis.call('EXPIRE', KEYS[2], ARGV[3])
+end
+redis.call('SET', KEYS[1], ARGV[1], 'EX', ARGV[2])
+return 0
+`)
+
+func (s *RedisStore) popularKey(hour time.Time) string {
+	return "popular:" + hour.UTC().Format("2006010215")
+}
+
+// countTopic counts the conversation towards the hour it was started in, which its ULID
+// records; conversations with other IDs count from the first save.
+func (s *RedisStore) countTopic(ctx context.Context, c *models.Conversation) error {
+	started := time.Now()
+	if id, err := ulid.Parse(c.ID); err == nil {
+		started = id.Timestamp()
+	}
+
+	keys := []string{"popular:counted:" + c.ID, s.popularKey(started), popularAllKey}
+	member := c.TopicID + popularSep + c.Topic
+
+	return countTopicScript.Run(ctx, s.c, keys, member, int((24 * time.Hour).Seconds()), int(popularTTL.Seconds())).Err()
+}
+
+// GetPopularTopics sums the hourly sets of the window, to the hour (Redis implementation)
+func (s *RedisStore) GetPopularTopics(ctx context.Context, q PopularTopicsQuery) ([]TopicCount, error) {
+	hours := int(q.Window / time.Hour)
+	if q.Window > 0 && hours == 0 {
+		hours = 1
+	}
+
+	now := time.Now()
+
+	current, err := s.popularity(ctx, now, hours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get popular topics: %w", err)
+	}
+
+	previous := map[string]int{}
+	if q.Trending && hours > 0 {
+		if previous, err = s.popularity(ctx, now.Add(-time.Duration(hours)*time.Hour), hours); err != nil {
+			return nil, fmt.Errorf("failed to get popular topics: %w", err)
+		}
+	}
+
+	counts := make([]topicCount, 0, len(current)+len(previous))
+
+	for member, n := range current {
+		id, name, _ := strings.Cut(member, popularSep)
+		counts = append(counts, topicCount{id: id, name: name, count: n, previous: previous[member]})
+		delete(previous, member)
+	}
+
+	for member, n := range previous {
+		id, name, _ := strings.Cut(member, popularSep)
+		counts = append(counts, topicCount{id: id, name: name, previous: n})
+	}
+
+	catalog, err := s.catalog(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get topics: %w", err)
+	}
+
+	return rankTopics(counts, catalog, q), nil
+}
+
+// popularity sums the hourly sets of the given number of hours up to last, or reads the
+// all-time set when hours is 0.
+func (s *RedisStore) popularity(ctx context.Context, last time.Time, hours int) (map[string]int, error) {
+	keys := []string{popularAllKey}
+
+	if hours > 0 {
+		keys = keys[:0]
+		for h := 0; h < hours; h++ {
+			keys = append(keys, s.popularKey(last.Add(-time.Duration(h)*time.Hour)))
+		}
+	}
+
+	members, err := s.c.ZUnionWithScores(ctx, redis.ZStore{Keys: keys, Aggregate: "SUM"}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]int, len(members))
+	for _, z := range members {
+		if m, ok := z.Member.(string); ok && z.Score > 0 {
+			out[m] = int(z.Score)
+		}
+	}
+
+	return out, nil
+}
+
+// ExperimentStats aggregates outcomes per variant (Redis fallback - scans all conversations)
+func (s *RedisStore) ExperimentStats(ctx context.Context, experiment string) ([]VariantStats, error) {
 	keys, err := s.c.Keys(ctx, "convo:*").Result()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get conversation keys: %w", err)
 	}
 
-	topicCount := make(map[string]int)
+	stats := map[string]*VariantStats{}
 
 	for _, key := range keys {
 		b, err := s.c.Get(ctx, key).Bytes()
@@ -176,17 +791,10 @@ func (s *RedisStore) GetPopularTopics(ctx context.Context, limit int) ([]string,
 			continue
 		}
 
-		topicCount[conv.Topic]++
-	}
-
-	// Sort by count and return top topics
-	var topics []string
-	for topic := range topicCount {
-		topics = append(topics, topic)
-		if len(topics) >= limit {
-			break
+		if conv.Experiment != nil && conv.Experiment.Experiment == experiment {
+			addConversation(stats, &conv)
 		}
 	}
 
-	return topics, nil
+	return sortedStats(stats), nil
 }
@@ -3,8 +3,11 @@ package storage
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/nikoremi97/debate/internal/models"
+
+	"github.com/oklog/ulid/v2"
 )
 
 func TestRedisStoreIntegration(t *testing.T) {
@@ -59,3 +62,45 @@ func TestRedisStoreIntegration(t *testing.T) {
 	// Clean up
 	client.Del(ctx, "convo:test-redis-123")
 }
+
+func TestRedisStorePopularTopics(t *testing.T) {
+	client, err := NewRedisClient("localhost:6379", "")
+	if err != nil {
+		t.Skip("Redis not available, skipping integration test")
+	}
+
+	store := NewRedisStore(client)
+	ctx := context.Background()
+	topic := "Popular topic " + ulid.Make().String()
+
+	for range 2 {
+		conv, err := store.CreateConversation(ctx, topic, "PRO")
+		if err != nil {
+			t.Fatalf("create conversation should succeed: %v", err)
+		}
+
+		// saving again must not count the debate twice
+		if err := store.SaveConversation(ctx, conv); err != nil {
+			t.Fatalf("save conversation should succeed: %v", err)
+		}
+
+		defer client.Del(ctx, "convo:"+conv.ID, "popular:counted:"+conv.ID)
+	}
+
+	topics, err := store.GetPopularTopics(ctx, PopularTopicsQuery{Window: 24 * time.Hour, Trending: true, Limit: 50})
+	if err != nil {
+		t.Fatalf("popular topics should succeed: %v", err)
+	}
+
+	for _, tc := range topics {
+		if tc.Name == topic {
+			if tc.Count != 2 || tc.Previous == nil || *tc.Previous != 0 {
+				t.Fatalf("expected 2 debates and none the day before, got %+v", tc)
+			}
+
+			return
+		}
+	}
+
+	t.Fatalf("expected %q among the trending topics, got %+v", topic, topics)
+}
@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/topics"
+)
+
+// seedTopics mirrors the topics init.sql seeds, so the stores without a topics table serve
+// the same catalog.
+var seedTopics = []models.Topic{
+	{ID: "01HZ0000000000000000000001", Name: "Artificial Intelligence Regulation", Description: "Should AI be heavily regulated by governments?", Category: "technology"},
+	{ID: "01HZ0000000000000000000002", Name: "Remote Work vs Office Work", Description: "Which is more productive and beneficial?", Category: "business"},
+	{ID: "01HZ0000000000000000000003", Name: "Social Media Impact", Description: "Is social media good or bad for society?", Category: "technology"},
+	{ID: "01HZ0000000000000000000004", Name: "Climate Change Action", Description: "Should governments take more aggressive action on climate change?", Category: "politics"},
+	{ID: "01HZ0000000000000000000005", Name: "Universal Basic Income", Description: "Should governments provide UBI to all citizens?", Category: "politics"},
+	{ID: "01HZ0000000000000000000006", Name: "Cryptocurrency Future", Description: "Will cryptocurrency replace traditional money?", Category: "finance"},
+	{ID: "01HZ0000000000000000000007", Name: "Space Exploration", Description: "Should we invest more in space exploration?", Category: "science"},
+	{ID: "01HZ0000000000000000000008", Name: "Electric Vehicles", Description: "Are electric vehicles the future of transportation?", Category: "technology"},
+	{ID: "01HZ0000000000000000000009", Name: "Online Education", Description: "Is online education as effective as traditional education?", Category: "education"},
+	{ID: "01HZ000000000000000000000A", Name: "Privacy vs Security", Description: "Should privacy be sacrificed for national security?", Category: "politics"},
+}
+
+// Catalog errors.
+var (
+	ErrTopicNotFound = errors.New("topic not found")
+	ErrTopicExists   = errors.New("a topic with that name already exists")
+)
+
+// TopicFilter narrows ListTopics. The zero value lists the active topics of every category.
+type TopicFilter struct {
+	Category        string // case-insensitive; empty for every category
+	IncludeInactive bool
+}
+
+func (f TopicFilter) match(t models.Topic) bool {
+	return (f.IncludeInactive || t.Active) && (f.Category == "" || strings.EqualFold(t.Category, f.Category))
+}
+
+// seedCatalog returns the seeded topics keyed by ID, all active.
+func seedCatalog() map[string]models.Topic {
+	catalog := make(map[string]models.Topic, len(seedTopics))
+	for _, t := range seedTopics {
+		t.Active = true
+		catalog[t.ID] = t
+	}
+
+	return catalog
+}
+
+// filterTopics returns the topics matching f, ordered by name.
+func filterTopics(topics []models.Topic, f TopicFilter) []models.Topic {
+	out := []models.Topic{}
+
+	for _, t := range topics {
+		if f.match(t) {
+			out = append(out, t)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+
+	return out
+}
+
+// topicCategories counts the active topics of each category, ordered by name.
+func topicCategories(topics []models.Topic) []models.TopicCategory {
+	counts := map[string]int{}
+
+	for _, t := range topics {
+		if t.Active && t.Category != "" {
+			counts[t.Category]++
+		}
+	}
+
+	out := make([]models.TopicCategory, 0, len(counts))
+	for name, n := range counts {
+		out = append(out, models.TopicCategory{Name: name, Topics: n})
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+
+	return out
+}
+
+// topicByName finds a topic by its case-insensitive name.
+func topicByName(topics []models.Topic, name string) (models.Topic, bool) {
+	for _, t := range topics {
+		if strings.EqualFold(t.Name, name) {
+			return t, true
+		}
+	}
+
+	return models.Topic{}, false
+}
+
+// checkTopic validates a new or updated topic against the rest of the catalog: names are
+// unique regardless of case.
+func checkTopic(topics []models.Topic, t *models.Topic) error {
+	if other, ok := topicByName(topics, t.Name); ok && other.ID != t.ID {
+		return ErrTopicExists
+	}
+
+	return nil
+}
+
+// PopularTopicsQuery selects the debates GetPopularTopics counts.
+type PopularTopicsQuery struct {
+	Window   time.Duration // count the debates started within it; 0 counts them all
+	Trending bool          // rank by the rise over the previous window instead of by count
+	Limit    int
+}
+
+// tally counts a debate started at created in the window (1, 0), in the window before it when
+// trending (0, 1), or in neither.
+func (q PopularTopicsQuery) tally(created, now time.Time) (int, int) {
+	switch {
+	case q.Window <= 0 || !created.Before(now.Add(-q.Window)):
+		return 1, 0
+	case q.Trending && !created.Before(now.Add(-2*q.Window)):
+		return 0, 1
+	default:
+		return 0, 0
+	}
+}
+
+// TopicCount is a topic and the number of debates held on it.
+type TopicCount struct {
+	TopicID  string `json:"topic_id,omitempty"` // catalog topic, empty for free-text topics
+	Name     string `json:"name"`
+	Count    int    `json:"count"`
+	Previous *int   `json:"previous,omitempty"` // debates in the previous window, in trending mode
+}
+
+// topicCount is how many debates were held on one wording of a topic, linked to a catalog
+// topic (id) or not, in the window and in the one before it.
+type topicCount struct {
+	id, name        string
+	count, previous int
+}
+
+// rankTopics ranks topics by debates held, most first, then by name. Debates linked to a
+// catalog topic count together under its name; the others count together by normalized
+// topic, under their most used wording. In trending mode only the topics that rose make the
+// list, by how much they rose.
+func rankTopics(counts []topicCount, catalog []models.Topic, q PopularTopicsQuery) []TopicCount {
+	type group struct {
+		TopicCount
+		previous int
+		wordings map[string]int
+	}
+
+	names := make(map[string]string, len(catalog))
+	for _, t := range catalog {
+		names[t.ID] = t.Name
+	}
+
+	groups := map[string]*group{}
+
+	for _, c := range counts {
+		key := c.id
+		if key == "" {
+			key = "~" + topics.Normalize(c.name)
+			if key == "~" {
+				key += strings.ToLower(c.name)
+			}
+		}
+
+		g, ok := groups[key]
+		if !ok {
+			g = &group{TopicCount: TopicCount{TopicID: c.id}, wordings: map[string]int{}}
+			groups[key] = g
+		}
+
+		g.Count += c.count
+		g.previous += c.previous
+		g.wordings[c.name] += c.count + c.previous
+	}
+
+	ranked := make([]TopicCount, 0, len(groups))
+
+	for _, g := range groups {
+		if g.Count == 0 || q.Trending && g.Count <= g.previous {
+			continue
+		}
+
+		g.Name = names[g.TopicID]
+
+		if g.Name == "" {
+			best := 0
+			for name, n := range g.wordings {
+				if n > best || n == best && name < g.Name {
+					g.Name, best = name, n
+				}
+			}
+		}
+
+		if q.Trending {
+			g.Previous = &g.previous
+		}
+
+		ranked = append(ranked, g.TopicCount)
+	}
+
+	rise := func(t TopicCount) int {
+		if t.Previous == nil {
+			return 0
+		}
+
+		return t.Count - *t.Previous
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+
+		switch {
+		case rise(a) != rise(b):
+			return rise(a) > rise(b)
+		case a.Count != b.Count:
+			return a.Count > b.Count
+		default:
+			return a.Name < b.Name
+		}
+	})
+
+	if q.Limit > 0 && len(ranked) > q.Limit {
+		ranked = ranked[:q.Limit]
+	}
+
+	return ranked
+}
+
+// briefKey identifies a cached brief.
+func briefKey(topicID, promptVersion string) string {
+	return topicID + ":" + promptVersion
+}
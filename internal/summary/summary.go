@@ -0,0 +1,240 @@
+// Package summary keeps a rolling summary and a distinctive title for each debate, refreshed
+// in the background every few turns.
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+const (
+	// DefaultEvery is how many bot turns pass between summaries by default.
+	DefaultEvery = 4
+
+	// maxTitle caps generated titles, in runes.
+	maxTitle = 80
+
+	// timeout bounds a background summary.
+	timeout = 60 * time.Second
+)
+
+// SaveFunc stores a generated title and summary on the conversation with the given ID.
+type SaveFunc func(ctx context.Context, id, title string, s *models.Summary) error
+
+// Summarizer writes rolling summaries. Each one folds the turns since the last summary into
+// it, so the prompt stays short however long the debate gets.
+type Summarizer struct {
+	engine bot.Engine
+	every  int
+	opts   []bot.Option
+
+	mu      sync.Mutex
+	running map[string]bool
+	wg      sync.WaitGroup
+}
+
+// New returns a summarizer that summarizes every `every` bot turns (DefaultEvery when not
+// positive). opts tune the call, e.g. a cheaper model.
+func New(engine bot.Engine, every int, opts ...bot.Option) *Summarizer {
+	if every <= 0 {
+		every = DefaultEvery
+	}
+
+	return &Summarizer{engine: engine, every: every, opts: opts, running: map[string]bool{}}
+}
+
+// Due reports whether enough turns have passed since the conversation's last summary.
+func (s *Summarizer) Due(conv *models.Conversation) bool {
+	_, turns := pending(conv)
+
+	replies := 0
+	for _, m := range turns {
+		if m.Role == "bot" {
+			replies++
+		}
+	}
+
+	return replies >= s.every
+}
+
+// Summarize folds the turns since the last summary into it and names the debate.
+func (s *Summarizer) Summarize(ctx context.Context, conv *models.Conversation) (string, *models.Summary, error) {
+	previous, turns := pending(conv)
+	if len(turns) == 0 {
+		return "", nil, errors.New("summary: no new turns to summarize")
+	}
+
+	var info bot.ReplyInfo
+
+	opts := append([]bot.Option{bot.WithJSON(), bot.WithTemperature(0.3), bot.WithMaxTokens(400)}, s.opts...)
+	opts = append(opts, bot.WithReplyInfo(&info))
+
+	out, err := s.engine.Complete(ctx, []map[string]string{
+		{"role": "system", "content": systemPrompt(conv)},
+		{"role": "user", "content": transcript(previous, turns)},
+	}, opts...)
+	if err != nil {
+		return "", nil, fmt.Errorf("summary: %w", err)
+	}
+
+	title, text, err := parse(out)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return title, &models.Summary{
+		Text:        text,
+		UpTo:        turns[len(turns)-1].ID,
+		Turns:       conv.Turns(),
+		Model:       info.Model,
+		GeneratedAt: time.Now().UnixMilli(),
+	}, nil
+}
+
+// Start summarizes a snapshot of the conversation in the background and hands the result to
+// save. It does nothing while a summary of the same conversation is still running. Failures
+// are logged: the debate carries on with its previous summary.
+func (s *Summarizer) Start(conv *models.Conversation, save SaveFunc) {
+	s.mu.Lock()
+	if s.running[conv.ID] {
+		s.mu.Unlock()
+		return
+	}
+
+	s.running[conv.ID] = true
+	s.mu.Unlock()
+
+	snapshot := *conv
+	snapshot.Messages = append([]models.Message(nil), conv.Messages...)
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, snapshot.ID)
+			s.mu.Unlock()
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		title, summary, err := s.Summarize(ctx, &snapshot)
+		if err == nil {
+			err = save(ctx, snapshot.ID, title, summary)
+		}
+
+		if err != nil {
+			log.Printf("summary of %s failed: %v", snapshot.ID, err)
+		}
+	}()
+}
+
+// Wait blocks until the background summaries have finished.
+func (s *Summarizer) Wait() { s.wg.Wait() }
+
+// Context returns the history to send the model: when the conversation has a summary, the
+// messages it covers are replaced by a system note carrying it. The full history is returned
+// when there is no usable summary, e.g. after the history was truncated past it.
+func Context(conv *models.Conversation) []models.Message {
+	if conv.Summary == nil {
+		return conv.Messages
+	}
+
+	i := conv.MessageIndex(conv.Summary.UpTo)
+	if i < 0 {
+		return conv.Messages
+	}
+
+	note := models.Message{Role: "system", Message: "Summary of the debate so far: " + conv.Summary.Text}
+
+	return append([]models.Message{note}, conv.Messages[i+1:]...)
+}
+
+func systemPrompt(conv *models.Conversation) string {
+	return "You summarize a debate. Topic: " + conv.Topic + ".\n" +
+		"You get the summary so far, if any, and the turns since. Write an updated summary of " +
+		"the whole debate in at most 4 sentences: the main arguments of each side and where they " +
+		"stand. Then give the debate a short, distinctive title (at most 8 words) that says what " +
+		"it is actually about, not just the topic.\n" +
+		`Reply with a JSON object only: {"title": string, "summary": string}`
+}
+
+// pending returns the current summary text and the turns it doesn't cover yet, leaving out
+// flagged turns, moderation notices and the moderator's reviews like the judge does. Without
+// a usable summary every turn is pending.
+func pending(conv *models.Conversation) (string, []models.Message) {
+	previous, from := "", 0
+
+	if conv.Summary != nil {
+		if i := conv.MessageIndex(conv.Summary.UpTo); i >= 0 {
+			previous, from = conv.Summary.Text, i+1
+		}
+	}
+
+	var turns []models.Message
+
+	for _, m := range conv.Messages[from:] {
+		if m.Flagged() || m.Event == models.EventModeration || m.Role == models.RoleModerator {
+			continue
+		}
+
+		turns = append(turns, m)
+	}
+
+	return previous, turns
+}
+
+func transcript(previous string, turns []models.Message) string {
+	var b strings.Builder
+
+	if previous != "" {
+		b.WriteString("Summary so far: " + previous + "\n\nNew turns:\n")
+	}
+
+	for _, m := range turns {
+		if m.Role == "system" {
+			b.WriteString("(system note: " + m.Message + ")\n")
+			continue
+		}
+
+		b.WriteString(strings.ToUpper(m.Role) + ": " + m.Message + "\n")
+	}
+
+	return b.String()
+}
+
+// parse validates the model's JSON, trimming the title to a sidebar-friendly length.
+func parse(out string) (string, string, error) {
+	var raw struct {
+		Title   string `json:"title"`
+		Summary string `json:"summary"`
+	}
+
+	if err := json.Unmarshal([]byte(bot.ExtractJSON(out)), &raw); err != nil {
+		return "", "", fmt.Errorf("invalid summary: %w", err)
+	}
+
+	title := strings.Trim(strings.TrimSpace(raw.Title), `"`)
+	text := strings.TrimSpace(raw.Summary)
+
+	if title == "" || text == "" {
+		return "", "", errors.New("invalid summary: it needs a title and a summary")
+	}
+
+	if r := []rune(title); len(r) > maxTitle {
+		title = strings.TrimSpace(string(r[:maxTitle-1])) + "…"
+	}
+
+	return title, text, nil
+}
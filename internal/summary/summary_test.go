@@ -0,0 +1,115 @@
+package summary
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/models"
+	"github.com/nikoremi97/debate/internal/moderation"
+)
+
+// cannedEngine returns out from Complete and records the prompt it was sent.
+type cannedEngine struct {
+	out  string
+	sent *[]map[string]string
+}
+
+func (e cannedEngine) Generate(context.Context, string, string, []bot.HistoryItem, string, ...bot.Option) (string, error) {
+	return "", errors.New("not used")
+}
+
+func (e cannedEngine) Complete(_ context.Context, messages []map[string]string, _ ...bot.Option) (string, error) {
+	*e.sent = messages
+	return e.out, nil
+}
+
+func debate(turns int) *models.Conversation {
+	conv := models.NewConversation("c1")
+	conv.Topic = "Pineapple on pizza"
+
+	for i := 0; i < turns; i++ {
+		conv.Append(models.Message{Role: "user", Message: "User point"})
+		conv.Append(models.Message{Role: "bot", Message: "Bot point"})
+	}
+
+	return conv
+}
+
+func TestSummarizeRolls(t *testing.T) {
+	var sent []map[string]string
+
+	s := New(cannedEngine{out: `{"title": " \"Sweet vs savory\" ", "summary": "Both sides dig in."}`, sent: &sent}, 2)
+	conv := debate(1)
+
+	if s.Due(conv) {
+		t.Fatal("expected no summary to be due after one turn")
+	}
+
+	conv.Append(models.Message{Role: "user", Message: "Flagged", Moderation: &moderation.Verdict{Allowed: false, Category: "abuse"}})
+	conv.Append(models.Message{Role: "bot", Message: "Second reply"})
+
+	if !s.Due(conv) {
+		t.Fatal("expected a summary to be due after two turns")
+	}
+
+	title, summary, err := s.Summarize(context.Background(), conv)
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+
+	if title != "Sweet vs savory" || summary.Text != "Both sides dig in." || summary.UpTo != conv.Messages[3].ID || summary.Turns != 2 {
+		t.Fatalf("unexpected summary %q %+v", title, summary)
+	}
+
+	if strings.Contains(sent[1]["content"], "Flagged") {
+		t.Fatalf("flagged turns shouldn't be summarized:\n%s", sent[1]["content"])
+	}
+
+	conv.Title, conv.Summary = title, summary
+	conv.Append(models.Message{Role: "user", Message: "Third point"})
+	conv.Append(models.Message{Role: "bot", Message: "Third reply"})
+
+	if _, _, err := s.Summarize(context.Background(), conv); err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+
+	if prompt := sent[1]["content"]; !strings.HasPrefix(prompt, "Summary so far: Both sides dig in.") || strings.Contains(prompt, "Second reply") ||
+		!strings.Contains(prompt, "BOT: Third reply") {
+		t.Fatalf("expected the previous summary and only the new turns:\n%s", prompt)
+	}
+}
+
+func TestContext(t *testing.T) {
+	conv := debate(3)
+
+	if got := Context(conv); len(got) != 6 {
+		t.Fatalf("expected the full history without a summary, got %d messages", len(got))
+	}
+
+	conv.Summary = &models.Summary{Text: "Both sides dig in.", UpTo: conv.Messages[3].ID}
+
+	got := Context(conv)
+	if len(got) != 3 || got[0].Role != "system" || !strings.HasSuffix(got[0].Message, "Both sides dig in.") || got[1].ID != conv.Messages[4].ID {
+		t.Fatalf("expected the summary and the turns after it, got %+v", got)
+	}
+
+	conv.Truncate(2)
+
+	if got := Context(conv); len(got) != 2 {
+		t.Fatalf("expected a summary past the end of the history to be ignored, got %+v", got)
+	}
+}
+
+func TestParseTrimsLongTitles(t *testing.T) {
+	title, _, err := parse(`{"title": "` + strings.Repeat("word ", 30) + `", "summary": "s"}`)
+	if err != nil || len([]rune(title)) != maxTitle || !strings.HasSuffix(title, "…") {
+		t.Fatalf("expected a trimmed title, got %q (%v)", title, err)
+	}
+
+	if _, _, err := parse(`{"title": "", "summary": "s"}`); err == nil {
+		t.Fatal("expected an empty title to be rejected")
+	}
+}
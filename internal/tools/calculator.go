@@ -0,0 +1,250 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nikoremi97/debate/internal/bot"
+)
+
+// maxExpression caps the length of a calculator expression.
+const maxExpression = 200
+
+// Calculator evaluates arithmetic so the bot can check the numbers in a claim instead of
+// estimating them.
+func Calculator() bot.Tool {
+	return bot.Tool{
+		Name:        "calculate",
+		Description: "Evaluate an arithmetic expression, e.g. to check a claimed statistic. Supports + - * / ^, parentheses and percentages such as 12%.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"expression": map[string]any{"type": "string", "description": "e.g. (27 * 2 * 5) / 60"},
+			},
+			"required": []string{"expression"},
+		},
+		Run: func(_ context.Context, args json.RawMessage) (string, error) {
+			var in struct {
+				Expression string `json:"expression"`
+			}
+
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", err
+			}
+
+			v, err := Evaluate(in.Expression)
+			if err != nil {
+				return "", err
+			}
+
+			return strconv.FormatFloat(v, 'g', 12, 64), nil
+		},
+	}
+}
+
+// Evaluate computes an arithmetic expression.
+func Evaluate(expr string) (float64, error) {
+	if len(expr) > maxExpression {
+		return 0, fmt.Errorf("expression longer than %d characters", maxExpression)
+	}
+
+	p := &parser{src: strings.TrimSpace(expr)}
+
+	v, err := p.expr()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.skipSpace(); p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, errors.New("result is not a finite number")
+	}
+
+	return v, nil
+}
+
+// parser is a recursive-descent evaluator:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = power { ("*" | "/") power }
+//	power   = unary [ "^" power ]
+//	unary   = [ "+" | "-" ] unary | primary
+//	primary = number [ "%" ] | "(" expr ")"
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) expr() (float64, error) {
+	v, err := p.term()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		switch p.peek() {
+		case '+':
+			p.pos++
+
+			rhs, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+
+			v += rhs
+		case '-':
+			p.pos++
+
+			rhs, err := p.term()
+			if err != nil {
+				return 0, err
+			}
+
+			v -= rhs
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (p *parser) term() (float64, error) {
+	v, err := p.power()
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		switch p.peek() {
+		case '*':
+			p.pos++
+
+			rhs, err := p.power()
+			if err != nil {
+				return 0, err
+			}
+
+			v *= rhs
+		case '/':
+			p.pos++
+
+			rhs, err := p.power()
+			if err != nil {
+				return 0, err
+			}
+
+			if rhs == 0 {
+				return 0, errors.New("division by zero")
+			}
+
+			v /= rhs
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (p *parser) power() (float64, error) {
+	base, err := p.unary()
+	if err != nil {
+		return 0, err
+	}
+
+	if p.peek() != '^' {
+		return base, nil
+	}
+
+	p.pos++
+
+	exp, err := p.power()
+	if err != nil {
+		return 0, err
+	}
+
+	return math.Pow(base, exp), nil
+}
+
+func (p *parser) unary() (float64, error) {
+	switch p.peek() {
+	case '-':
+		p.pos++
+		v, err := p.unary()
+
+		return -v, err
+	case '+':
+		p.pos++
+		return p.unary()
+	}
+
+	return p.primary()
+}
+
+func (p *parser) primary() (float64, error) {
+	if p.peek() == '(' {
+		p.pos++
+
+		v, err := p.expr()
+		if err != nil {
+			return 0, err
+		}
+
+		if p.peek() != ')' {
+			return 0, errors.New("missing closing parenthesis")
+		}
+
+		p.pos++
+
+		return v, nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.src) && (unicode.IsDigit(rune(p.src[p.pos])) || p.src[p.pos] == '.' || p.src[p.pos] == ',') {
+		p.pos++
+	}
+
+	if start == p.pos {
+		if p.pos >= len(p.src) {
+			return 0, errors.New("unexpected end of expression")
+		}
+
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos], p.pos+1)
+	}
+
+	// thousands separators are common in quoted statistics
+	v, err := strconv.ParseFloat(strings.ReplaceAll(p.src[start:p.pos], ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+	}
+
+	if p.peek() == '%' {
+		p.pos++
+		v /= 100
+	}
+
+	return v, nil
+}
+
+// peek skips whitespace and returns the next byte, or 0 at the end.
+func (p *parser) peek() byte {
+	p.skipSpace()
+
+	if p.pos >= len(p.src) {
+		return 0
+	}
+
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
@@ -0,0 +1,80 @@
+// Package tools holds the functions the debate bot may call mid-reply.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nikoremi97/debate/internal/bot"
+	"github.com/nikoremi97/debate/internal/evidence"
+)
+
+// TopicSource looks up the curated description of a debate topic.
+type TopicSource interface {
+	TopicDescription(ctx context.Context, name string) (string, error)
+}
+
+// Debate returns the tools for a debate on topic. The evidence search is left out when there
+// is no corpus.
+func Debate(topic string, corpus evidence.Retriever, topics TopicSource) *bot.Tools {
+	tools := bot.NewTools(Calculator(), TopicDescription(topic, topics))
+
+	if corpus != nil {
+		tools.Add(EvidenceSearch(topic, corpus))
+	}
+
+	return tools
+}
+
+// EvidenceSearch looks up passages about the debate's topic in the evidence corpus.
+func EvidenceSearch(topic string, corpus evidence.Retriever) bot.Tool {
+	return bot.Tool{
+		Name:        "search_evidence",
+		Description: "Search the evidence library for passages about the debate topic. Quote facts and figures only from what it returns, naming the source.",
+		Parameters: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string", "description": "what to look for, e.g. average commute time"},
+			},
+			"required": []string{"query"},
+		},
+		Run: func(ctx context.Context, args json.RawMessage) (string, error) {
+			var in struct {
+				Query string `json:"query"`
+			}
+
+			if err := json.Unmarshal(args, &in); err != nil {
+				return "", err
+			}
+
+			passages, err := corpus.Retrieve(ctx, topic, in.Query, 3)
+			if err != nil {
+				return "", err
+			}
+
+			if len(passages) == 0 {
+				return "No passages found.", nil
+			}
+
+			var b strings.Builder
+			for _, p := range passages {
+				fmt.Fprintf(&b, "(%s) %s\n", p.Source, p.Text)
+			}
+
+			return strings.TrimSpace(b.String()), nil
+		},
+	}
+}
+
+// TopicDescription fetches the curated description of the debate's topic.
+func TopicDescription(topic string, topics TopicSource) bot.Tool {
+	return bot.Tool{
+		Name:        "get_topic_description",
+		Description: "Get the curated description of the debate topic, to check what exactly is being debated.",
+		Run: func(ctx context.Context, _ json.RawMessage) (string, error) {
+			return topics.TopicDescription(ctx, topic)
+		},
+	}
+}
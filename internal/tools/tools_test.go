@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/evidence"
+)
+
+func TestEvaluate(t *testing.T) {
+	cases := map[string]float64{
+		"1 + 2 * 3":        7,
+		"(1 + 2) * 3":      9,
+		"2 ^ 3 ^ 2":        512,
+		"-4 + +2":          -2,
+		"12% * 1,500":      180,
+		"27 * 2 * 5 / 60":  4.5,
+		"\t10 - 2 - 3 ":    5,
+		"((0.5))":          0.5,
+		"100 / 8 * 2 - -1": 26,
+	}
+
+	for expr, want := range cases {
+		got, err := Evaluate(expr)
+		if err != nil || got != want {
+			t.Fatalf("%q: expected %v, got %v (%v)", expr, want, got, err)
+		}
+	}
+
+	for _, expr := range []string{"", "1 / 0", "(1 + 2", "2 +", "3 apples", "1..2", strings.Repeat("1+", 200) + "1"} {
+		if _, err := Evaluate(expr); err == nil {
+			t.Fatalf("%q: expected an error", expr)
+		}
+	}
+}
+
+type topicSource map[string]string
+
+func (s topicSource) TopicDescription(_ context.Context, name string) (string, error) {
+	if d, ok := s[name]; ok {
+		return d, nil
+	}
+
+	return "", errors.New("topic not found")
+}
+
+func TestDebate(t *testing.T) {
+	topics := topicSource{"Remote work": "Which is more productive and beneficial?"}
+
+	if tools := Debate("Remote work", nil, topics); tools.Len() != 2 {
+		t.Fatalf("expected the calculator and topic description without a corpus, got %d tools", tools.Len())
+	}
+
+	corpus := evidence.NewIndex()
+	corpus.Add("remote-work", "remote-work/commute.md", "The average commute takes 27 minutes each way.")
+
+	tools := Debate("Remote work", corpus, topics)
+
+	if call := tools.Call(context.Background(), "search_evidence", `{"query": "commute"}`); call.Result != "(remote-work/commute.md) The average commute takes 27 minutes each way." {
+		t.Fatalf("unexpected evidence search %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "get_topic_description", ""); call.Result != "Which is more productive and beneficial?" {
+		t.Fatalf("unexpected topic description %+v", call)
+	}
+
+	if call := tools.Call(context.Background(), "calculate", `{"expression": "27 * 2"}`); call.Result != "54" {
+		t.Fatalf("unexpected calculation %+v", call)
+	}
+
+	if call := Debate("Tabs", corpus, topics).Call(context.Background(), "get_topic_description", "{}"); call.Error != "topic not found" {
+		t.Fatalf("expected an unknown topic to be reported, got %+v", call)
+	}
+}
@@ -0,0 +1,206 @@
+// Package topics normalizes free-text debate topics and matches them to the topic catalog, so
+// "AI should be regulated", "ai regulation" and "Should AI be regulated?" count as one topic.
+package topics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+// DefaultThreshold is the similarity from which a topic is taken to mean a catalog topic.
+const DefaultThreshold = 0.65
+
+// tokenThreshold is the trigram similarity from which two words count as the same, so typos
+// and other inflections still match.
+const tokenThreshold = 0.6
+
+// stopWords carry no topic of their own; "not" and "no" are among them because both sides of
+// a debate share its topic.
+var stopWords = map[string]bool{
+	"a": true, "about": true, "all": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "been": true, "by": true, "can": true, "could": true, "do": true,
+	"does": true, "for": true, "from": true, "has": true, "have": true, "how": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "more": true, "most": true,
+	"must": true, "no": true, "not": true, "of": true, "on": true, "or": true, "our": true,
+	"should": true, "so": true, "than": true, "that": true, "the": true, "their": true, "there": true,
+	"this": true, "to": true, "vs": true, "versus": true, "was": true, "we": true, "were": true,
+	"what": true, "whether": true, "which": true, "who": true, "will": true, "with": true, "would": true,
+}
+
+// suffixes are stripped, first match only, to fold inflections together: "regulated" and
+// "regulation" both become "regulat".
+var suffixes = []string{"ions", "ion", "ings", "ing", "ies", "ed", "es", "ly", "s"}
+
+// Tokens folds case, drops punctuation and stop words, and strips common suffixes. The words
+// keep their order.
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+
+		tokens = append(tokens, stem(w))
+	}
+
+	return tokens
+}
+
+// Normalize returns the canonical form of a topic: its tokens, sorted and joined by spaces.
+func Normalize(text string) string {
+	tokens := Tokens(text)
+	sort.Strings(tokens)
+
+	return strings.Join(tokens, " ")
+}
+
+func stem(w string) string {
+	for _, s := range suffixes {
+		if len(w)-len(s) >= 3 && strings.HasSuffix(w, s) && !strings.HasSuffix(w, "ss") {
+			return strings.TrimSuffix(w, s)
+		}
+	}
+
+	return w
+}
+
+// Similarity scores how alike two topics are, from 0 to 1: the share of the words of both
+// that have a counterpart in the other, by trigram similarity or as an acronym ("ai" for
+// "artificial intelligence").
+func Similarity(a, b string) float64 {
+	return similarity(Tokens(a), Tokens(b))
+}
+
+func similarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	inA, inB := make([]bool, len(a)), make([]bool, len(b))
+
+	for i := range a {
+		for j := range b {
+			if trigramSimilarity(a[i], b[j]) >= tokenThreshold {
+				inA[i], inB[j] = true, true
+			}
+		}
+	}
+
+	matchAcronyms(a, b, inA, inB)
+	matchAcronyms(b, a, inB, inA)
+
+	matched := 0
+	for _, ok := range append(inA, inB...) {
+		if ok {
+			matched++
+		}
+	}
+
+	return float64(matched) / float64(len(a)+len(b))
+}
+
+// matchAcronyms marks the short words of a that spell the initials of a run of words of b.
+func matchAcronyms(a, b []string, inA, inB []bool) {
+	for i, w := range a {
+		if len(w) < 2 || len(w) > 5 {
+			continue
+		}
+
+		for j := 0; j+len(w) <= len(b); j++ {
+			run := b[j : j+len(w)]
+
+			initials := make([]byte, len(run))
+			for k, r := range run {
+				initials[k] = r[0]
+			}
+
+			if string(initials) == w {
+				inA[i] = true
+				for k := range run {
+					inB[j+k] = true
+				}
+			}
+		}
+	}
+}
+
+// trigramSimilarity is the Jaccard similarity of the padded trigrams of two words.
+func trigramSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ta, tb := trigrams(a), trigrams(b)
+
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+func trigrams(w string) map[string]bool {
+	padded := []rune("  " + w + " ")
+	out := make(map[string]bool, len(padded))
+
+	for i := 0; i+3 <= len(padded); i++ {
+		out[string(padded[i:i+3])] = true
+	}
+
+	return out
+}
+
+// Matcher maps free-text topics to the catalog.
+type Matcher struct {
+	threshold float64
+}
+
+// NewMatcher returns a matcher accepting matches from threshold (DefaultThreshold when not
+// positive).
+func NewMatcher(threshold float64) *Matcher {
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	return &Matcher{threshold: threshold}
+}
+
+// Match finds the active catalog topic closest to text, comparing it with each topic's name
+// and description. ok is false when none is similar enough.
+func (m *Matcher) Match(catalog []models.Topic, text string) (models.TopicSuggestion, bool) {
+	tokens := Tokens(text)
+
+	var best models.TopicSuggestion
+
+	for _, t := range catalog {
+		if !t.Active {
+			continue
+		}
+
+		score := similarity(tokens, Tokens(t.Name))
+		if d := similarity(tokens, Tokens(t.Description)); d > score {
+			score = d
+		}
+
+		if score > best.Similarity {
+			best = models.TopicSuggestion{TopicID: t.ID, Name: t.Name, Similarity: score}
+		}
+	}
+
+	ok := best.Similarity >= m.threshold
+	best.Similarity = math.Round(best.Similarity*100) / 100
+
+	return best, ok
+}
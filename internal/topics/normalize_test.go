@@ -0,0 +1,68 @@
+package topics
+
+import (
+	"testing"
+
+	"github.com/nikoremi97/debate/internal/models"
+)
+
+func TestNormalize(t *testing.T) {
+	want := Normalize("AI should be regulated")
+
+	for _, text := range []string{"ai regulation", "Should AI be regulated?", "  AI, regulated! "} {
+		if got := Normalize(text); got != want {
+			t.Errorf("Normalize(%q) = %q, expected %q", text, got, want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		min  float64
+		max  float64
+	}{
+		{"AI should be regulated", "Artificial Intelligence Regulation", 1, 1},
+		{"Remote work is better than office work", "Remote Work vs Office Work", 0.85, 1},
+		{"Is social media harmful?", "Social Media Impact", 0.65, 0.7},
+		{"Should we ban social medias", "Social Media Impact", 0.65, 1},
+		{"crypto", "Electric Vehicles", 0, 0},
+		{"", "Electric Vehicles", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); got < tt.min || got > tt.max {
+			t.Errorf("Similarity(%q, %q) = %.2f, expected between %.2f and %.2f", tt.a, tt.b, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	catalog := []models.Topic{
+		{ID: "1", Name: "Artificial Intelligence Regulation", Description: "Should AI be heavily regulated by governments?", Active: true},
+		{ID: "2", Name: "Universal Basic Income", Description: "Should governments provide UBI to all citizens?", Active: true},
+		{ID: "3", Name: "Space Exploration", Active: false},
+	}
+
+	m := NewMatcher(0)
+
+	if match, ok := m.Match(catalog, "Should AI be regulated?"); !ok || match.TopicID != "1" || match.Similarity != 1 {
+		t.Fatalf("expected the AI topic, got %+v %v", match, ok)
+	}
+
+	if match, ok := m.Match(catalog, "UBI"); !ok || match.TopicID != "2" {
+		t.Fatalf("expected the description to match the acronym, got %+v %v", match, ok)
+	}
+
+	if _, ok := m.Match(catalog, "Space exploration"); ok {
+		t.Fatal("expected inactive topics to be skipped")
+	}
+
+	if match, ok := m.Match(catalog, "Pineapple on pizza"); ok {
+		t.Fatalf("expected no match, got %+v", match)
+	}
+
+	if _, ok := NewMatcher(1).Match(catalog, "Is AI regulation needed"); ok {
+		t.Fatal("expected a strict threshold to reject a partial match")
+	}
+}